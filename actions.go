@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+// The operations in this file are shared by the CLI handlers and the HTTP
+// API, so both behave the same way.  They return plain errors and leave
+// presentation to the caller.
+
+var errInvalidURL = errors.New("Incorrectly formed URL")
+
+// addFeed creates a feed owned by user and follows it.  If the feed has
+// already been added, user follows it instead, and added is false.  Either
+// way the feed and its follow are stored together or not at all.
+func addFeed(ctx context.Context, s *state, user database.User, name, feedURL string) (feed database.GetFeedDetailsRow, added bool, err error) {
+	if _, err := url.ParseRequestURI(feedURL); err != nil {
+		return database.GetFeedDetailsRow{}, false, errInvalidURL
+	}
+	err = withTx(ctx, s, func(q database.Querier) error {
+		existing, err := q.GetFeedByUrl(ctx, feedURL)
+		if errors.Is(err, sql.ErrNoRows) {
+			added = true
+			existing, err = q.CreateFeed(ctx, database.CreateFeedParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				Name:      name,
+				UserID:    user.ID,
+				Url:       feedURL,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := createFollow(ctx, q, user, existing.ID); err != nil {
+			return err
+		}
+		feed, err = q.GetFeedDetails(ctx, feedURL)
+		return err
+	})
+	return feed, added, err
+}
+
+// followFeed makes user follow the feed that has already been added at
+// feedURL.
+func followFeed(ctx context.Context, s *state, user database.User, feedURL string) (follow database.CreateFeedFollowRow, err error) {
+	err = withTx(ctx, s, func(q database.Querier) error {
+		feed, err := q.GetFeedByUrl(ctx, feedURL)
+		if err != nil {
+			return err
+		}
+		follow, err = createFollow(ctx, q, user, feed.ID)
+		return err
+	})
+	return follow, err
+}
+
+func createFollow(ctx context.Context, q database.Querier, user database.User, feedID uuid.UUID) (database.CreateFeedFollowRow, error) {
+	return q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feedID,
+	})
+}
+
+func unfollowFeed(ctx context.Context, s *state, user database.User, feedURL string) error {
+	return s.db.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{
+		Name: user.Name,
+		Url:  feedURL,
+	})
+}
+
+// checkFollowsPost returns sql.ErrNoRows, which callers report as a missing
+// post, unless the user follows the feed the post is in.
+func checkFollowsPost(ctx context.Context, s *state, userID, postID uuid.UUID) error {
+	follows, err := s.db.FollowsPost(ctx, database.FollowsPostParams{UserID: userID, PostID: postID})
+	if err == nil && !follows {
+		err = sql.ErrNoRows
+	}
+	return err
+}
+
+func setPostRead(ctx context.Context, s *state, userID, postID uuid.UUID, read bool) error {
+	if err := checkFollowsPost(ctx, s, userID, postID); err != nil {
+		return err
+	}
+	if read {
+		return s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+			UserID: userID,
+			PostID: postID,
+			ReadAt: time.Now(),
+		})
+	}
+	return s.db.MarkPostUnread(ctx, database.MarkPostUnreadParams{
+		UserID: userID,
+		PostID: postID,
+	})
+}
+
+func setPostStarred(ctx context.Context, s *state, userID, postID uuid.UUID, starred bool) error {
+	if err := checkFollowsPost(ctx, s, userID, postID); err != nil {
+		return err
+	}
+	if starred {
+		return s.db.StarPost(ctx, database.StarPostParams{
+			UserID:    userID,
+			PostID:    postID,
+			StarredAt: time.Now(),
+		})
+	}
+	return s.db.UnstarPost(ctx, database.UnstarPostParams{
+		UserID: userID,
+		PostID: postID,
+	})
+}
+
+// postQuery holds the timeline filters as the user typed them, whether they
+// came from browse's flags or the API's query string.
+type postQuery struct {
+	Limit   int
+	Feed    string
+	Since   string
+	Until   string
+	Author  string
+	Tag     string
+	Search  string
+	Folder  string
+	Unread  bool
+	Starred bool
+	Sort    string
+	After   string
+}
+
+// browseArgs returns the browse flags that repeat q, quoted for the shell.
+func (q postQuery) browseArgs() []string {
+	args := []string{"--limit", strconv.Itoa(q.Limit)}
+	for _, f := range []struct{ name, value string }{
+		{"feed", q.Feed},
+		{"since", q.Since},
+		{"until", q.Until},
+		{"author", q.Author},
+		{"tag", q.Tag},
+		{"search", q.Search},
+		{"folder", q.Folder},
+	} {
+		if f.value != "" {
+			args = append(args, "--"+f.name, shellQuote(f.value))
+		}
+	}
+	if q.Unread {
+		args = append(args, "--unread")
+	}
+	if q.Starred {
+		args = append(args, "--starred")
+	}
+	if q.Sort != "" && q.Sort != "newest" {
+		args = append(args, "--sort", shellQuote(q.Sort))
+	}
+	if q.After != "" {
+		args = append(args, "--after", q.After)
+	}
+	return args
+}
+
+// shellQuote quotes s for a POSIX shell, unless it is safe as it is.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.,:/=+@%") == "" {
+		return s
+	}
+	return zshQuote(s)
+}
+
+// postQueryFromValues reads the filters from a URL's query string, using the
+// same names as browse's flags.
+func postQueryFromValues(values url.Values, limit int) postQuery {
+	return postQuery{
+		Limit:   limit,
+		Feed:    values.Get("feed"),
+		Since:   values.Get("since"),
+		Until:   values.Get("until"),
+		Author:  values.Get("author"),
+		Tag:     values.Get("tag"),
+		Search:  values.Get("search"),
+		Folder:  values.Get("folder"),
+		Unread:  values.Get("unread") == "true",
+		Starred: values.Get("starred") == "true",
+		Sort:    values.Get("sort"),
+		After:   values.Get("after"),
+	}
+}
+
+// invalidQueryError marks problems with a postQuery, as opposed to failures
+// talking to the database.
+type invalidQueryError struct {
+	msg string
+}
+
+func (e invalidQueryError) Error() string {
+	return e.msg
+}
+
+func (q postQuery) params(userID uuid.UUID) (database.GetPostsForUserParams, error) {
+	if q.Limit < 1 {
+		return database.GetPostsForUserParams{}, invalidQueryError{"The limit must be at least 1"}
+	}
+	if q.Sort != "" && q.Sort != "newest" && q.Sort != "oldest" {
+		return database.GetPostsForUserParams{}, invalidQueryError{fmt.Sprintf("Unknown sort order %q; use newest or oldest", q.Sort)}
+	}
+	params := database.GetPostsForUserParams{
+		UserID:      userID,
+		FeedUrl:     optionalString(q.Feed),
+		Author:      optionalString(q.Author),
+		Tag:         optionalString(q.Tag),
+		Search:      optionalString(q.Search),
+		Folder:      optionalString(q.Folder),
+		UnreadOnly:  q.Unread,
+		StarredOnly: q.Starred,
+		OldestFirst: q.Sort == "oldest",
+		Limit:       int32(q.Limit),
+	}
+	var err error
+	if params.Since, err = parseDateFlag(q.Since, false); err != nil {
+		return params, invalidQueryError{err.Error()}
+	}
+	if params.Until, err = parseDateFlag(q.Until, true); err != nil {
+		return params, invalidQueryError{err.Error()}
+	}
+	if q.After != "" {
+		params.AfterDate, params.AfterID, err = decodeCursor(q.After)
+		if err != nil {
+			return params, invalidQueryError{err.Error()}
+		}
+	}
+	return params, nil
+}
+
+func newFollowRecord(row database.GetFeedFollowsForUserRow) followRecord {
+	folders := row.Folders
+	if folders == nil {
+		folders = []string{}
+	}
+	return followRecord{
+		User:       row.UserName,
+		FeedName:   row.FeedName,
+		FeedURL:    row.FeedUrl,
+		FollowedAt: row.CreatedAt,
+		Alias:      row.Alias.String,
+		Folders:    folders,
+	}
+}
+
+func newPostRecord(row database.GetPostsForUserRow) postRecord {
+	categories := row.Categories
+	if categories == nil {
+		categories = []string{}
+	}
+	return postRecord{
+		ID:          row.ID.String(),
+		Title:       row.Title,
+		URL:         row.Url,
+		Description: sanitizeHTML(row.Description.String, row.Url),
+		Author:      row.Author.String,
+		Categories:  categories,
+		Feed:        row.FeedName,
+		PublishedAt: row.SortDate,
+		DateSource:  row.DateSource,
+		Read:        row.Read,
+		Starred:     row.Starred,
+		Cursor:      encodeCursor(row.SortDate, row.ID),
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+//go:embed api/openapi.json
+var openAPISpec []byte
+
+const defaultAPIPageSize = 20
+const maxAPIPageSize = 200
+
+type apiServer struct {
+	s *state
+}
+
+type authedHandler func(w http.ResponseWriter, r *http.Request, user database.User)
+
+func handlerServe(s *state, cmd command) error {
+	if len(cmd.args) != 0 {
+		return cmd.usageError("Too many arguments.")
+	}
+	mux := newAPIServer(s).routes()
+	newWebUI(s).register(mux)
+	srv := &http.Server{
+		Addr:              cmd.flagString("addr"),
+		Handler:           logRequests(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("Serving gator on %s", srv.Addr)
+	return srv.ListenAndServe()
+}
+
+func newAPIServer(s *state) *apiServer {
+	return &apiServer{s: s}
+}
+
+func (a *apiServer) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	})
+	mux.HandleFunc("POST /api/v1/sessions", a.handleLogin)
+	mux.HandleFunc("DELETE /api/v1/sessions", a.authenticated(a.handleLogout))
+	mux.HandleFunc("GET /api/v1/me", a.authenticated(a.handleMe))
+	mux.HandleFunc("GET /api/v1/users", a.authenticated(a.handleUsers))
+	mux.HandleFunc("GET /api/v1/feeds", a.authenticated(a.handleFeeds))
+	mux.HandleFunc("POST /api/v1/feeds", a.authenticated(a.handleAddFeed))
+	mux.HandleFunc("GET /api/v1/follows", a.authenticated(a.handleFollows))
+	mux.HandleFunc("POST /api/v1/follows", a.authenticated(a.handleFollow))
+	mux.HandleFunc("DELETE /api/v1/follows", a.authenticated(a.handleUnfollow))
+	mux.HandleFunc("GET /api/v1/posts", a.authenticated(a.handlePosts))
+	mux.HandleFunc("POST /api/v1/posts/{id}/read", a.authenticated(a.handlePostState(setPostRead, true)))
+	mux.HandleFunc("DELETE /api/v1/posts/{id}/read", a.authenticated(a.handlePostState(setPostRead, false)))
+	mux.HandleFunc("POST /api/v1/posts/{id}/star", a.authenticated(a.handlePostState(setPostStarred, true)))
+	mux.HandleFunc("DELETE /api/v1/posts/{id}/star", a.authenticated(a.handlePostState(setPostStarred, false)))
+	mux.HandleFunc("GET /saved/feed.atom", a.handleSavedFeed("atom"))
+	mux.HandleFunc("GET /saved/feed.rss", a.handleSavedFeed("rss"))
+	mux.HandleFunc("/fever", a.handleFever)
+	mux.HandleFunc("/fever/", a.handleFever)
+	return mux
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Printf("%s %s (%v)", r.Method, r.URL.Path, time.Since(start))
+	})
+}
+
+// authenticated is the API's counterpart to middlewareLoggedIn: it looks up
+// the user from the "Authorization: Bearer <token>" header, where the token
+// is either an API key or a session token.
+func (a *apiServer) authenticated(handler authedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "Missing API key or session token")
+			return
+		}
+		user, err := a.s.db.GetUserByAPIKey(r.Context(), hashToken(token))
+		if errors.Is(err, sql.ErrNoRows) {
+			user, err = userForSession(r.Context(), a.s, token)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusUnauthorized, "Invalid API key or session token")
+			return
+		}
+		if err != nil {
+			respondWithDBError(w, err)
+			return
+		}
+		handler(w, r, user)
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token, ok && token != ""
+}
+
+type sessionResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (a *apiServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Expected a JSON body with name and password")
+		return
+	}
+	user, err := checkPassword(r.Context(), a.s, body.Name, body.Password)
+	if errors.Is(err, errBadLogin) || errors.Is(err, errNoPassword) {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	token, expires, err := startSession(r.Context(), a.s, user)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, sessionResponse{Token: token, ExpiresAt: expires})
+}
+
+// handleLogout ends the session the request was made with.  API keys are
+// not sessions, so there is nothing to end for them.
+func (a *apiServer) handleLogout(w http.ResponseWriter, r *http.Request, user database.User) {
+	token, _ := bearerToken(r)
+	if err := endSession(r.Context(), a.s, token); err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiServer) handleMe(w http.ResponseWriter, r *http.Request, user database.User) {
+	respondWithJSON(w, http.StatusOK, userRecord{
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
+		Current:   true,
+		Role:      user.Role,
+	})
+}
+
+func (a *apiServer) handleUsers(w http.ResponseWriter, r *http.Request, user database.User) {
+	users, err := a.s.db.GetAllUsers(r.Context())
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	records := make([]userRecord, 0, len(users))
+	for _, u := range users {
+		records = append(records, userRecord{
+			Name:      u.Name,
+			CreatedAt: u.CreatedAt,
+			Current:   u.ID == user.ID,
+			Role:      u.Role,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, records)
+}
+
+func (a *apiServer) handleFeeds(w http.ResponseWriter, r *http.Request, user database.User) {
+	feeds, err := a.s.db.GetAllFeeds(r.Context())
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	records := make([]feedRecord, 0, len(feeds))
+	for _, feed := range feeds {
+		records = append(records, feedRecord{
+			Name:  feed.Name,
+			URL:   feed.Url,
+			Owner: feed.Owner,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, records)
+}
+
+func (a *apiServer) handleAddFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	var body struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Expected a JSON body with name and url")
+		return
+	}
+	feed, added, err := addFeed(r.Context(), a.s, user, body.Name, body.URL)
+	if errors.Is(err, errInvalidURL) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	status := http.StatusCreated
+	if !added {
+		status = http.StatusOK
+	}
+	respondWithJSON(w, status, feedRecord{
+		Name:  feed.Name,
+		URL:   feed.Url,
+		Owner: feed.Owner,
+	})
+}
+
+func (a *apiServer) handleFollows(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := a.s.db.GetFeedFollowsForUser(r.Context(), user.Name)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	records := make([]followRecord, 0, len(follows))
+	for _, follow := range follows {
+		records = append(records, newFollowRecord(follow))
+	}
+	respondWithJSON(w, http.StatusOK, records)
+}
+
+func (a *apiServer) handleFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.URL == "" {
+		respondWithError(w, http.StatusBadRequest, "Expected a JSON body with url")
+		return
+	}
+	follow, err := followFeed(r.Context(), a.s, user, body.URL)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, followRecord{
+		User:       follow.UserName,
+		FeedName:   follow.FeedName,
+		FeedURL:    body.URL,
+		FollowedAt: follow.CreatedAt,
+		Folders:    []string{},
+	})
+}
+
+func (a *apiServer) handleUnfollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedURL := r.URL.Query().Get("url")
+	if feedURL == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing url parameter")
+		return
+	}
+	if err := unfollowFeed(r.Context(), a.s, user, feedURL); err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type postsPage struct {
+	Posts      []postRecord `json:"posts"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+func (a *apiServer) handlePosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	values := r.URL.Query()
+	query := postQueryFromValues(values, defaultAPIPageSize)
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n > maxAPIPageSize {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be a number up to %d", maxAPIPageSize))
+			return
+		}
+		query.Limit = n
+	}
+	params, err := query.params(user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rows, err := database.GetPostsForUser(r.Context(), a.s.db, params)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	page := postsPage{Posts: make([]postRecord, 0, len(rows))}
+	for _, row := range rows {
+		page.Posts = append(page.Posts, newPostRecord(row))
+	}
+	if len(rows) == query.Limit {
+		page.NextCursor = page.Posts[len(page.Posts)-1].Cursor
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handlePostState serves the read and star endpoints, which differ only in
+// the shared operation they call.
+func (a *apiServer) handlePostState(set func(context.Context, *state, uuid.UUID, uuid.UUID, bool) error, value bool) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) {
+		postID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid post id")
+			return
+		}
+		if err := set(r.Context(), a.s, user.ID, postID, value); err != nil {
+			respondWithDBError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshalling JSON: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+func respondWithError(w http.ResponseWriter, code int, msg string) {
+	respondWithJSON(w, code, map[string]string{"error": msg})
+}
+
+// respondWithQueryError reports an invalidQueryError as a bad request and
+// anything else as a database error.
+func respondWithQueryError(w http.ResponseWriter, err error) {
+	var invalid invalidQueryError
+	if errors.As(err, &invalid) {
+		respondWithError(w, http.StatusBadRequest, invalid.Error())
+		return
+	}
+	respondWithDBError(w, err)
+}
+
+// respondWithDBError reports a database error with the status picked by
+// dbErrorStatus.
+func respondWithDBError(w http.ResponseWriter, err error) {
+	code, msg := dbErrorStatus(err)
+	respondWithError(w, code, msg)
+}
+
+// dbErrorStatus maps database errors onto HTTP statuses: missing rows and
+// references to missing rows are 404s, duplicates are 409s and anything else
+// is logged and reported as a 500.
+func dbErrorStatus(err error) (int, string) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return http.StatusNotFound, "Not found"
+	}
+	if isUniqueViolation(err) {
+		return http.StatusConflict, "Already exists"
+	}
+	if isForeignKeyViolation(err) {
+		return http.StatusNotFound, "Not found"
+	}
+	log.Printf("Database error: %v", err)
+	return http.StatusInternalServerError, "Something went wrong"
+}
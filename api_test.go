@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/interyx/gator/internal/database"
+)
+
+// apiEnv serves the API from a testEnv's state.
+type apiEnv struct {
+	*testEnv
+	srv *httptest.Server
+}
+
+// newAPIEnv sets up bob and alice, and alice's feed with two posts, and
+// returns the API keys they were given.
+func newAPIEnv(t *testing.T) (e *apiEnv, aliceKey, bobKey string) {
+	env := newTestEnv(t)
+	env.setUp()
+	env.addPosts("https://example.com/feed", "First", "Second")
+	aliceKey = strings.TrimSpace(env.mustRun("", "apikey", "--rotate"))
+	env.mustRun("password1\n", "login", "bob")
+	bobKey = strings.TrimSpace(env.mustRun("", "apikey", "--rotate"))
+	srv := httptest.NewServer(newAPIServer(env.s).routes())
+	t.Cleanup(srv.Close)
+	return &apiEnv{testEnv: env, srv: srv}, aliceKey, bobKey
+}
+
+// call makes a request with token as its bearer token, if there is one, and
+// decodes the JSON response into out, if it is not nil.  It returns the
+// status.
+func (e *apiEnv) call(method, path, token, body string, out any) int {
+	e.t.Helper()
+	req, err := http.NewRequest(method, e.srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.Unmarshal(data, out); err != nil {
+			e.t.Fatalf("%s %s: %v in %s", method, path, err, data)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	e, aliceKey, _ := newAPIEnv(t)
+	for _, token := range []string{"", "not-a-key"} {
+		if code := e.call("GET", "/api/v1/me", token, "", nil); code != http.StatusUnauthorized {
+			t.Errorf("GET /api/v1/me with token %q: %d, want 401", token, code)
+		}
+	}
+	var me userRecord
+	if code := e.call("GET", "/api/v1/me", aliceKey, "", &me); code != http.StatusOK || me.Name != "alice" {
+		t.Errorf("GET /api/v1/me with alice's key: %d, %+v", code, me)
+	}
+
+	if code := e.call("POST", "/api/v1/sessions", "", `{"name": "alice", "password": "wrong"}`, nil); code != http.StatusUnauthorized {
+		t.Errorf("logging in with the wrong password: %d, want 401", code)
+	}
+	if code := e.call("POST", "/api/v1/sessions", "", `not json`, nil); code != http.StatusBadRequest {
+		t.Errorf("logging in without a body: %d, want 400", code)
+	}
+	var session sessionResponse
+	if code := e.call("POST", "/api/v1/sessions", "", `{"name": "alice", "password": "password2"}`, &session); code != http.StatusCreated || session.Token == "" {
+		t.Fatalf("logging in: %d, %+v", code, session)
+	}
+	if code := e.call("GET", "/api/v1/me", session.Token, "", &me); code != http.StatusOK || me.Name != "alice" {
+		t.Errorf("GET /api/v1/me with a session: %d, %+v", code, me)
+	}
+	if code := e.call("DELETE", "/api/v1/sessions", session.Token, "", nil); code != http.StatusNoContent {
+		t.Errorf("logging out: %d, want 204", code)
+	}
+	if code := e.call("GET", "/api/v1/me", session.Token, "", nil); code != http.StatusUnauthorized {
+		t.Errorf("GET /api/v1/me after logging out: %d, want 401", code)
+	}
+}
+
+func TestAPIPosts(t *testing.T) {
+	e, aliceKey, bobKey := newAPIEnv(t)
+	var page postsPage
+	if code := e.call("GET", "/api/v1/posts?limit=1", aliceKey, "", &page); code != http.StatusOK {
+		t.Fatalf("GET /api/v1/posts: %d", code)
+	}
+	if len(page.Posts) != 1 || page.Posts[0].Title != "Second" || page.NextCursor == "" {
+		t.Fatalf("first page = %+v", page)
+	}
+	second := page.Posts[0].ID
+	if code := e.call("GET", "/api/v1/posts?limit=1&after="+url.QueryEscape(page.NextCursor), aliceKey, "", &page); code != http.StatusOK {
+		t.Fatalf("GET /api/v1/posts: %d", code)
+	}
+	if len(page.Posts) != 1 || page.Posts[0].Title != "First" {
+		t.Errorf("second page = %+v", page)
+	}
+	for _, query := range []string{"limit=abc", "limit=1000", "since=yesterday", "sort=sideways"} {
+		if code := e.call("GET", "/api/v1/posts?"+query, aliceKey, "", nil); code != http.StatusBadRequest {
+			t.Errorf("GET /api/v1/posts?%s: %d, want 400", query, code)
+		}
+	}
+
+	if code := e.call("POST", "/api/v1/posts/"+second+"/read", aliceKey, "", nil); code != http.StatusNoContent {
+		t.Errorf("marking a post read: %d, want 204", code)
+	}
+	if code := e.call("POST", "/api/v1/posts/"+second+"/star", aliceKey, "", nil); code != http.StatusNoContent {
+		t.Errorf("starring a post: %d, want 204", code)
+	}
+	e.call("GET", "/api/v1/posts?unread=true", aliceKey, "", &page)
+	if len(page.Posts) != 1 || page.Posts[0].Title != "First" {
+		t.Errorf("unread posts = %+v", page.Posts)
+	}
+	e.call("GET", "/api/v1/posts?starred=true", aliceKey, "", &page)
+	if len(page.Posts) != 1 || page.Posts[0].ID != second || !page.Posts[0].Read {
+		t.Errorf("starred posts = %+v", page.Posts)
+	}
+	if code := e.call("DELETE", "/api/v1/posts/"+second+"/read", aliceKey, "", nil); code != http.StatusNoContent {
+		t.Errorf("marking a post unread: %d, want 204", code)
+	}
+	if code := e.call("POST", "/api/v1/posts/not-an-id/read", aliceKey, "", nil); code != http.StatusBadRequest {
+		t.Errorf("marking an invalid id read: %d, want 400", code)
+	}
+	if code := e.call("POST", "/api/v1/posts/00000000-0000-0000-0000-000000000000/star", aliceKey, "", nil); code != http.StatusNotFound {
+		t.Errorf("starring a missing post: %d, want 404", code)
+	}
+
+	// Bob cannot mark posts in feeds he does not follow, which would tell
+	// him they exist.
+	for _, path := range []string{"/read", "/star"} {
+		for _, method := range []string{"POST", "DELETE"} {
+			if code := e.call(method, "/api/v1/posts/"+second+path, bobKey, "", nil); code != http.StatusNotFound {
+				t.Errorf("%s %s on a post bob does not follow: %d, want 404", method, path, code)
+			}
+		}
+	}
+	if code := e.call("POST", "/api/v1/follows", bobKey, `{"url": "https://example.com/feed"}`, nil); code != http.StatusCreated {
+		t.Fatalf("following: %d, want 201", code)
+	}
+	if code := e.call("POST", "/api/v1/posts/"+second+"/star", bobKey, "", nil); code != http.StatusNoContent {
+		t.Errorf("starring a post bob follows: %d, want 204", code)
+	}
+	e.call("GET", "/api/v1/posts?starred=true", bobKey, "", &page)
+	if len(page.Posts) != 1 || page.Posts[0].Read {
+		t.Errorf("bob's starred posts = %+v", page.Posts)
+	}
+}
+
+func TestAPIFeedsAndFollows(t *testing.T) {
+	e, _, bobKey := newAPIEnv(t)
+	var feeds []feedRecord
+	if code := e.call("GET", "/api/v1/feeds", bobKey, "", &feeds); code != http.StatusOK || len(feeds) != 1 || feeds[0].Owner != "alice" {
+		t.Errorf("GET /api/v1/feeds: %d, %+v", code, feeds)
+	}
+	var feed feedRecord
+	if code := e.call("POST", "/api/v1/feeds", bobKey, `{"name": "News", "url": "https://example.com/news"}`, &feed); code != http.StatusCreated || feed.Owner != "bob" {
+		t.Errorf("adding a feed: %d, %+v", code, feed)
+	}
+	if code := e.call("POST", "/api/v1/feeds", bobKey, `{"name": "Bad", "url": "not a url"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("adding a feed with a bad URL: %d, want 400", code)
+	}
+
+	if code := e.call("POST", "/api/v1/follows", bobKey, `{"url": "https://example.com/feed"}`, nil); code != http.StatusCreated {
+		t.Errorf("following: %d, want 201", code)
+	}
+	if code := e.call("POST", "/api/v1/follows", bobKey, `{"url": "https://example.com/feed"}`, nil); code != http.StatusConflict {
+		t.Errorf("following twice: %d, want 409", code)
+	}
+	if code := e.call("POST", "/api/v1/follows", bobKey, `{"url": "https://example.com/missing"}`, nil); code != http.StatusNotFound {
+		t.Errorf("following a missing feed: %d, want 404", code)
+	}
+	var follows []followRecord
+	if code := e.call("GET", "/api/v1/follows", bobKey, "", &follows); code != http.StatusOK || len(follows) != 2 {
+		t.Errorf("GET /api/v1/follows: %d, %+v", code, follows)
+	}
+	if code := e.call("DELETE", "/api/v1/follows?url="+url.QueryEscape("https://example.com/feed"), bobKey, "", nil); code != http.StatusNoContent {
+		t.Errorf("unfollowing: %d, want 204", code)
+	}
+	if code := e.call("DELETE", "/api/v1/follows", bobKey, "", nil); code != http.StatusBadRequest {
+		t.Errorf("unfollowing without a url: %d, want 400", code)
+	}
+	e.call("GET", "/api/v1/follows", bobKey, "", &follows)
+	if len(follows) != 1 || follows[0].FeedURL != "https://example.com/news" {
+		t.Errorf("after unfollowing: %+v", follows)
+	}
+}
+
+// feverResponse holds the parts of a Fever response the tests look at.
+type feverResponse struct {
+	Auth          int         `json:"auth"`
+	LastRefreshed int64       `json:"last_refreshed_on_time"`
+	Feeds         []feverFeed `json:"feeds"`
+	Items         []feverItem `json:"items"`
+	TotalItems    int64       `json:"total_items"`
+	UnreadItemIDs string      `json:"unread_item_ids"`
+	SavedItemIDs  string      `json:"saved_item_ids"`
+}
+
+func TestFever(t *testing.T) {
+	e, aliceKey, bobKey := newAPIEnv(t)
+	feverKey := func(name, key string) string {
+		sum := md5.Sum([]byte(name + ":" + key))
+		return hex.EncodeToString(sum[:])
+	}
+	// fever makes a request with the given query and key, which Fever
+	// clients post as a form.
+	fever := func(query, key string) (int, feverResponse) {
+		t.Helper()
+		form := url.Values{"api_key": {key}}
+		resp, err := http.Post(e.srv.URL+"/fever/?api&"+query, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body feverResponse
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode, body
+	}
+	alice := feverKey("alice", aliceKey)
+
+	if _, resp := fever("", ""); resp.Auth != 0 {
+		t.Errorf("without a key: auth %d, want 0", resp.Auth)
+	}
+	if _, resp := fever("", feverKey("alice", bobKey)); resp.Auth != 0 {
+		t.Errorf("with the wrong key: auth %d, want 0", resp.Auth)
+	}
+	code, resp := fever("feeds&items&unread_item_ids", alice)
+	if code != http.StatusOK || resp.Auth != 1 {
+		t.Fatalf("with alice's key: %d, auth %d", code, resp.Auth)
+	}
+	if len(resp.Feeds) != 1 || resp.Feeds[0].URL != "https://example.com/feed" {
+		t.Errorf("feeds = %+v", resp.Feeds)
+	}
+	if len(resp.Items) != 2 || resp.TotalItems != 2 || resp.Items[0].Title != "First" || resp.Items[0].FeedID != resp.Feeds[0].ID {
+		t.Fatalf("items = %+v, total %d", resp.Items, resp.TotalItems)
+	}
+	first, second := resp.Items[0].ID, resp.Items[1].ID
+	if want := joinSeqs([]int64{first, second}); resp.UnreadItemIDs != want {
+		t.Errorf("unread_item_ids = %q, want %q", resp.UnreadItemIDs, want)
+	}
+	_, resp = fever("items&since_id="+seqString(first), alice)
+	if len(resp.Items) != 1 || resp.Items[0].ID != second {
+		t.Errorf("items since %d = %+v", first, resp.Items)
+	}
+	if code, _ := fever("items&max_id=x", alice); code != http.StatusBadRequest {
+		t.Errorf("items with a bad max_id: %d, want 400", code)
+	}
+
+	_, resp = fever("mark=item&as=read&id="+seqString(first), alice)
+	if resp.UnreadItemIDs != seqString(second) {
+		t.Errorf("after marking %d read: unread_item_ids = %q", first, resp.UnreadItemIDs)
+	}
+	_, resp = fever("mark=item&as=saved&id="+seqString(second), alice)
+	if resp.SavedItemIDs != seqString(second) {
+		t.Errorf("after saving %d: saved_item_ids = %q", second, resp.SavedItemIDs)
+	}
+	if code, _ := fever("mark=item&as=read&id=999", alice); code != http.StatusNotFound {
+		t.Errorf("marking a missing item: %d, want 404", code)
+	}
+
+	// last_refreshed_on_time is when the feeds were last fetched, which
+	// renaming a feed does not change.
+	if resp.LastRefreshed != 0 {
+		t.Errorf("last_refreshed_on_time = %d before any fetch, want 0", resp.LastRefreshed)
+	}
+	e.mustRun("", "feed", "rename", "https://example.com/feed", "Renamed")
+	if _, resp = fever("", alice); resp.LastRefreshed != 0 {
+		t.Errorf("last_refreshed_on_time = %d after a rename, want 0", resp.LastRefreshed)
+	}
+	feed, err := e.s.db.GetFeedByUrl(context.Background(), "https://example.com/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().Unix()
+	if err := e.s.db.MarkFeedFetched(context.Background(), database.MarkFeedFetchedParams{ID: feed.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, resp = fever("", alice); resp.LastRefreshed < before || resp.LastRefreshed > time.Now().Unix() {
+		t.Errorf("last_refreshed_on_time = %d after a fetch at %d", resp.LastRefreshed, before)
+	}
+
+	// Bob follows nothing, so sees no items and can mark none.
+	bob := feverKey("bob", bobKey)
+	_, resp = fever("items", bob)
+	if resp.Auth != 1 || len(resp.Items) != 0 {
+		t.Errorf("bob's items = %+v", resp.Items)
+	}
+	if code, _ := fever("mark=item&as=saved&id="+seqString(first), bob); code != http.StatusNotFound {
+		t.Errorf("bob saving an item he does not follow: %d, want 404", code)
+	}
+	_, resp = fever("saved_item_ids", alice)
+	if resp.SavedItemIDs != seqString(second) {
+		t.Errorf("alice's saved_item_ids = %q after bob's request", resp.SavedItemIDs)
+	}
+}
+
+func seqString(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/interyx/gator/internal/database"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+)
+
+// Accounts are protected by bcrypt password hashes.  Logging in starts a
+// session: a random token whose SHA-256 is kept in the sessions table.  The
+// CLI stores the token in ~/.gatorconfig.json, the web reader in a cookie,
+// and API clients send it as a bearer token.
+
+// Roles.  Admins may run the commands that affect other users' data.
+const (
+	roleAdmin  = "admin"
+	roleMember = "member"
+)
+
+const sessionLifetime = 30 * 24 * time.Hour
+const minPasswordLength = 8
+
+var errBadLogin = errors.New("Unknown user name or wrong password")
+
+// errNoPassword is returned for accounts created before passwords existed.
+// Nobody can log in to them until an admin sets a password.
+var errNoPassword = errors.New("This account has no password; an admin must set one with `gator passwd <name>`")
+
+func hashPassword(password string) (sql.NullString, error) {
+	if len(password) < minPasswordLength {
+		return sql.NullString{}, fmt.Errorf("Passwords must be at least %d characters long", minPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return sql.NullString{}, errors.New("Passwords can be at most 72 bytes long")
+	}
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(hash), Valid: true}, nil
+}
+
+// checkPassword returns the named user if password is theirs.
+func checkPassword(ctx context.Context, s *state, name, password string) (database.User, error) {
+	user, err := s.db.GetUser(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, errBadLogin
+	}
+	if err != nil {
+		return database.User{}, err
+	}
+	if !user.PasswordHash.Valid {
+		return user, errNoPassword
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash.String), []byte(password)) != nil {
+		return database.User{}, errBadLogin
+	}
+	return user, nil
+}
+
+// startSession creates a session for user and returns its token.
+func startSession(ctx context.Context, s *state, user database.User) (string, time.Time, error) {
+	// Expired sessions are only ever looked up to be refused, so this is as
+	// good a time as any to clear them out.
+	if err := s.db.DeleteExpiredSessions(ctx); err != nil {
+		return "", time.Time{}, err
+	}
+	token, err := newToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := time.Now().Add(sessionLifetime)
+	err = s.db.CreateSession(ctx, database.CreateSessionParams{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+		ExpiresAt: expires,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expires, nil
+}
+
+func userForSession(ctx context.Context, s *state, token string) (database.User, error) {
+	return s.db.GetUserBySession(ctx, hashToken(token))
+}
+
+func endSession(ctx context.Context, s *state, token string) error {
+	return s.db.DeleteSession(ctx, hashToken(token))
+}
+
+// newToken returns a random token for a session or a published feed.
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+var passwordInput *bufio.Reader
+
+// readPassword prompts for a password without echoing it.  When stdin is not
+// a terminal, it reads one line instead, so scripts can pipe passwords in.
+func readPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(password), err
+	}
+	if passwordInput == nil {
+		passwordInput = bufio.NewReader(os.Stdin)
+	}
+	line, err := passwordInput.ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("Expected a password on standard input")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readNewPassword prompts for a new password, asking twice on a terminal to
+// catch typos, and returns its hash.
+func readNewPassword() (sql.NullString, error) {
+	password, err := readPassword("New password: ")
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		again, err := readPassword("Repeat the password: ")
+		if err != nil {
+			return sql.NullString{}, err
+		}
+		if again != password {
+			return sql.NullString{}, errors.New("The passwords do not match")
+		}
+	}
+	return hashPassword(password)
+}
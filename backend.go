@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/interyx/gator/internal/database"
+	"github.com/interyx/gator/internal/memstore"
+	"github.com/interyx/gator/internal/sqlite"
+	"github.com/lib/pq"
+)
+
+// backend describes one of the databases gator can store its data in.  The
+// db_url in the config picks it: "sqlite:<path>" opens a SQLite file, and
+// anything else is passed to Postgres.
+type backend struct {
+	name string
+	// schemaDir holds the backend's migrations within schemaFiles.
+	schemaDir string
+	// tableExists is a query reporting whether the table named by its one
+	// argument exists.
+	tableExists string
+	// versionTableDDL creates goose's version table.
+	versionTableDDL string
+	// queries returns the backend's queries run through db, which is the
+	// connection pool or a transaction.
+	queries func(db database.DBTX) database.Querier
+}
+
+var postgresBackend = backend{
+	name:            "postgres",
+	schemaDir:       "sql/schema",
+	tableExists:     "SELECT to_regclass($1) IS NOT NULL",
+	versionTableDDL: "id serial NOT NULL, version_id bigint NOT NULL, is_applied boolean NOT NULL, tstamp timestamp NULL DEFAULT now(), PRIMARY KEY(id)",
+	queries: func(db database.DBTX) database.Querier {
+		return database.New(db)
+	},
+}
+
+var sqliteBackend = backend{
+	name:            "sqlite",
+	schemaDir:       "sql/sqlite/schema",
+	tableExists:     "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = $1)",
+	versionTableDDL: "id INTEGER PRIMARY KEY AUTOINCREMENT, version_id INTEGER NOT NULL, is_applied INTEGER NOT NULL, tstamp TIMESTAMP DEFAULT (datetime('now'))",
+	queries: func(db database.DBTX) database.Querier {
+		return sqlite.NewStore(db)
+	},
+}
+
+// openDatabase opens the database named by dbURL and returns the queries to
+// run against it.
+func openDatabase(dbURL string) (*sql.DB, database.Querier, backend, error) {
+	if path, ok := sqlitePath(dbURL); ok {
+		conn, err := sqlite.Open(path)
+		if err != nil {
+			return nil, nil, backend{}, err
+		}
+		return conn, sqliteBackend.queries(conn), sqliteBackend, nil
+	}
+	conn, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return nil, nil, backend{}, err
+	}
+	return conn, postgresBackend.queries(conn), postgresBackend, nil
+}
+
+// withTx runs fn with queries that share one transaction, which is committed
+// if fn succeeds and rolled back if it fails.  The in-memory store used when
+// there is no connection has no transactions, so fn runs on it directly.
+func withTx(ctx context.Context, s *state, fn func(q database.Querier) error) error {
+	if s.conn == nil {
+		return fn(s.db)
+	}
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(s.backend.queries(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func sqlitePath(dbURL string) (string, bool) {
+	if path, ok := strings.CutPrefix(dbURL, "sqlite://"); ok {
+		return path, true
+	}
+	return strings.CutPrefix(dbURL, "sqlite:")
+}
+
+// isUniqueViolation reports whether err is a duplicate key error from any
+// backend.
+func isUniqueViolation(err error) bool {
+	if errors.Is(err, memstore.ErrUniqueViolation) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Name() == "unique_violation"
+	}
+	code := sqlite.ErrorCode(err)
+	return code == sqlite.ErrConstraintUnique || code == sqlite.ErrConstraintPrimaryKey
+}
+
+// isForeignKeyViolation reports whether err is a reference to a missing row
+// from any backend.
+func isForeignKeyViolation(err error) bool {
+	if errors.Is(err, memstore.ErrForeignKeyViolation) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Name() == "foreign_key_violation"
+	}
+	return sqlite.ErrorCode(err) == sqlite.ErrConstraintForeignKey
+}
@@ -1,21 +1,27 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/xml"
+	"errors"
+	"flag"
 	"fmt"
 	"html"
 	"io"
+	"math"
 	"net/http"
-	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/interyx/gator/internal/database"
-	"github.com/lib/pq"
+	"golang.org/x/term"
 )
 
 func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
@@ -44,6 +50,8 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	for i := range feed.Channel.Item {
 		feed.Channel.Item[i].Title = html.UnescapeString(feed.Channel.Item[i].Title)
 		feed.Channel.Item[i].Description = html.UnescapeString(feed.Channel.Item[i].Description)
+		feed.Channel.Item[i].Author = html.UnescapeString(feed.Channel.Item[i].Author)
+		feed.Channel.Item[i].Creator = html.UnescapeString(feed.Channel.Item[i].Creator)
 	}
 
 	return &feed, nil
@@ -51,120 +59,310 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 
 func handlerLogin(s *state, cmd command) error {
 	ctx := context.Background()
-	if len(cmd.args) == 0 {
-		return fmt.Errorf("Login requires at least one argument\n")
+	if len(cmd.args) != 1 {
+		return cmd.usageError("Login requires a username.")
 	}
-	username := cmd.args[0]
-	user, err := s.db.GetUser(ctx, username)
+	password, err := readPassword("Password: ")
 	if err != nil {
-		return fmt.Errorf("No user with that name found")
+		return err
+	}
+	user, err := checkPassword(ctx, s, cmd.args[0], password)
+	if err != nil {
+		return err
+	}
+	if s.cfg.SessionToken != "" {
+		if err := endSession(ctx, s, s.cfg.SessionToken); err != nil {
+			return err
+		}
+	}
+	token, _, err := startSession(ctx, s, user)
+	if err != nil {
+		return err
 	}
-	err = s.cfg.SetUser(user.Name)
+	err = s.cfg.SetSession(user.Name, token)
 	if err != nil {
 		return fmt.Errorf("An error occurred: %v\n", err)
 	}
-	fmt.Printf("%s was logged in successfully!\n", cmd.args[0])
+	fmt.Fprintf(s.out, "%s was logged in successfully!\n", user.Name)
+	return nil
+}
+
+func handlerLogout(s *state, cmd command) error {
+	if len(cmd.args) != 0 {
+		return cmd.usageError("Too many arguments.")
+	}
+	if s.cfg.SessionToken == "" {
+		return errors.New("You are not logged in")
+	}
+	if err := endSession(context.Background(), s, s.cfg.SessionToken); err != nil {
+		return err
+	}
+	if err := s.cfg.SetSession("", ""); err != nil {
+		return err
+	}
+	fmt.Fprintln(s.out, "Logged out")
 	return nil
 }
 
 func handlerRegister(s *state, cmd command) error {
-	if len(cmd.args) == 0 {
-		return fmt.Errorf("Registration requires a name argument.")
+	if len(cmd.args) != 1 {
+		return cmd.usageError("Registration requires a name argument.")
+	}
+	role := roleMember
+	if cmd.flagBool("admin") {
+		role = roleAdmin
 	}
-	params := database.CreateUserParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Name:      cmd.args[0],
+	hash, err := readNewPassword()
+	if err != nil {
+		return err
 	}
 	ctx := context.Background()
-	newUser, err := s.db.CreateUser(ctx, params)
+	var newUser database.User
+	err = withTx(ctx, s, func(q database.Querier) error {
+		// The first admin has to be asked for, so that nobody becomes one
+		// just by registering first; after that, only admins make admins.
+		admins, err := q.CountAdmins(ctx)
+		if err != nil {
+			return err
+		}
+		if admins == 0 && role != roleAdmin {
+			return errors.New("There is no admin yet; register the first one with `gator register --admin <name>`")
+		}
+		if admins > 0 && role == roleAdmin {
+			return errors.New("There is already an admin; ask them to run `gator role <name> admin`")
+		}
+		newUser, err = q.CreateUser(ctx, database.CreateUserParams{
+			ID:           uuid.New(),
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+			Name:         cmd.args[0],
+			PasswordHash: hash,
+			Role:         role,
+		})
+		return err
+	})
+	if isUniqueViolation(err) {
+		return fmt.Errorf("The name %s is taken", cmd.args[0])
+	}
+	if err != nil {
+		return err
+	}
+	token, _, err := startSession(ctx, s, newUser)
 	if err != nil {
 		return err
 	}
-	err = s.cfg.SetUser(newUser.Name)
+	err = s.cfg.SetSession(newUser.Name, token)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("User %s was created!\nParams %v\n", newUser.Name, params)
+	fmt.Fprintf(s.out, "User %s was created and logged in!\n", newUser.Name)
+	if role == roleAdmin {
+		fmt.Fprintln(s.out, "They are this installation's admin.")
+	}
+	return nil
+}
+
+func handlerPasswd(s *state, cmd command, current database.User) error {
+	if len(cmd.args) > 1 {
+		return cmd.usageError("Too many arguments.")
+	}
+	ctx := context.Background()
+	user := current
+	if len(cmd.args) == 1 && cmd.args[0] != current.Name {
+		// Admins set other users' passwords without knowing the old ones,
+		// which is how accounts from before passwords get their first.
+		if current.Role != roleAdmin {
+			return errors.New("Only admins can set other users' passwords")
+		}
+		var err error
+		if user, err = s.db.GetUser(ctx, cmd.args[0]); err != nil {
+			return fmt.Errorf("No user with that name found")
+		}
+	} else {
+		password, err := readPassword("Current password: ")
+		if err != nil {
+			return err
+		}
+		if _, err := checkPassword(ctx, s, user.Name, password); err != nil {
+			if errors.Is(err, errNoPassword) {
+				return err
+			}
+			return errors.New("Wrong password")
+		}
+	}
+	hash, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+	// Anyone who knew the old password may still be logged in elsewhere.
+	// Another user's sessions all go; your own, except this one.
+	keep := ""
+	if user.ID == current.ID {
+		keep = hashToken(s.cfg.SessionToken)
+	}
+	err = withTx(ctx, s, func(q database.Querier) error {
+		err := q.SetPassword(ctx, database.SetPasswordParams{
+			ID:           user.ID,
+			PasswordHash: hash,
+		})
+		if err != nil {
+			return err
+		}
+		return q.DeleteOtherSessions(ctx, database.DeleteOtherSessionsParams{
+			UserID:    user.ID,
+			TokenHash: keep,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if user.ID != current.ID {
+		fmt.Fprintf(s.out, "Password set for %s; their sessions have been logged out.\n", user.Name)
+		return nil
+	}
+	fmt.Fprintln(s.out, "Password changed; your other sessions have been logged out.")
 	return nil
 }
 
 func handlerAddFeed(s *state, cmd command, user database.User) error {
 	if len(cmd.args) != 2 {
-		return fmt.Errorf("Wrong number of arguments.\nUSAGE: addfeed \"<feed name>\" <url>")
+		return cmd.usageError("Wrong number of arguments.")
 	}
-
-	_, err := url.ParseRequestURI(cmd.args[1])
-	if err != nil {
-		return fmt.Errorf("Incorrectly formed URL\nUSAGE: addfeed \"<feed name>\" <url>")
+	res, added, err := addFeed(context.Background(), s, user, cmd.args[0], cmd.args[1])
+	if errors.Is(err, errInvalidURL) {
+		return cmd.usageError("Incorrectly formed URL.")
 	}
-	params := database.CreateFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Name:      cmd.args[0],
-		UserID:    user.ID,
-		Url:       cmd.args[1],
+	if isUniqueViolation(err) {
+		return fmt.Errorf("You already follow %s", cmd.args[1])
 	}
-	res, err := s.db.CreateFeed(context.Background(), params)
 	if err != nil {
-		return fmt.Errorf("An error occurred while creating the feed.  Please try again.")
+		return fmt.Errorf("An error occurred while creating the feed: %v", err)
+	}
+	if output := cmd.flagString("output"); output != "" {
+		return writeRecords(s.out, output, []feedRecord{{
+			Name:  res.Name,
+			URL:   res.Url,
+			Owner: res.Owner,
+		}})
+	}
+	if !added {
+		fmt.Fprintf(s.out, "%s was already added by %s; you now follow it\n", res.Name, res.Owner)
+		return nil
+	}
+	fmt.Fprintf(s.out, "Added feed %s (%s)\n", res.Name, res.Url)
+	return nil
+}
+
+func handlerReset(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return cmd.usageError("Too many arguments.")
 	}
-	followParams := database.CreateFeedFollowParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		UserID:    user.ID,
-		FeedID:    res.ID,
+	ok, err := confirm(s, cmd, "Delete every user, feed, follow and post?")
+	if err != nil || !ok {
+		return err
 	}
-	_, err = s.db.CreateFeedFollow(context.Background(), followParams)
+	ctx := context.Background()
+	err = s.db.DeleteAllUsers(ctx)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%+v\n", res)
+	if err := s.cfg.SetSession("", ""); err != nil {
+		return err
+	}
+	fmt.Fprintln(s.out, "All users have been deleted.  Register a new admin with `gator register --admin <name>`.")
 	return nil
 }
 
-func handlerReset(s *state, cmd command) error {
+func handlerRole(s *state, cmd command, admin database.User) error {
+	if len(cmd.args) != 2 {
+		return cmd.usageError("Expected a user name and a role.")
+	}
+	role := cmd.args[1]
+	if role != roleAdmin && role != roleMember {
+		return cmd.usageError(fmt.Sprintf("Unknown role %q; use admin or member", role))
+	}
 	ctx := context.Background()
-	err := s.db.DeleteAllUsers(ctx)
+	user, err := s.db.GetUser(ctx, cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("No user with that name found")
+	}
+	if user.Role == roleAdmin && user.PasswordHash.Valid && role == roleMember {
+		admins, err := s.db.CountAdmins(ctx)
+		if err != nil {
+			return err
+		}
+		if admins == 1 {
+			return errors.New("Cannot remove the last admin; make someone else an admin first")
+		}
+	}
+	err = s.db.SetUserRole(ctx, database.SetUserRoleParams{
+		ID:   user.ID,
+		Role: role,
+	})
 	if err != nil {
 		return err
 	}
-	fmt.Println("All users have been deleted")
+	fmt.Fprintf(s.out, "%s's role is now %s\n", user.Name, role)
 	return nil
 }
 
 func handlerUsers(s *state, cmd command) error {
+	if len(cmd.args) != 0 {
+		return cmd.usageError("Too many arguments.")
+	}
 	users, err := s.db.GetAllUsers(context.Background())
 	if err != nil {
 		return err
 	}
+	if output := cmd.flagString("output"); output != "" {
+		records := make([]userRecord, 0, len(users))
+		for _, user := range users {
+			records = append(records, userRecord{
+				Name:      user.Name,
+				CreatedAt: user.CreatedAt,
+				Current:   user.Name == s.cfg.User,
+				Role:      user.Role,
+			})
+		}
+		return writeRecords(s.out, output, records)
+	}
 	for _, user := range users {
-		fmt.Printf("* %s", user.Name)
+		fmt.Fprintf(s.out, "* %s", user.Name)
+		if user.Role == roleAdmin {
+			fmt.Fprintf(s.out, " [admin]")
+		}
 		if user.Name == s.cfg.User {
-			fmt.Printf(" (current)")
+			fmt.Fprintf(s.out, " (current)")
 		}
-		fmt.Printf("\n")
+		fmt.Fprintf(s.out, "\n")
 	}
 	return nil
 }
 
 func handlerFeeds(s *state, cmd command) error {
+	if len(cmd.args) != 0 {
+		return cmd.usageError("Too many arguments.")
+	}
 	feeds, err := s.db.GetAllFeeds(context.Background())
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%+v\n", feeds)
-	return nil
+	records := make([]feedRecord, 0, len(feeds))
+	for _, feed := range feeds {
+		records = append(records, feedRecord{
+			Name:  feed.Name,
+			URL:   feed.Url,
+			Owner: feed.Owner,
+		})
+	}
+	return writeRecords(s.out, cmd.flagString("output"), records)
 }
 
 func handlerAgg(s *state, cmd command) error {
 	var time_between_reqs time.Duration
 	var err error
 	if len(cmd.args) > 1 {
-		return fmt.Errorf("Too many arguments.  This function takes a single argument with a duration like \"1m or \"1h\nUsage agg <duration>")
+		return cmd.usageError("Too many arguments.  This function takes a single argument with a duration like \"1m\" or \"1h\".")
 	}
 	if len(cmd.args) == 0 {
 		time_between_reqs, err = time.ParseDuration("1m")
@@ -174,154 +372,475 @@ func handlerAgg(s *state, cmd command) error {
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Collecting feeds every %v\n", time_between_reqs)
+	pruneEvery := cmd.flagDuration("prune")
+	if pruneEvery < 0 {
+		return cmd.usageError("--prune cannot be negative.")
+	}
+	fmt.Fprintf(s.out, "Collecting feeds every %v\n", time_between_reqs)
 	ticker := time.NewTicker(time_between_reqs)
+	var lastPruned time.Time
 	for ; ; <-ticker.C {
 		scrapeFeeds(s)
+		if pruneEvery > 0 && time.Since(lastPruned) >= pruneEvery {
+			lastPruned = time.Now()
+			// A failed prune is tried again next time; fetching goes on.
+			if err := prunePosts(context.Background(), s, false); err != nil {
+				fmt.Fprintf(s.out, "Could not prune posts: %v\n", err)
+			}
+		}
 	}
 }
 
 func interpretTime(s string) sql.NullTime {
-	timeFormats := []string{
-		time.RFC1123Z,
-		time.RFC1123,
-		time.RFC822,
-		time.RFC822Z,
-		time.RFC850,
-		time.RFC3339,
-		time.RFC3339Nano,
-	}
-	var parsedTime sql.NullTime
-	for _, format := range timeFormats {
-		if parseAttempt, err := time.Parse(format, s); err == nil {
-			parsedTime = sql.NullTime{
-				Time:  parseAttempt,
-				Valid: true,
-			}
-			return parsedTime
-		}
+	t, ok := parseFeedDate(s)
+	return sql.NullTime{Time: t, Valid: ok}
+}
+
+// dateSourceNote explains a post's date when it is not the post's own.
+func dateSourceNote(source string) string {
+	switch source {
+	case database.DateSourceFeed:
+		return " (feed updated)"
+	case database.DateSourceFetched:
+		return " (first seen)"
+	}
+	return ""
+}
+
+// effectiveDate picks the date a post sorts by, and where it came from: the
+// item's publish date, or failing that the feed's lastBuildDate, or failing
+// that now, when the post is first stored.  A lastBuildDate in the future is
+// not believed.
+func effectiveDate(published, built sql.NullTime, now time.Time) (time.Time, string) {
+	switch {
+	case published.Valid:
+		return published.Time, database.DateSourcePublished
+	case built.Valid && !built.Time.After(now):
+		return built.Time, database.DateSourceFeed
+	default:
+		return now, database.DateSourceFetched
 	}
-	return parsedTime
 }
 
 func scrapeFeeds(s *state) {
 	nextFeed, err := s.db.GetNextFeedToFetch(context.Background())
 	handleError(err)
-	feed, err := fetchFeed(context.Background(), nextFeed.Url)
-	handleError(err)
-	err = s.db.MarkFeedFetched(context.Background(), nextFeed.ID)
+	feed, fetchErr := fetchFeed(context.Background(), nextFeed.Url)
+	// A feed that fails to fetch goes to the back of the queue like any
+	// other, with the error kept for `gator feed show`.
+	status := database.MarkFeedFetchedParams{ID: nextFeed.ID}
+	if fetchErr != nil {
+		status.LastFetchError = optionalString(fetchErr.Error())
+	}
+	err = s.db.MarkFeedFetched(context.Background(), status)
 	handleError(err)
-	fmt.Printf("Scanning %s...\n", feed.Channel.Title)
+	if fetchErr != nil {
+		fmt.Fprintf(s.out, "Could not fetch %s: %v\n", nextFeed.Url, fetchErr)
+		return
+	}
+	fmt.Fprintf(s.out, "Scanning %s...\n", cleanLine(feed.Channel.Title))
+	// Posts are dated in UTC, as parseFeedDate returns dates, so that the
+	// fetched fallback sorts among them.
+	now := time.Now().UTC()
+	built := interpretTime(feed.Channel.LastBuildDate)
+	items := make([]database.NewPost, 0, len(feed.Channel.Item))
 	for _, item := range feed.Channel.Item {
 		descPub := interpretTime(item.PubDate)
-		if !descPub.Valid {
-			fmt.Printf("Publish time %v could not be read\n", item.PubDate)
+		if !descPub.Valid && item.PubDate != "" {
+			fmt.Fprintf(s.out, "Publish time %v could not be read\n", item.PubDate)
 		}
-		descString := sql.NullString{
-			String: item.Description,
-			Valid:  true,
-		}
-		params := database.CreatePostParams{
+		description := sanitizeHTML(item.Description, item.Link)
+		post := database.NewPost{
 			ID:          uuid.New(),
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
 			Title:       item.Title,
 			Url:         item.Link,
-			Description: descString,
-			PublishedAt: descPub,
-			FeedID:      nextFeed.ID,
+			Description: &description,
+			Categories:  item.Categories,
 		}
-		_, err := s.db.CreatePost(context.Background(), params)
-		if err != nil {
-			if pqErr, ok := err.(*pq.Error); ok {
-				if pqErr.Code.Name() == "unique_violation" {
-					continue
-				}
-			} else {
-				fmt.Printf("An error has occurred: %v\n", err)
-			}
+		if descPub.Valid {
+			post.PublishedAt = &descPub.Time
 		}
+		post.EffectiveAt, post.DateSource = effectiveDate(descPub, built, now)
+		author := item.Author
+		if author == "" {
+			author = item.Creator
+		}
+		if author != "" {
+			post.Author = &author
+		}
+		if post.Categories == nil {
+			post.Categories = []string{}
+		}
+		items = append(items, post)
+	}
+	batch, err := database.EncodePosts(items)
+	handleError(err)
+	added, err := s.db.CreatePosts(context.Background(), database.CreatePostsParams{
+		CreatedAt: now,
+		FeedID:    nextFeed.ID,
+		Items:     batch,
+	})
+	if err != nil {
+		fmt.Fprintf(s.out, "Could not store the posts from %s: %v\n", nextFeed.Url, err)
+		return
 	}
+	fmt.Fprintf(s.out, "%d new posts, %d already stored\n", added, int64(len(items))-added)
 }
 
 func handlerFollow(s *state, cmd command, user database.User) error {
 	if len(cmd.args) != 1 {
-		return fmt.Errorf("This function requires a feed URL.\nUsage: follow <url>")
+		return cmd.usageError("This function requires a feed URL.")
 	}
-	feed, err := s.db.GetFeedByUrl(context.Background(), cmd.args[0])
-	if err != nil {
-		return err
+	res, err := followFeed(context.Background(), s, user, cmd.args[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("No feed has been added at %s; add it with `gator addfeed`", cmd.args[0])
 	}
-	params := database.CreateFeedFollowParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		UserID:    user.ID,
-		FeedID:    feed.ID,
+	if isUniqueViolation(err) {
+		return fmt.Errorf("You already follow %s", cmd.args[0])
 	}
-	res, err := s.db.CreateFeedFollow(context.Background(), params)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%+v", res)
+	if output := cmd.flagString("output"); output != "" {
+		return writeRecords(s.out, output, []followRecord{{
+			User:       res.UserName,
+			FeedName:   res.FeedName,
+			FeedURL:    cmd.args[0],
+			FollowedAt: res.CreatedAt,
+			Folders:    []string{},
+		}})
+	}
+	fmt.Fprintf(s.out, "%s is now following %s\n", res.UserName, res.FeedName)
 	return nil
 }
 
 func handlerFollowing(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return cmd.usageError("Too many arguments.")
+	}
 	ctx := context.Background()
 	follows, err := s.db.GetFeedFollowsForUser(ctx, user.Name)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%s's Feeds:\n", user.Name)
-	for _, feed := range follows {
-		fmt.Printf("* %s\n", feed.FeedName)
+	if output := cmd.flagString("output"); output != "" {
+		records := make([]followRecord, 0, len(follows))
+		for _, follow := range follows {
+			records = append(records, newFollowRecord(follow))
+		}
+		return writeRecords(s.out, output, records)
+	}
+	// Feeds outside any folder come first, then each folder in turn; a
+	// feed filed in several folders is listed under each of them.
+	var unfiled []database.GetFeedFollowsForUserRow
+	folders := map[string][]database.GetFeedFollowsForUserRow{}
+	for _, follow := range follows {
+		if len(follow.Folders) == 0 {
+			unfiled = append(unfiled, follow)
+		}
+		for _, folder := range follow.Folders {
+			folders[folder] = append(folders[folder], follow)
+		}
+	}
+	names := make([]string, 0, len(folders))
+	for name := range folders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(s.out, "%s's Feeds:\n", user.Name)
+	for _, feed := range unfiled {
+		fmt.Fprintf(s.out, "* %s\n", followName(feed))
+	}
+	for _, name := range names {
+		fmt.Fprintf(s.out, "%s/\n", name)
+		for _, feed := range folders[name] {
+			fmt.Fprintf(s.out, "  * %s\n", followName(feed))
+		}
 	}
 	return nil
 }
 
+// followName is the name a follower sees for a feed: their alias, with the
+// feed's own name alongside so it can still be recognised.
+func followName(follow database.GetFeedFollowsForUserRow) string {
+	if follow.Alias.Valid && follow.Alias.String != follow.FeedName {
+		return fmt.Sprintf("%s (%s)", follow.Alias.String, follow.FeedName)
+	}
+	return follow.FeedName
+}
+
 func handlerUnfollow(s *state, cmd command, user database.User) error {
 	if len(cmd.args) != 1 {
-		return fmt.Errorf("Wrong number of arguments.\nUsage: unfollow <url>")
+		return cmd.usageError("Wrong number of arguments.")
+	}
+	return unfollowFeed(context.Background(), s, user, cmd.args[0])
+}
+
+func handlerAlias(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 || len(cmd.args) > 2 {
+		return cmd.usageError("Expected a feed URL and optionally a name.")
+	}
+	var alias string
+	if len(cmd.args) == 2 {
+		alias = strings.TrimSpace(cmd.args[1])
+	}
+	n, err := s.db.SetFollowAlias(context.Background(), database.SetFollowAliasParams{
+		Alias:  optionalString(alias),
+		UserID: user.ID,
+		Url:    cmd.args[0],
+	})
+	if err != nil {
+		return err
 	}
-	params := database.DeleteFeedFollowParams{
-		Name: user.Name,
-		Url:  cmd.args[0],
+	if n == 0 {
+		return fmt.Errorf("You are not following %s", cmd.args[0])
+	}
+	if alias == "" {
+		fmt.Fprintf(s.out, "%s is shown under its own name again\n", cmd.args[0])
+		return nil
+	}
+	fmt.Fprintf(s.out, "%s is now shown as %s\n", cmd.args[0], alias)
+	return nil
+}
+
+func handlerFolder(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 1 {
+		return cmd.usageError("Expected a feed URL and the folders to put it in.")
+	}
+	folders := []string{}
+	seen := map[string]bool{}
+	for _, folder := range cmd.args[1:] {
+		folder = strings.TrimSpace(folder)
+		if folder == "" || seen[folder] {
+			continue
+		}
+		seen[folder] = true
+		folders = append(folders, folder)
+	}
+	n, err := s.db.SetFollowFolders(context.Background(), database.SetFollowFoldersParams{
+		Folders: folders,
+		UserID:  user.ID,
+		Url:     cmd.args[0],
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("You are not following %s", cmd.args[0])
+	}
+	if len(folders) == 0 {
+		fmt.Fprintf(s.out, "%s is no longer in any folder\n", cmd.args[0])
+		return nil
+	}
+	fmt.Fprintf(s.out, "%s is now in %s\n", cmd.args[0], strings.Join(folders, ", "))
+	return nil
+}
+
+func handlerAPIKey(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return cmd.usageError("Too many arguments.")
+	}
+	if !cmd.flagBool("rotate") {
+		// Only the key's hash is kept, as with session tokens.
+		fmt.Fprintln(s.out, "Your API key is only shown when it is made; run `gator apikey --rotate`")
+		fmt.Fprintln(s.out, "for a new one.  Apps using the old key will be signed out.")
+		return nil
+	}
+	key, err := newToken()
+	if err != nil {
+		return err
 	}
-	err := s.db.DeleteFeedFollow(context.Background(), params)
+	err = s.db.SetAPIKey(context.Background(), database.SetAPIKeyParams{
+		ID:           user.ID,
+		ApiKeyHash:   hashToken(key),
+		FeverKeyHash: sql.NullString{String: feverKeyHash(user.Name, key), Valid: true},
+	})
 	if err != nil {
 		return err
 	}
+	fmt.Fprintln(s.out, key)
 	return nil
 }
 
+func browseFlags(fs *flag.FlagSet) {
+	fs.Int("limit", 2, "number of posts to show")
+	fs.String("feed", "", "only show posts from the feed with this `url`")
+	fs.String("since", "", "only show posts published on or after this `date` (YYYY-MM-DD or RFC3339)")
+	fs.String("until", "", "only show posts published before the end of this `date` (YYYY-MM-DD or RFC3339)")
+	fs.String("author", "", "only show posts whose author contains this `text`")
+	fs.String("tag", "", "only show posts with this `category`")
+	fs.String("search", "", "only show posts whose title or description contains this `text`")
+	fs.String("folder", "", "only show posts from the feeds you filed in this `folder`")
+	fs.Bool("unread", false, "only show posts that have not been read")
+	fs.Bool("starred", false, "only show starred posts")
+	fs.String("sort", "newest", "sort `order`: newest or oldest")
+	fs.String("after", "", "continue from the `cursor` printed by a previous browse")
+	outputFlags(fs)
+}
+
 func handlerBrowse(s *state, cmd command, user database.User) error {
-	limit := int32(2)
+	if len(cmd.args) > 1 {
+		return cmd.usageError("Too many arguments.")
+	}
+	query := postQuery{
+		Limit:   cmd.flagInt("limit"),
+		Feed:    cmd.flagString("feed"),
+		Since:   cmd.flagString("since"),
+		Until:   cmd.flagString("until"),
+		Author:  cmd.flagString("author"),
+		Tag:     cmd.flagString("tag"),
+		Search:  cmd.flagString("search"),
+		Folder:  cmd.flagString("folder"),
+		Unread:  cmd.flagBool("unread"),
+		Starred: cmd.flagBool("starred"),
+		Sort:    cmd.flagString("sort"),
+		After:   cmd.flagString("after"),
+	}
 	if len(cmd.args) == 1 {
 		input, err := strconv.ParseInt(cmd.args[0], 0, 32)
-		limit = int32(input)
 		if err != nil {
 			return err
 		}
+		query.Limit = int(input)
 	}
-	params := database.GetPostsForUserParams{
-		Limit: limit,
-		Name:  user.Name,
+	params, err := query.params(user.ID)
+	if err != nil {
+		return err
 	}
-	res, err := s.db.GetPostsForUser(context.Background(), params)
+	output := cmd.flagString("output")
+
+	// One post more than asked for tells whether there is another page.
+	if params.Limit < math.MaxInt32 {
+		params.Limit++
+	}
+	ctx := context.Background()
+	res, err := database.GetPostsForUser(ctx, s.db, params)
 	if err != nil {
 		return err
 	}
+	more := len(res) > query.Limit
+	if more {
+		res = res[:query.Limit]
+	}
+	if output != "" {
+		records := make([]postRecord, 0, len(res))
+		for _, item := range res {
+			records = append(records, newPostRecord(item))
+		}
+		if err := writeRecords(s.out, output, records); err != nil {
+			return err
+		}
+	}
+	width := terminalWidth()
 	for _, item := range res {
-		fmt.Printf("%s\n------------\n%s\n%s\n\n", item.Title, item.Description.String, item.Url)
+		if output == "" {
+			// Everything printed here comes from the feed, which could
+			// otherwise send escape sequences to the terminal.
+			fmt.Fprintf(s.out, "%s\n------------\n", cleanLine(item.Title))
+			fmt.Fprintf(s.out, "%s | %s%s", cleanLine(item.FeedName), formatTime(item.SortDate), dateSourceNote(item.DateSource))
+			if item.Author.Valid && item.Author.String != "" {
+				fmt.Fprintf(s.out, " | %s", cleanLine(item.Author.String))
+			}
+			fmt.Fprintf(s.out, "\n%s\n%s\n\n", strings.Join(htmlText(item.Description.String, item.Url, width), "\n"), cleanLine(item.Url))
+		}
+		if err := setPostRead(ctx, s, user.ID, item.ID, true); err != nil {
+			return err
+		}
+	}
+	if output == "" && more {
+		last := res[len(res)-1]
+		query.After = encodeCursor(last.SortDate, last.ID)
+		fmt.Fprintf(s.out, "More posts: gator browse %s\n", strings.Join(query.browseArgs(), " "))
 	}
 	return nil
 }
 
+// yesFlag adds --yes to commands that ask before destroying data.
+func yesFlag(fs *flag.FlagSet) {
+	fs.Bool("yes", false, "do not ask for confirmation")
+}
+
+// confirm asks the user to confirm a destructive command, unless they passed
+// --yes.  Without a terminal to ask on, --yes is required.
+func confirm(s *state, cmd command, question string) (bool, error) {
+	if cmd.flagBool("yes") {
+		return true, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, cmd.usageError("Pass --yes to confirm when not running interactively.")
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		fmt.Fprintln(s.out, "Cancelled.")
+		return false, nil
+	}
+	return true, nil
+}
+
+// optionalString turns an empty flag value into a NULL query parameter.
+func optionalString(s string) sql.NullString {
+	return sql.NullString{
+		String: s,
+		Valid:  s != "",
+	}
+}
+
+// parseDateFlag reads a YYYY-MM-DD or RFC3339 date.  When endOfDay is set, a
+// bare date is moved to the following midnight so the whole day is included.
+func parseDateFlag(s string, endOfDay bool) (sql.NullTime, error) {
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("Could not read date %q; use YYYY-MM-DD", s)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+// encodeCursor builds the opaque token passed to `browse --after`.  It holds
+// the sort date and ID of the last post shown, which together are unique.
+func encodeCursor(date time.Time, id uuid.UUID) string {
+	raw := date.Format(time.RFC3339Nano) + "," + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (sql.NullTime, uuid.NullUUID, error) {
+	invalid := fmt.Errorf("Invalid cursor %q", cursor)
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return sql.NullTime{}, uuid.NullUUID{}, invalid
+	}
+	date, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return sql.NullTime{}, uuid.NullUUID{}, invalid
+	}
+	t, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return sql.NullTime{}, uuid.NullUUID{}, invalid
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return sql.NullTime{}, uuid.NullUUID{}, invalid
+	}
+	return sql.NullTime{Time: t, Valid: true}, uuid.NullUUID{UUID: parsedID, Valid: true}, nil
+}
+
 func handleError(err error) {
 	if err != nil {
-		fmt.Printf("An error has occurred: %v\n", err)
-		fmt.Println("Exiting...")
+		fmt.Fprintf(os.Stderr, "An error has occurred: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Exiting...")
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,480 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/config"
+	"github.com/interyx/gator/internal/database"
+	"github.com/interyx/gator/internal/memstore"
+)
+
+// testEnv runs gator's commands against the in-memory store, with the
+// config in a temporary file and the output in a buffer.
+type testEnv struct {
+	t       *testing.T
+	cmds    *commands
+	s       *state
+	out     *bytes.Buffer
+	cfgPath string
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".gatorconfig.json")
+	if err := os.WriteFile(path, []byte(`{"db_url": "memory"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	s := &state{db: memstore.New(), cfg: &cfg, out: out}
+	cmds := newCommands()
+	cmds.newState = func() (*state, error) { return s, nil }
+	return &testEnv{t: t, cmds: cmds, s: s, out: out, cfgPath: path}
+}
+
+// run runs a gator command line and returns its output.  Each line of input
+// answers one password prompt.
+func (e *testEnv) run(input string, args ...string) (string, error) {
+	e.t.Helper()
+	e.out.Reset()
+	passwordInput = bufio.NewReader(strings.NewReader(input))
+	err := e.cmds.run(command{name: args[0], args: args[1:]})
+	return e.out.String(), err
+}
+
+// mustRun is run for commands that are expected to succeed.
+func (e *testEnv) mustRun(input string, args ...string) string {
+	e.t.Helper()
+	out, err := e.run(input, args...)
+	if err != nil {
+		e.t.Fatalf("gator %s: %v", strings.Join(args, " "), err)
+	}
+	return out
+}
+
+// mustFail is run for commands that are expected to fail with an error
+// containing want.
+func (e *testEnv) mustFail(want, input string, args ...string) {
+	e.t.Helper()
+	_, err := e.run(input, args...)
+	if err == nil || !strings.Contains(err.Error(), want) {
+		e.t.Fatalf("gator %s: got error %v, want one containing %q", strings.Join(args, " "), err, want)
+	}
+}
+
+// setUp registers bob, the admin, and alice, leaving alice logged in.
+func (e *testEnv) setUp() {
+	e.t.Helper()
+	e.mustRun("password1\n", "register", "--admin", "bob")
+	e.mustRun("password2\n", "register", "alice")
+}
+
+func TestRegisterFirstAdmin(t *testing.T) {
+	e := newTestEnv(t)
+	e.mustFail("no admin yet", "password1\n", "register", "alice")
+	out := e.mustRun("password1\n", "register", "--admin", "bob")
+	if !strings.Contains(out, "admin") {
+		t.Errorf("register --admin printed %q", out)
+	}
+	e.mustFail("already an admin", "password1\n", "register", "--admin", "carol")
+	e.mustRun("password2\n", "register", "alice")
+	e.mustFail("taken", "password2\n", "register", "alice")
+	e.mustFail("at least", "short\n", "register", "dave")
+
+	out = e.mustRun("", "users")
+	if want := "* bob [admin]\n* alice (current)\n"; out != want {
+		t.Errorf("users printed %q, want %q", out, want)
+	}
+}
+
+func TestLoginWritesConfig(t *testing.T) {
+	e := newTestEnv(t)
+	e.setUp()
+	e.mustFail("wrong password", "password2\n", "login", "bob")
+	e.mustRun("password1\n", "login", "bob")
+
+	// The session is written back to the config file it was read from.
+	cfg, err := config.ReadFile(e.cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.User != "bob" || cfg.SessionToken == "" || cfg.SessionToken != e.s.cfg.SessionToken {
+		t.Errorf("config after login = %+v", cfg)
+	}
+	if cfg.Db_url != "memory" {
+		t.Errorf("login lost db_url: %+v", cfg)
+	}
+
+	e.mustRun("", "logout")
+	if e.s.cfg.SessionToken != "" {
+		t.Errorf("logout left session token %q", e.s.cfg.SessionToken)
+	}
+	e.mustFail("not logged in", "", "following")
+}
+
+func TestRoleKeepsAnAdmin(t *testing.T) {
+	e := newTestEnv(t)
+	e.setUp()
+	e.mustFail("Only admins", "", "role", "alice", "admin")
+	e.mustRun("password1\n", "login", "bob")
+	e.mustFail("last admin", "", "role", "bob", "member")
+	e.mustRun("", "role", "alice", "admin")
+	e.mustRun("", "role", "bob", "member")
+	e.mustFail("Only admins", "", "role", "bob", "admin")
+}
+
+func TestUserRenameUpdatesConfig(t *testing.T) {
+	e := newTestEnv(t)
+	e.setUp()
+	e.mustFail("Only admins", "", "user", "rename", "bob", "robert")
+	e.mustRun("", "user", "rename", "alice", "alicia")
+	if e.s.cfg.User != "alicia" {
+		t.Errorf("config user is %q after renaming yourself", e.s.cfg.User)
+	}
+	e.mustRun("password2\n", "login", "alicia")
+}
+
+func TestFeedCommands(t *testing.T) {
+	e := newTestEnv(t)
+	e.setUp()
+	const url = "https://example.com/feed.xml"
+	out := e.mustRun("", "addfeed", "Example", url)
+	if want := "Added feed Example (" + url + ")\n"; out != want {
+		t.Errorf("addfeed printed %q, want %q", out, want)
+	}
+	e.mustFail("Incorrectly formed URL", "", "addfeed", "Bad", "not a url")
+	e.mustFail("You already follow", "", "addfeed", "Example", url)
+
+	e.mustRun("password1\n", "login", "bob")
+	out = e.mustRun("", "addfeed", "Again", url)
+	if !strings.Contains(out, "already added by alice") {
+		t.Errorf("addfeed of a known feed printed %q", out)
+	}
+	out = e.mustRun("", "following", "--output", "json")
+	var follows []followRecord
+	if err := json.Unmarshal([]byte(out), &follows); err != nil {
+		t.Fatalf("following --output json: %v\n%s", err, out)
+	}
+	if len(follows) != 1 || follows[0].FeedURL != url {
+		t.Errorf("following = %+v", follows)
+	}
+
+	// Flags belong to their own subcommand.
+	e.mustFail("not defined: -yes", "", "feed", "rename", "--yes", url, "Renamed")
+	e.mustFail("not defined: -days", "", "feed", "delete", "--days", "3", url)
+
+	e.mustRun("", "feed", "rename", url, "Renamed")
+	e.mustRun("", "feed", "retain", "--days", "30", url)
+	e.mustFail("Pass --yes", "", "feed", "delete", url)
+	e.mustRun("", "feed", "delete", "--yes", url)
+	out = e.mustRun("", "feeds")
+	if strings.Contains(out, url) {
+		t.Errorf("feeds still lists the deleted feed:\n%s", out)
+	}
+}
+
+func TestFeedChangesNeedOwner(t *testing.T) {
+	e := newTestEnv(t)
+	e.setUp()
+	const url = "https://example.com/feed.xml"
+	e.mustRun("password1\n", "login", "bob")
+	e.mustRun("", "addfeed", "Example", url)
+	e.mustRun("password2\n", "login", "alice")
+	e.mustRun("", "follow", url)
+	e.mustFail("Only the user who added a feed", "", "feed", "rename", url, "Mine")
+	e.mustFail("Only the user who added a feed", "", "feed", "delete", "--yes", url)
+}
+
+// addPosts adds a feed with a post for each title, an hour apart in order.
+func (e *testEnv) addPosts(url string, titles ...string) {
+	e.t.Helper()
+	e.mustRun("", "addfeed", "Example", url)
+	ctx := context.Background()
+	feed, err := e.s.db.GetFeedByUrl(ctx, url)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	published := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	for i, title := range titles {
+		date := published.Add(time.Duration(i) * time.Hour)
+		_, err := e.s.db.CreatePost(ctx, database.CreatePostParams{
+			ID:          uuid.New(),
+			CreatedAt:   date,
+			UpdatedAt:   date,
+			Title:       title,
+			Url:         url + "#" + strings.ToLower(title),
+			PublishedAt: sql.NullTime{Time: date, Valid: true},
+			FeedID:      feed.ID,
+			EffectiveAt: date,
+			DateSource:  "published",
+		})
+		if err != nil {
+			e.t.Fatal(err)
+		}
+	}
+}
+
+// nextPage returns the arguments of the command browse printed for the next
+// page, or nil if it printed none.
+func nextPage(out string) []string {
+	_, hint, ok := strings.Cut(out, "More posts: gator ")
+	if !ok {
+		return nil
+	}
+	hint, _, _ = strings.Cut(hint, "\n")
+	return strings.Fields(hint)
+}
+
+func TestBrowseMarksRead(t *testing.T) {
+	e := newTestEnv(t)
+	e.setUp()
+	e.addPosts("https://example.com/feed.xml", "First", "Second", "Third")
+
+	out := e.mustRun("", "browse", "2")
+	if !strings.HasPrefix(out, "Third\n") || !strings.Contains(out, "Second\n") || strings.Contains(out, "First\n") {
+		t.Errorf("browse 2 printed:\n%s", out)
+	}
+	if nextPage(out) == nil {
+		t.Errorf("browse 2 did not offer the next page:\n%s", out)
+	}
+
+	out = e.mustRun("", "browse", "--unread", "--output", "jsonl")
+	var titles []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var post postRecord
+		if err := json.Unmarshal([]byte(line), &post); err != nil {
+			t.Fatalf("browse --output jsonl: %v\n%s", err, out)
+		}
+		titles = append(titles, post.Title)
+	}
+	if len(titles) != 1 || titles[0] != "First" {
+		t.Errorf("unread posts after browsing = %q, want [First]", titles)
+	}
+}
+
+func TestBrowseNextPage(t *testing.T) {
+	e := newTestEnv(t)
+	e.setUp()
+	e.addPosts("https://example.com/feed.xml", "First", "Second", "Third", "Fourth", "Fifth")
+
+	// The printed command keeps the sort order and filters, and stops
+	// offering more once the last page is shown.
+	args := []string{"browse", "--sort", "oldest", "--search", "i", "2"}
+	var titles []string
+	for pages := 0; args != nil; pages++ {
+		if pages == 3 {
+			t.Fatalf("browse kept offering pages: %q", titles)
+		}
+		out := e.mustRun("", args...)
+		lines := strings.Split(out, "\n")
+		for i, line := range lines {
+			if line == "------------" {
+				titles = append(titles, lines[i-1])
+			}
+		}
+		args = nextPage(out)
+	}
+	if want := []string{"First", "Third", "Fifth"}; !slices.Equal(titles, want) {
+		t.Errorf("browse --sort oldest pages showed %q, want %q", titles, want)
+	}
+
+	// A page that ends with the last post offers no more.
+	if out := e.mustRun("", "browse", "--search", "i", "3"); nextPage(out) != nil {
+		t.Errorf("browse with no more posts offered another page:\n%s", out)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	e := newTestEnv(t)
+	e.setUp()
+	ctx := context.Background()
+	alice, err := e.s.db.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each post is stored the given number of days ago, and dated when it
+	// was stored unless it says otherwise.
+	now := time.Now().UTC()
+	add := func(url string, posts map[string][2]int) {
+		e.mustRun("", "addfeed", url[len("https://"):], url)
+		feed, err := e.s.db.GetFeedByUrl(ctx, url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for title, days := range posts {
+			stored, dated := now.AddDate(0, 0, -days[0]), now.AddDate(0, 0, -days[1])
+			items, err := database.EncodePosts([]database.NewPost{{
+				ID: uuid.New(), Title: title, Url: url + "#" + title, EffectiveAt: dated, DateSource: "published",
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := e.s.db.CreatePosts(ctx, database.CreatePostsParams{CreatedAt: stored, FeedID: feed.ID, Items: items}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	add("https://blog.example", map[string][2]int{"b1": {1, 1}, "b2": {5, 5}, "b3": {40, 40}, "b4": {50, 50}})
+	// n3 is new to gator but old by its own date, which keep_posts ranks
+	// by and keep_days ignores.
+	add("https://news.example", map[string][2]int{"n1": {20, 20}, "n2": {2, 2}, "n3": {0, 100}})
+	titles := func() []string {
+		rows, err := database.GetPostsForUser(ctx, e.s.db, database.GetPostsForUserParams{UserID: alice.ID, Limit: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, row := range rows {
+			titles = append(titles, row.Title)
+		}
+		return titles
+	}
+	ids := map[string]uuid.UUID{}
+	rows, err := database.GetPostsForUser(ctx, e.s.db, database.GetPostsForUserParams{UserID: alice.ID, Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		ids[row.Title] = row.ID
+	}
+	if err := e.s.db.StarPost(ctx, database.StarPostParams{UserID: alice.ID, PostID: ids["b3"], StarredAt: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only admins prune.
+	e.mustFail("Only admins", "", "prune")
+	e.mustRun("password1\n", "login", "bob")
+	if out := e.mustRun("", "prune"); out != "No posts to prune\n" {
+		t.Errorf("prune with no limits printed %q", out)
+	}
+
+	// The starred b3 is kept, though it is older than keep_days and past
+	// keep_posts.
+	e.s.cfg.KeepDays, e.s.cfg.KeepPosts = 30, 2
+	out := e.mustRun("", "prune", "--dry-run")
+	want := "  blog.example (https://blog.example): 1 posts\n  news.example (https://news.example): 1 posts\nWould remove 2 posts from 2 feeds\n"
+	if out != want {
+		t.Errorf("prune --dry-run printed %q, want %q", out, want)
+	}
+	if got := titles(); len(got) != 7 {
+		t.Errorf("prune --dry-run removed posts, leaving %q", got)
+	}
+
+	// A feed's own limits win over the config file's.
+	e.mustRun("", "feed", "retain", "--posts", "0", "https://news.example")
+	e.mustRun("", "feed", "retain", "--days", "45", "--posts", "default", "https://blog.example")
+	out = e.mustRun("", "prune")
+	want = "  blog.example (https://blog.example): 1 posts\nRemoved 1 posts from 1 feeds\n"
+	if out != want {
+		t.Errorf("prune printed %q, want %q", out, want)
+	}
+	if got, want := titles(), []string{"b1", "n2", "b2", "n1", "b3", "n3"}; !slices.Equal(got, want) {
+		t.Errorf("after pruning: %q, want %q", got, want)
+	}
+	if out := e.mustRun("", "prune"); out != "No posts to prune\n" {
+		t.Errorf("pruning again printed %q", out)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	e := newTestEnv(t)
+	e.setUp()
+	e.mustFail("Could not read date", "", "publish", "add", "--since", "yesterday", "news")
+	e.mustFail("not defined: -feed", "", "publish", "list", "--feed", "x")
+	out := e.mustRun("", "publish", "add", "--author", "Ann", "news")
+	if !strings.Contains(out, "/saved/feed.atom?token=") {
+		t.Errorf("publish add printed:\n%s", out)
+	}
+	e.mustFail("already have", "", "publish", "add", "news")
+	out = e.mustRun("", "publish", "list")
+	if !strings.Contains(out, `* news: --author "Ann"`) {
+		t.Errorf("publish list printed:\n%s", out)
+	}
+	e.mustRun("", "publish", "remove", "news")
+	e.mustFail("No saved search", "", "publish", "remove", "news")
+}
+
+func TestSiteTimezone(t *testing.T) {
+	e := newTestEnv(t)
+	e.setUp()
+	// 08:00 to 11:00 UTC, which is 21:00 to 00:00 in Auckland.
+	e.addPosts("https://example.com/feed", "One", "Two", "Three", "Four")
+	build := func(args ...string) string {
+		t.Helper()
+		dir := t.TempDir()
+		e.mustRun("", append(append([]string{"site", "build"}, args...), dir)...)
+		index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(index)
+	}
+
+	index := build()
+	if n := strings.Count(index, "Monday, 19 October 2026"); n != 1 || strings.Contains(index, "20 October") {
+		t.Errorf("in UTC the posts are not all on the 19th:\n%s", index)
+	}
+	if !strings.Contains(index, "11:00") || !strings.Contains(index, "08:00") {
+		t.Errorf("in UTC the times are not shown in UTC:\n%s", index)
+	}
+
+	index = build("--timezone", "Pacific/Auckland")
+	if !strings.Contains(index, "Tuesday, 20 October 2026") || !strings.Contains(index, "Monday, 19 October 2026") {
+		t.Errorf("in Auckland the posts are not split over two days:\n%s", index)
+	}
+	if !strings.Contains(index, "00:00") || !strings.Contains(index, "21:00") || strings.Contains(index, "11:00") {
+		t.Errorf("in Auckland the times are not shown in Auckland:\n%s", index)
+	}
+	// Four comes first, on the 20th, and the rest follow on the 19th.
+	order := []int{
+		strings.Index(index, "20 October"), strings.Index(index, "Four"),
+		strings.Index(index, "19 October"), strings.Index(index, "Three"),
+	}
+	if !slices.IsSorted(order) {
+		t.Errorf("in Auckland the posts are under the wrong days:\n%s", index)
+	}
+
+	e.mustFail("Unknown time zone", "", "site", "build", "--timezone", "Mars/Olympus", t.TempDir())
+}
+
+func TestFlagsNotDeclared(t *testing.T) {
+	// A command without a flag reads it as unset rather than panicking.
+	var cmd command
+	if cmd.flagBool("yes") || cmd.flagString("output") != "" || cmd.flagInt("limit") != 0 ||
+		cmd.flagDuration("interval") != 0 || cmd.flagStrings("feed") != nil {
+		t.Error("undeclared flags are not zero")
+	}
+	cmd.flags = commandSpec{name: "test"}.flagSet()
+	if cmd.flagBool("yes") {
+		t.Error("undeclared --yes is set")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for in, want := range map[string]string{
+		"oldest":                       "oldest",
+		"https://example.com/feed.xml": "https://example.com/feed.xml",
+		"two words":                    "'two words'",
+		"it's":                         `'it'\''s'`,
+		"":                             "''",
+		"$HOME":                        "'$HOME'",
+	} {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Values a command's positional arguments can be completed with, set in
+// commandSpec.completes.  Commands, shells and migrate's actions are fixed
+// and written into the generated script; the rest are looked up with
+// `gator __complete <kind>` so they stay current as feeds and users are added.
+const (
+	completeCommands  = "commands"
+	completeShells    = "shells"
+	completeMigrate   = "migrate"
+	completeFeeds     = "feeds"
+	completeFollowing = "following"
+	completeUsers     = "users"
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+func (c *commands) handlerCompletion(s *state, cmd command) error {
+	if len(cmd.args) != 1 {
+		return cmd.usageError("Choose a shell: bash, zsh or fish.")
+	}
+	switch cmd.args[0] {
+	case "bash":
+		c.writeBashCompletion(s.out)
+	case "zsh":
+		c.writeZshCompletion(s.out)
+	case "fish":
+		c.writeFishCompletion(s.out)
+	default:
+		return cmd.usageError(fmt.Sprintf("Unsupported shell %q.", cmd.args[0]))
+	}
+	return nil
+}
+
+// handlerComplete prints dynamic completion candidates one per line.  It is
+// called by the completion scripts, which discard anything it writes to
+// stderr.
+func handlerComplete(s *state, cmd command) error {
+	if len(cmd.args) != 1 {
+		return cmd.usageError("Choose what to complete.")
+	}
+	ctx := context.Background()
+	switch cmd.args[0] {
+	case completeFeeds:
+		feeds, err := s.db.GetAllFeeds(ctx)
+		if err != nil {
+			return err
+		}
+		for _, feed := range feeds {
+			fmt.Fprintln(s.out, feed.Url)
+		}
+	case completeFollowing:
+		follows, err := s.db.GetFeedFollowsForUser(ctx, s.cfg.User)
+		if err != nil {
+			return err
+		}
+		for _, follow := range follows {
+			fmt.Fprintln(s.out, follow.FeedUrl)
+		}
+	case completeUsers:
+		users, err := s.db.GetAllUsers(ctx)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			fmt.Fprintln(s.out, user.Name)
+		}
+	default:
+		return fmt.Errorf("Nothing to complete for %q", cmd.args[0])
+	}
+	return nil
+}
+
+// visibleNames lists the commands offered for completion.
+func (c *commands) visibleNames() []string {
+	var names []string
+	for _, name := range c.sortedNames() {
+		if !c.names[name].hidden {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// subcommandNames lists the command's subcommands, or nil if it has none.
+func (spec commandSpec) subcommandNames() []string {
+	if spec.subcommands == nil {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(spec.subcommands))
+}
+
+func (spec commandSpec) flagNames() []string {
+	var names []string
+	spec.flagSet().VisitAll(func(f *flag.Flag) {
+		names = append(names, "--"+f.Name)
+	})
+	return names
+}
+
+// staticCompletions returns the fixed words for a completion kind, or nil if
+// the kind has to be looked up at completion time.
+func (c *commands) staticCompletions(kind string) []string {
+	switch kind {
+	case completeCommands:
+		return c.visibleNames()
+	case completeShells:
+		return completionShells
+	case completeMigrate:
+		return []string{"up", "down", "status"}
+	}
+	return nil
+}
+
+// bashReply is the bash code that offers the completions for kind.
+func (c *commands) bashReply(kind string) string {
+	if words := c.staticCompletions(kind); words != nil {
+		return bashWords(words)
+	}
+	return fmt.Sprintf("local IFS=$'\\n'; COMPREPLY=($(compgen -W \"$(gator __complete %s 2>/dev/null)\" -- \"$cur\"))", kind)
+}
+
+func bashWords(words []string) string {
+	return fmt.Sprintf("COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))", strings.Join(words, " "))
+}
+
+// zshReply is the zsh code that offers the completions for kind.
+func (c *commands) zshReply(kind string) string {
+	if words := c.staticCompletions(kind); words != nil {
+		return "compadd -- " + strings.Join(words, " ")
+	}
+	return fmt.Sprintf("compadd -- ${(f)\"$(gator __complete %s 2>/dev/null)\"}", kind)
+}
+
+func (c *commands) writeBashCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# bash completion for gator")
+	fmt.Fprintln(w, "# Load with: source <(gator completion bash)")
+	fmt.Fprintln(w, "_gator() {")
+	fmt.Fprintln(w, "    local cur cmd")
+	fmt.Fprintln(w, "    cur=\"${COMP_WORDS[COMP_CWORD]}\"")
+	fmt.Fprintln(w, "    if declare -F _get_comp_words_by_ref >/dev/null; then")
+	fmt.Fprintln(w, "        _get_comp_words_by_ref -n : cur")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "    if [[ $COMP_CWORD -eq 1 ]]; then")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(c.visibleNames(), " "))
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "    cmd=\"${COMP_WORDS[1]}\"")
+	fmt.Fprintln(w, "    if [[ \"$cur\" == -* ]]; then")
+	fmt.Fprintln(w, "        case \"$cmd\" in")
+	for _, name := range c.visibleNames() {
+		if flags := c.names[name].flagNames(); len(flags) > 0 {
+			fmt.Fprintf(w, "            %s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", name, strings.Join(flags, " "))
+		}
+	}
+	fmt.Fprintln(w, "        esac")
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "    case \"$cmd\" in")
+	for _, name := range c.visibleNames() {
+		spec := c.names[name]
+		// The subcommand, if any, comes right after the command.
+		switch subs := spec.subcommandNames(); {
+		case subs != nil && spec.completes != "":
+			fmt.Fprintf(w, "        %s) if [[ $COMP_CWORD -eq 2 ]]; then %s; else %s; fi ;;\n", name, bashWords(subs), c.bashReply(spec.completes))
+		case subs != nil:
+			fmt.Fprintf(w, "        %s) if [[ $COMP_CWORD -eq 2 ]]; then %s; fi ;;\n", name, bashWords(subs))
+		case spec.completes != "":
+			fmt.Fprintf(w, "        %s) %s ;;\n", name, c.bashReply(spec.completes))
+		}
+	}
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, "    if declare -F __ltrim_colon_completions >/dev/null; then")
+	fmt.Fprintln(w, "        __ltrim_colon_completions \"$cur\"")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -F _gator gator")
+}
+
+func (c *commands) writeZshCompletion(w io.Writer) {
+	fmt.Fprintln(w, "#compdef gator")
+	fmt.Fprintln(w, "# zsh completion for gator")
+	fmt.Fprintln(w, "# Load with: source <(gator completion zsh)")
+	fmt.Fprintln(w, "_gator() {")
+	fmt.Fprintln(w, "    local -a commands")
+	fmt.Fprintln(w, "    commands=(")
+	for _, name := range c.visibleNames() {
+		fmt.Fprintf(w, "        %s\n", zshQuote(name+":"+c.names[name].description))
+	}
+	fmt.Fprintln(w, "    )")
+	fmt.Fprintln(w, "    if (( CURRENT == 2 )); then")
+	fmt.Fprintln(w, "        _describe 'command' commands")
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "    if [[ $PREFIX == -* ]]; then")
+	fmt.Fprintln(w, "        case $words[2] in")
+	for _, name := range c.visibleNames() {
+		if flags := c.names[name].flagNames(); len(flags) > 0 {
+			fmt.Fprintf(w, "            %s) compadd -- %s ;;\n", name, strings.Join(flags, " "))
+		}
+	}
+	fmt.Fprintln(w, "        esac")
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "    case $words[2] in")
+	for _, name := range c.visibleNames() {
+		spec := c.names[name]
+		switch subs := spec.subcommandNames(); {
+		case subs != nil && spec.completes != "":
+			fmt.Fprintf(w, "        %s) if (( CURRENT == 3 )); then compadd -- %s; else %s; fi ;;\n", name, strings.Join(subs, " "), c.zshReply(spec.completes))
+		case subs != nil:
+			fmt.Fprintf(w, "        %s) (( CURRENT == 3 )) && compadd -- %s ;;\n", name, strings.Join(subs, " "))
+		case spec.completes != "":
+			fmt.Fprintf(w, "        %s) %s ;;\n", name, c.zshReply(spec.completes))
+		}
+	}
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "compdef _gator gator")
+}
+
+func (c *commands) writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# fish completion for gator")
+	fmt.Fprintln(w, "# Load with: gator completion fish | source")
+	fmt.Fprintln(w, "complete -c gator -f")
+	for _, name := range c.visibleNames() {
+		spec := c.names[name]
+		fmt.Fprintf(w, "complete -c gator -n __fish_use_subcommand -a %s -d %s\n", name, fishQuote(spec.description))
+		condition := fishQuote("__fish_seen_subcommand_from " + name)
+		spec.flagSet().VisitAll(func(f *flag.Flag) {
+			_, usage := flag.UnquoteUsage(f)
+			fmt.Fprintf(w, "complete -c gator -n %s -l %s -d %s\n", condition, f.Name, fishQuote(usage))
+		})
+		if subs := spec.subcommandNames(); subs != nil {
+			seen := "__fish_seen_subcommand_from " + strings.Join(subs, " ")
+			fmt.Fprintf(w, "complete -c gator -n %s -a %s\n", fishQuote("__fish_seen_subcommand_from "+name+"; and not "+seen), fishQuote(strings.Join(subs, " ")))
+			// The values are offered once a subcommand has been given.
+			condition = fishQuote("__fish_seen_subcommand_from " + name + "; and " + seen)
+		}
+		if spec.completes == "" {
+			continue
+		}
+		if words := c.staticCompletions(spec.completes); words != nil {
+			fmt.Fprintf(w, "complete -c gator -n %s -a %s\n", condition, fishQuote(strings.Join(words, " ")))
+		} else {
+			fmt.Fprintf(w, "complete -c gator -n %s -a '(gator __complete %s 2>/dev/null)'\n", condition, spec.completes)
+		}
+	}
+}
+
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	var script bytes.Buffer
+	newCommands().writeBashCompletion(&script)
+	// complete runs the script's completion function on words, the last of
+	// which is being completed, with gator itself replaced by a stub that
+	// knows one feed and one user.
+	complete := func(words ...string) []string {
+		t.Helper()
+		program := script.String() + `
+gator() {
+    case "$2" in
+        feeds|following) echo https://example.com/feed ;;
+        users) echo alice ;;
+    esac
+}
+COMP_WORDS=("$@")
+COMP_CWORD=$(( $# - 1 ))
+_gator
+printf '%s\n' "${COMPREPLY[@]}"
+`
+		out, err := exec.Command(bash, append([]string{"-c", program, "bash", "gator"}, words...)...).Output()
+		if err != nil {
+			t.Fatalf("completing %q: %v", words, err)
+		}
+		return strings.Fields(string(out))
+	}
+
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{"fol"}, "folder follow following"},
+		{[]string{"follow", ""}, "https://example.com/feed"},
+		{[]string{"unfollow", ""}, "https://example.com/feed"},
+		{[]string{"follow", "--o"}, "--output"},
+		{[]string{"feed", ""}, "delete rename retain set-url show"},
+		{[]string{"feed", "s"}, "set-url show"},
+		{[]string{"feed", "show", ""}, "https://example.com/feed"},
+		{[]string{"feed", "delete", "--"}, "--days --interval --posts --yes"},
+		{[]string{"user", ""}, "delete rename show"},
+		{[]string{"user", "delete", "a"}, "alice"},
+		{[]string{"publish", ""}, "add list remove"},
+		{[]string{"publish", "add", ""}, ""},
+		{[]string{"migrate", ""}, "up down status"},
+		{[]string{"completion", "z"}, "zsh"},
+		{[]string{"browse", ""}, ""},
+	}
+	for _, tc := range tests {
+		if got := strings.Join(complete(tc.words...), " "); got != tc.want {
+			t.Errorf("gator %s: got %q, want %q", strings.Join(tc.words, " "), got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Feeds are meant to date items in RFC 822 (RSS) or RFC 3339 (Atom), but
+// real ones drift: single-digit days, four-digit years in RFC 822, missing
+// or localized weekday and month names, "GMT+2" offsets, dates without a
+// time.  parseFeedDate first tries the strict formats, then rewrites the
+// date into a canonical form (English month, numeric offset, no weekday)
+// and tries the layouts that form can take.
+
+// isoLayouts are tried on the date as it is.  Fractional seconds are
+// accepted after any seconds field.
+var isoLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// The canonical form is a date, optionally a time, then optionally a zone
+// offset, separated by single spaces.
+var (
+	dateLayouts = []string{
+		"2 Jan 2006",
+		"2 Jan 06",
+		"Jan 2 2006",
+		"2006-01-02",
+		"2006/01/02",
+		"2.1.2006",
+	}
+	clockLayouts = []string{"", " 15:04:05", " 15:04", " 3:04:05 PM", " 3:04 PM"}
+	zoneLayouts  = []string{"", " -0700"}
+)
+
+// canonicalLayouts is every combination of the parts above, plus the order
+// of time.UnixDate, which puts the zone before the year.
+var canonicalLayouts = func() []string {
+	layouts := []string{"Jan 2 15:04:05 -0700 2006", "Jan 2 15:04:05 2006"}
+	for _, date := range dateLayouts {
+		for _, clock := range clockLayouts {
+			for _, zone := range zoneLayouts {
+				layouts = append(layouts, date+clock+zone)
+			}
+		}
+	}
+	return layouts
+}()
+
+// monthNames maps month names and abbreviations, in English and the
+// languages feeds most often use, to the abbreviations time.Parse reads.
+var monthNames = map[string]string{}
+
+func init() {
+	names := [12][]string{
+		{"january", "jan", "januar", "jänner", "janvier", "janv", "enero", "ene", "gennaio", "gen", "janeiro", "januari"},
+		{"february", "feb", "februar", "février", "févr", "fevrier", "fevr", "febrero", "febbraio", "fevereiro", "fev", "februari"},
+		{"march", "mar", "märz", "mär", "maerz", "mars", "marzo", "março", "marco", "maart", "mrt"},
+		{"april", "apr", "avril", "avr", "abril", "abr", "aprile"},
+		{"may", "mai", "mayo", "maggio", "mag", "maio", "mei"},
+		{"june", "jun", "juni", "juin", "junio", "giugno", "giu", "junho"},
+		{"july", "jul", "juli", "juillet", "juil", "julio", "luglio", "lug", "julho"},
+		{"august", "aug", "août", "aout", "agosto", "ago", "augustus"},
+		{"september", "sep", "sept", "septembre", "septiembre", "settembre", "set", "setembro"},
+		{"october", "oct", "oktober", "okt", "octobre", "octubre", "ottobre", "ott", "outubro", "out"},
+		{"november", "nov", "novembre", "noviembre", "novembro"},
+		{"december", "dec", "dezember", "dez", "décembre", "decembre", "déc", "diciembre", "dic", "dicembre", "dezembro"},
+	}
+	for i, list := range names {
+		abbr := time.Month(i + 1).String()[:3]
+		for _, name := range list {
+			monthNames[name] = abbr
+		}
+	}
+}
+
+// weekdayNames holds the weekday names and abbreviations to drop, in the
+// same languages as monthNames.
+var weekdayNames = map[string]bool{}
+
+func init() {
+	for _, name := range strings.Fields(`
+		mon monday tue tues tuesday wed weds wednesday thu thur thurs thursday
+		fri friday sat saturday sun sunday
+		mo montag di dienstag mi mittwoch do donnerstag fr freitag sa samstag so sonntag
+		lun lundi mardi mer mercredi jeu jeudi ven vendredi sam samedi dim dimanche
+		lunes martes mié miércoles miercoles jue jueves vie viernes sáb sábado sabado domingo dom
+		lunedì lunedi martedì martedi mercoledì mercoledi giovedì giovedi gio venerdì venerdi sabato domenica
+		seg segunda ter terça terca qua quarta qui quinta sex sexta
+		ma maandag woensdag wo donderdag vr vrijdag za zaterdag zo zondag`) {
+		weekdayNames[name] = true
+	}
+}
+
+// fillerWords join the parts of a date in prose, as in "19 de octubre de
+// 2026" or "October 19, 2026 at 3:04 PM".
+var fillerWords = map[string]bool{"at": true, "de": true, "del": true, "à": true, "um": true, "om": true}
+
+// zoneOffsets gives the offsets of the zone names feeds use.  time.Parse
+// reads an unknown zone name as UTC, so these are replaced by numbers
+// before parsing.  Ambiguous names, like IST, are left out.
+var zoneOffsets = map[string]int{
+	"ut": 0, "utc": 0, "gmt": 0, "z": 0, "wet": 0,
+	"est": -5 * 60, "edt": -4 * 60,
+	"cst": -6 * 60, "cdt": -5 * 60,
+	"mst": -7 * 60, "mdt": -6 * 60,
+	"pst": -8 * 60, "pdt": -7 * 60,
+	"akst": -9 * 60, "akdt": -8 * 60, "hst": -10 * 60,
+	"bst": 60, "west": 60, "cet": 60, "cest": 2 * 60, "met": 60, "mest": 2 * 60,
+	"eet": 2 * 60, "eest": 3 * 60, "msk": 3 * 60,
+	"jst": 9 * 60, "kst": 9 * 60, "hkt": 8 * 60, "sgt": 8 * 60,
+	"awst": 8 * 60, "acst": 9*60 + 30, "acdt": 10*60 + 30, "aest": 10 * 60, "aedt": 11 * 60,
+	"nzst": 12 * 60, "nzdt": 13 * 60,
+}
+
+// parseFeedDate reads a date from a feed, returning it in UTC.  A date
+// without a zone is taken to be in UTC.
+func parseFeedDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range isoLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	canonical := canonicalDate(s)
+	for _, layout := range canonicalLayouts {
+		if t, err := time.Parse(layout, canonical); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// canonicalDate rewrites a date into the form canonicalLayouts describe.
+func canonicalDate(s string) string {
+	s = strings.NewReplacer(",", " ", "\u00a0", " ").Replace(s)
+	words := strings.Fields(s)
+	months := 0
+	for _, field := range words {
+		if monthNames[strings.ToLower(strings.TrimRight(field, "."))] != "" {
+			months++
+		}
+	}
+	var fields []string
+	for i, field := range words {
+		lower := strings.ToLower(strings.TrimRight(field, "."))
+		switch {
+		case i == 0 && (weekdayNames[lower] || months > 1 && monthNames[lower] != ""),
+			fillerWords[lower],
+			strings.HasPrefix(field, "(") && strings.HasSuffix(field, ")"):
+			// Weekdays add nothing; the French "mar." for mardi reads as a
+			// month unless another month follows.  A parenthesized zone
+			// name, as in "+0200 (CEST)", repeats the offset.
+			continue
+		case monthNames[lower] != "":
+			field = monthNames[lower]
+		case i > 0 && isZone(lower):
+			field = zoneOffset(lower)
+		case lower == "am" || lower == "pm":
+			field = strings.ToUpper(lower)
+		default:
+			field = strings.TrimRight(trimOrdinal(field), ".")
+		}
+		fields = append(fields, field)
+	}
+	return strings.Join(fields, " ")
+}
+
+// trimOrdinal turns "1st", "22nd" and the like into plain numbers.
+func trimOrdinal(field string) string {
+	lower := strings.ToLower(field)
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		digits, ok := strings.CutSuffix(lower, suffix)
+		if ok && digits != "" && strings.Trim(digits, "0123456789") == "" {
+			return digits
+		}
+	}
+	return field
+}
+
+// isZone reports whether field, in lower case, names a time zone: a name
+// from zoneOffsets, a numeric offset like +02, +0200 or +02:00, or a name
+// followed by one, like GMT+2.
+func isZone(field string) bool {
+	_, ok := parseZone(field)
+	return ok
+}
+
+// zoneOffset formats a zone isZone accepts as an offset like +0200.
+func zoneOffset(field string) string {
+	minutes, _ := parseZone(field)
+	sign := '+'
+	if minutes < 0 {
+		sign, minutes = '-', -minutes
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, minutes/60, minutes%60)
+}
+
+// parseZone returns the offset east of UTC, in minutes, of a zone field.
+func parseZone(field string) (int, bool) {
+	if minutes, ok := zoneOffsets[field]; ok {
+		return minutes, true
+	}
+	i := strings.IndexAny(field, "+-")
+	if i < 0 {
+		return 0, false
+	}
+	base, ok := 0, i == 0
+	if !ok {
+		base, ok = zoneOffsets[field[:i]]
+	}
+	if !ok {
+		return 0, false
+	}
+	sign, offset := 1, field[i+1:]
+	if field[i] == '-' {
+		sign = -1
+	}
+	var hours, minutes int
+	var err error
+	switch h, m, colon := strings.Cut(offset, ":"); {
+	case colon:
+		hours, err = strconv.Atoi(h)
+		if err == nil {
+			minutes, err = strconv.Atoi(m)
+		}
+	case len(offset) <= 2:
+		hours, err = strconv.Atoi(offset)
+	case len(offset) == 4:
+		hours, err = strconv.Atoi(offset[:2])
+		if err == nil {
+			minutes, err = strconv.Atoi(offset[2:])
+		}
+	default:
+		return 0, false
+	}
+	if err != nil || offset == "" || hours > 14 || minutes > 59 || minutes < 0 || hours < 0 {
+		return 0, false
+	}
+	return base + sign*(hours*60+minutes), true
+}
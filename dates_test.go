@@ -0,0 +1,118 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/interyx/gator/internal/database"
+)
+
+// dateCorpus holds dates as feeds write them, and the instant each one
+// means in UTC.  An empty want is a date that should not parse.
+var dateCorpus = []struct {
+	in   string
+	want string
+}{
+	// RFC 822 and RFC 1123, and the ways feeds drift from them.
+	{"Mon, 19 Oct 2026 10:00:00 +0200", "2026-10-19T08:00:00Z"},
+	{"Mon, 19 Oct 2026 10:00:00 GMT", "2026-10-19T10:00:00Z"},
+	{"Mon, 9 Oct 26 10:00:00 GMT", "2026-10-09T10:00:00Z"},
+	{"Mon, 19 Oct 2026 10:00 +0200", "2026-10-19T08:00:00Z"},
+	{"9 Oct 2026 10:00 EST", "2026-10-09T15:00:00Z"},
+	{"Tue, 10 Jun 2003 04:00:00 PDT", "2003-06-10T11:00:00Z"},
+	{"Tuesday, 10 June 2003 04:00:00 -0700", "2003-06-10T11:00:00Z"},
+	{"Fri, 1 Jan 1999 00:00:00 Z", "1999-01-01T00:00:00Z"},
+	{"Mon, 19 Oct 2026 10:00:00 +0200 (CEST)", "2026-10-19T08:00:00Z"},
+	{"  Mon, 19 Oct 2026 10:00:00 GMT\n", "2026-10-19T10:00:00Z"},
+	// Offsets written as a zone name plus hours.
+	{"Sat, 07 Sep 2002 00:00:01 GMT+2", "2002-09-06T22:00:01Z"},
+	{"Sat, 07 Sep 2002 00:00:01 UTC-0530", "2002-09-07T05:30:01Z"},
+	// RFC 3339 and its looser ISO 8601 cousins.
+	{"2026-10-19T10:00:00+02:00", "2026-10-19T08:00:00Z"},
+	{"2026-10-19T10:00:00.123Z", "2026-10-19T10:00:00.123Z"},
+	{"2026-10-19 10:00:00+01:00", "2026-10-19T09:00:00Z"},
+	{"2026-10-19T10:00:00", "2026-10-19T10:00:00Z"},
+	// Dates without a time are midnight UTC.
+	{"2026-10-19", "2026-10-19T00:00:00Z"},
+	{"October 19th, 2026", "2026-10-19T00:00:00Z"},
+	{"19.10.2026", "2026-10-19T00:00:00Z"},
+	// Localized weekday and month names.
+	{"Di, 20 Okt 2026 10:00:00 +0200", "2026-10-20T08:00:00Z"},
+	{"mar., 20 oct. 2026 10:00:00 +0200", "2026-10-20T08:00:00Z"},
+	{"20 März 2026 10:00:00 +0100", "2026-03-20T09:00:00Z"},
+	{"19. Oktober 2026", "2026-10-19T00:00:00Z"},
+	{"lunes, 19 de octubre de 2026", "2026-10-19T00:00:00Z"},
+	// Other layouts seen in the wild.
+	{"March 5, 2026 3:04 PM", "2026-03-05T15:04:00Z"},
+	{"October 19, 2026 at 3:04 pm", "2026-10-19T15:04:00Z"},
+	{"Mon Oct 19 10:00:00 UTC 2026", "2026-10-19T10:00:00Z"},
+	// Garbage.
+	{"", ""},
+	{"   ", ""},
+	{"garbage", ""},
+	{"yesterday", ""},
+	{"Mon, 32 Oct 2026 10:00:00 GMT", ""},
+	{"2026-13-01", ""},
+}
+
+func TestParseFeedDate(t *testing.T) {
+	for _, tc := range dateCorpus {
+		got, ok := parseFeedDate(tc.in)
+		if tc.want == "" {
+			if ok {
+				t.Errorf("parseFeedDate(%q) = %v, want no date", tc.in, got)
+			}
+			continue
+		}
+		want, err := time.Parse(time.RFC3339Nano, tc.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Errorf("parseFeedDate(%q) found no date, want %v", tc.in, want)
+			continue
+		}
+		if !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("parseFeedDate(%q) = %v, want %v", tc.in, got, want)
+		}
+	}
+}
+
+func TestEffectiveDate(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	published := sql.NullTime{Time: now.Add(-48 * time.Hour), Valid: true}
+	built := sql.NullTime{Time: now.Add(-time.Hour), Valid: true}
+	future := sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+	tests := []struct {
+		name       string
+		published  sql.NullTime
+		built      sql.NullTime
+		want       time.Time
+		wantSource string
+	}{
+		{"published", published, built, published.Time, database.DateSourcePublished},
+		{"published without build date", published, sql.NullTime{}, published.Time, database.DateSourcePublished},
+		{"published after build date", sql.NullTime{Time: now.Add(time.Hour), Valid: true}, built, now.Add(time.Hour), database.DateSourcePublished},
+		{"build date", sql.NullTime{}, built, built.Time, database.DateSourceFeed},
+		{"build date now", sql.NullTime{}, sql.NullTime{Time: now, Valid: true}, now, database.DateSourceFeed},
+		{"future build date", sql.NullTime{}, future, now, database.DateSourceFetched},
+		{"no dates", sql.NullTime{}, sql.NullTime{}, now, database.DateSourceFetched},
+	}
+	for _, tc := range tests {
+		got, source := effectiveDate(tc.published, tc.built, now)
+		if !got.Equal(tc.want) || source != tc.wantSource {
+			t.Errorf("%s: effectiveDate = %v, %s; want %v, %s", tc.name, got, source, tc.want, tc.wantSource)
+		}
+	}
+}
+
+func TestEffectiveDateFromFeed(t *testing.T) {
+	// An item whose pubDate cannot be read falls back to the channel's
+	// lastBuildDate, as read from the feed.
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	got, source := effectiveDate(interpretTime("not a date"), interpretTime("Mon, 19 Oct 2026 10:00:00 +0200"), now)
+	if want := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC); !got.Equal(want) || source != database.DateSourceFeed {
+		t.Errorf("effectiveDate = %v, %s; want %v, %s", got, source, want, database.DateSourceFeed)
+	}
+}
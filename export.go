@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+// A user's timeline can be re-published as an RSS 2.0 or Atom feed, so gator
+// can act as a "planet" aggregator.  `gator export feed` writes one out for
+// the logged-in user, with the same filters as browse.  Publishing over HTTP
+// is opt-in: `gator publish add` saves a search, which `gator serve` serves
+// at /saved/feed.atom and /saved/feed.rss to whoever has its secret token.
+
+const defaultExportSize = 50
+
+const exportGenerator = "gator"
+
+// timeline is a user's posts ready to be written out as a feed.
+type timeline struct {
+	ID      string
+	Title   string
+	Link    string
+	Self    string
+	Updated time.Time
+	Posts   []postRecord
+}
+
+// loadTimeline reads the newest posts matching query.  Read state and paging
+// mean nothing to subscribers, so those filters are ignored.
+func loadTimeline(ctx context.Context, s *state, user database.User, query postQuery) (timeline, error) {
+	query.Unread = false
+	query.Sort = ""
+	query.After = ""
+	params, err := query.params(user.ID)
+	if err != nil {
+		return timeline{}, err
+	}
+	rows, err := database.GetPostsForUser(ctx, s.db, params)
+	if err != nil {
+		return timeline{}, err
+	}
+	// The ID must not change between runs, but two differently filtered
+	// exports of the same user are different feeds.
+	filters := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%t",
+		query.Feed, query.Since, query.Until, query.Author, query.Tag, query.Search, query.Starred)
+	if query.Folder != "" {
+		// Appended rather than added to the format, so exports from before
+		// folders existed keep their IDs.
+		filters += "|" + query.Folder
+	}
+	t := timeline{
+		ID:      "urn:uuid:" + uuid.NewSHA1(user.ID, []byte(filters)).String(),
+		Title:   user.Name + " on gator",
+		Updated: user.UpdatedAt,
+	}
+	for _, row := range rows {
+		post := newPostRecord(row)
+		t.Posts = append(t.Posts, post)
+		if post.PublishedAt.After(t.Updated) {
+			t.Updated = post.PublishedAt
+		}
+	}
+	return t, nil
+}
+
+func writeTimeline(w io.Writer, format string, t timeline) error {
+	var doc any
+	switch format {
+	case "atom":
+		doc = newAtomFeed(t)
+	case "rss":
+		doc = newRSSDocument(t)
+	default:
+		return fmt.Errorf("Unknown feed format %q; use atom or rss", format)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Author     atomPerson     `xml:"author"`
+	Link       atomLink       `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary"`
+	Source     atomSource     `xml:"source"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomSource struct {
+	Title string `xml:"title"`
+}
+
+func newAtomFeed(t timeline) atomFeed {
+	feed := atomFeed{
+		ID:        t.ID,
+		Title:     t.Title,
+		Updated:   t.Updated.UTC().Format(time.RFC3339),
+		Generator: exportGenerator,
+	}
+	if t.Link != "" {
+		feed.Links = append(feed.Links, atomLink{Href: t.Link})
+	}
+	if t.Self != "" {
+		feed.Links = append(feed.Links, atomLink{Href: t.Self, Rel: "self"})
+	}
+	for _, post := range t.Posts {
+		published := post.PublishedAt.UTC().Format(time.RFC3339)
+		entry := atomEntry{
+			ID:        "urn:uuid:" + post.ID,
+			Title:     post.Title,
+			Updated:   published,
+			Published: published,
+			Author:    atomPerson{Name: post.Author},
+			Link:      atomLink{Href: post.URL},
+			Source:    atomSource{Title: post.Feed},
+		}
+		// Atom requires an author; the feed's name is the best we have.
+		if entry.Author.Name == "" {
+			entry.Author.Name = post.Feed
+		}
+		for _, category := range post.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		if post.Description != "" {
+			entry.Summary = &atomText{Type: "html", Body: post.Description}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          *rssSelf  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssSelf struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description,omitempty"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func newRSSDocument(t timeline) rssDocument {
+	doc := rssDocument{
+		Version: "2.0",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         t.Title,
+			Link:          t.Link,
+			Description:   t.Title,
+			LastBuildDate: t.Updated.UTC().Format(time.RFC1123Z),
+			Generator:     exportGenerator,
+		},
+	}
+	if t.Self != "" {
+		doc.Channel.Self = &rssSelf{Href: t.Self, Rel: "self", Type: "application/rss+xml"}
+	}
+	for _, post := range t.Posts {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       post.Title,
+			Link:        post.URL,
+			Description: post.Description,
+			GUID:        rssGUID{Value: "urn:uuid:" + post.ID},
+			PubDate:     post.PublishedAt.UTC().Format(time.RFC1123Z),
+			Creator:     post.Author,
+			Categories:  post.Categories,
+		})
+	}
+	return doc
+}
+
+func exportFlags(fs *flag.FlagSet) {
+	fs.String("user", "", "export this `user`'s timeline instead of your own (admins only)")
+	fs.String("saved", "", "export the saved search with this `name` instead of filtering")
+	fs.String("format", "atom", "feed `format`: atom or rss")
+	fs.Int("limit", defaultExportSize, "number of posts to include")
+	fs.String("title", "", "feed `title` (default \"<user> on gator\")")
+	fs.String("link", "", "`url` of the site the feed describes (required for rss)")
+	fs.String("feed", "", "only include posts from the feed with this `url`")
+	fs.String("since", "", "only include posts published on or after this `date`")
+	fs.String("until", "", "only include posts published before the end of this `date`")
+	fs.String("author", "", "only include posts whose author contains this `text`")
+	fs.String("tag", "", "only include posts with this `category`")
+	fs.String("search", "", "only include posts whose title or description contains this `text`")
+	fs.String("folder", "", "only include posts from the feeds filed in this `folder`")
+	fs.Bool("starred", false, "only include starred posts")
+}
+
+func handlerExport(s *state, cmd command, current database.User) error {
+	if len(cmd.args) != 1 || cmd.args[0] != "feed" {
+		return cmd.usageError("Expected `export feed`.")
+	}
+	ctx := context.Background()
+	user := current
+	if name := cmd.flagString("user"); name != "" {
+		var err error
+		if user, err = userForCommand(ctx, s, current, name); err != nil {
+			return err
+		}
+	}
+	query := postQuery{
+		Limit:   cmd.flagInt("limit"),
+		Feed:    cmd.flagString("feed"),
+		Since:   cmd.flagString("since"),
+		Until:   cmd.flagString("until"),
+		Author:  cmd.flagString("author"),
+		Tag:     cmd.flagString("tag"),
+		Search:  cmd.flagString("search"),
+		Folder:  cmd.flagString("folder"),
+		Starred: cmd.flagBool("starred"),
+	}
+	var saved database.SavedSearch
+	if name := cmd.flagString("saved"); name != "" {
+		if query != (postQuery{Limit: query.Limit}) {
+			return cmd.usageError("--saved cannot be combined with other filters.")
+		}
+		var err error
+		saved, err = s.db.GetSavedSearch(ctx, database.GetSavedSearchParams{
+			UserID: user.ID,
+			Name:   name,
+		})
+		if err != nil {
+			return fmt.Errorf("No saved search called %s", name)
+		}
+		if query, err = savedQuery(saved.Filters, query.Limit); err != nil {
+			return err
+		}
+	}
+	t, err := loadTimeline(ctx, s, user, query)
+	if err != nil {
+		return err
+	}
+	if saved.Name != "" {
+		t.ID = "urn:uuid:" + saved.ID.String()
+	}
+	if title := cmd.flagString("title"); title != "" {
+		t.Title = title
+	}
+	t.Link = cmd.flagString("link")
+	if t.Link == "" && cmd.flagString("format") == "rss" {
+		return cmd.usageError("RSS feeds need a --link.")
+	}
+	return writeTimeline(s.out, cmd.flagString("format"), t)
+}
+
+// handleSavedFeed serves a saved search as a feed.  These URLs are meant to
+// be handed to other feed readers, so they need no API key; the token in
+// the query string stands in for one, and picks the search.  Only limit,
+// title and link may be changed in the query string, never the filters.
+func (a *apiServer) handleSavedFeed(format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		values := r.URL.Query()
+		row, err := a.s.db.GetSavedSearchByToken(r.Context(), hashToken(values.Get("token")))
+		if err != nil {
+			respondWithDBError(w, err)
+			return
+		}
+		limit := defaultExportSize
+		if l := values.Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil || n > maxAPIPageSize {
+				respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be a number up to %d", maxAPIPageSize))
+				return
+			}
+			limit = n
+		}
+		query, err := savedQuery(row.SavedSearch.Filters, limit)
+		if err != nil {
+			respondWithDBError(w, err)
+			return
+		}
+		t, err := loadTimeline(r.Context(), a.s, row.User, query)
+		if err != nil {
+			respondWithQueryError(w, err)
+			return
+		}
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		t.ID = "urn:uuid:" + row.SavedSearch.ID.String()
+		t.Title = row.User.Name + " on gator: " + row.SavedSearch.Name
+		t.Self = scheme + "://" + r.Host + r.URL.RequestURI()
+		t.Link = scheme + "://" + r.Host + "/"
+		if title := values.Get("title"); title != "" {
+			t.Title = title
+		}
+		if link := values.Get("link"); link != "" {
+			t.Link = link
+		}
+		var buf bytes.Buffer
+		if err := writeTimeline(&buf, format, t); err != nil {
+			log.Printf("Error rendering %s feed: %v", format, err)
+			http.Error(w, "Something went wrong", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/"+format+"+xml; charset=utf-8")
+		w.Write(buf.Bytes())
+	}
+}
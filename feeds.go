@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/interyx/gator/internal/database"
+)
+
+// `gator feed` manages single feeds.  Anyone may look at a feed; only the
+// user who added it, or an admin, may change or delete it.
+
+var feedSubcommands = map[string]func(fs *flag.FlagSet){
+	"show": func(fs *flag.FlagSet) {
+		fs.Duration("interval", time.Minute, "on show, the `duration` agg waits between fetches, to estimate the next fetch")
+	},
+	"rename":  nil,
+	"set-url": nil,
+	"retain": func(fs *flag.FlagSet) {
+		fs.String("days", "", "on retain, keep posts this many `days` after they are stored (0 for no limit, default for the config file's)")
+		fs.String("posts", "", "on retain, keep this many of the newest `posts` (0 for no limit, default for the config file's)")
+	},
+	"delete": yesFlag,
+}
+
+func handlerFeed(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return cmd.usageError("Expected a subcommand: show, rename, set-url, retain or delete.")
+	}
+	sub := cmd.args[0]
+	cmd.args = cmd.args[1:]
+	switch sub {
+	case "show":
+		return handlerFeedShow(s, cmd)
+	case "rename":
+		return handlerFeedRename(s, cmd, user)
+	case "set-url":
+		return handlerFeedSetURL(s, cmd, user)
+	case "retain":
+		return handlerFeedRetain(s, cmd, user)
+	case "delete":
+		return handlerFeedDelete(s, cmd, user)
+	}
+	return cmd.usageError(fmt.Sprintf("Unknown subcommand %q; use show, rename, set-url, retain or delete.", sub))
+}
+
+// feedForCommand looks up the feed at feedURL, which user must own unless
+// they are an admin.
+func feedForCommand(ctx context.Context, s *state, user database.User, feedURL string) (database.Feed, error) {
+	feed, err := s.db.GetFeedByUrl(ctx, feedURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, fmt.Errorf("No feed with that URL found")
+	}
+	if err != nil {
+		return database.Feed{}, err
+	}
+	if feed.UserID != user.ID && user.Role != roleAdmin {
+		return database.Feed{}, errors.New("Only the user who added a feed, or an admin, can change it")
+	}
+	return feed, nil
+}
+
+func handlerFeedShow(s *state, cmd command) error {
+	if len(cmd.args) != 1 {
+		return cmd.usageError("Expected the feed's URL.")
+	}
+	feed, err := s.db.GetFeedDetails(context.Background(), cmd.args[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("No feed with that URL found")
+	}
+	if err != nil {
+		return err
+	}
+	status := "never fetched"
+	if feed.LastFetchedAt.Valid {
+		status = formatTime(feed.LastFetchedAt.Time) + ", ok"
+		if feed.LastFetchError.Valid {
+			status = formatTime(feed.LastFetchedAt.Time) + ", failed: " + feed.LastFetchError.String
+		}
+	}
+	// agg fetches one feed per interval, least recently fetched first.
+	interval := cmd.flagDuration("interval")
+	next := time.Now().Add(time.Duration(feed.QueuedAhead+1) * interval)
+	fmt.Fprintf(s.out, "%s\n", feed.Name)
+	fmt.Fprintf(s.out, "  URL:         %s\n", feed.Url)
+	fmt.Fprintf(s.out, "  Added by:    %s on %s\n", feed.Owner, formatTime(feed.CreatedAt))
+	fmt.Fprintf(s.out, "  Followers:   %d\n", feed.Followers)
+	fmt.Fprintf(s.out, "  Posts:       %d\n", feed.Posts)
+	fmt.Fprintf(s.out, "  Keep for:    %s\n", retentionLimit(feed.KeepDays, s.cfg.KeepDays, " days"))
+	fmt.Fprintf(s.out, "  Keep newest: %s\n", retentionLimit(feed.KeepPosts, s.cfg.KeepPosts, ""))
+	fmt.Fprintf(s.out, "  Last fetch:  %s\n", status)
+	fmt.Fprintf(s.out, "  Next fetch:  about %s, after %d other feeds (if agg runs every %v)\n",
+		formatTime(next), feed.QueuedAhead, interval)
+	return nil
+}
+
+func handlerFeedRename(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 2 {
+		return cmd.usageError("Expected the feed's URL and its new name.")
+	}
+	ctx := context.Background()
+	feed, err := feedForCommand(ctx, s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	err = s.db.RenameFeed(ctx, database.RenameFeedParams{
+		ID:   feed.ID,
+		Name: cmd.args[1],
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "%s is now called %s\n", feed.Name, cmd.args[1])
+	return nil
+}
+
+func handlerFeedSetURL(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 2 {
+		return cmd.usageError("Expected the feed's current and new URLs.")
+	}
+	newURL := cmd.args[1]
+	if _, err := url.ParseRequestURI(newURL); err != nil {
+		return cmd.usageError("Incorrectly formed URL.")
+	}
+	ctx := context.Background()
+	feed, err := feedForCommand(ctx, s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	err = s.db.SetFeedUrl(ctx, database.SetFeedUrlParams{
+		ID:  feed.ID,
+		Url: newURL,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("Another feed already uses %s", newURL)
+		}
+		return err
+	}
+	fmt.Fprintf(s.out, "%s now fetches from %s\n", feed.Name, newURL)
+	return nil
+}
+
+func handlerFeedRetain(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return cmd.usageError("Expected the feed's URL.")
+	}
+	days, posts := cmd.flagString("days"), cmd.flagString("posts")
+	if days == "" && posts == "" {
+		return cmd.usageError("Expected --days, --posts or both.")
+	}
+	ctx := context.Background()
+	feed, err := feedForCommand(ctx, s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	params := database.SetFeedRetentionParams{
+		ID:        feed.ID,
+		KeepDays:  feed.KeepDays,
+		KeepPosts: feed.KeepPosts,
+	}
+	if days != "" {
+		if params.KeepDays, err = parseRetentionLimit(days); err != nil {
+			return cmd.usageError("--days " + err.Error())
+		}
+	}
+	if posts != "" {
+		if params.KeepPosts, err = parseRetentionLimit(posts); err != nil {
+			return cmd.usageError("--posts " + err.Error())
+		}
+	}
+	if err := s.db.SetFeedRetention(ctx, params); err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "%s\n", feed.Name)
+	fmt.Fprintf(s.out, "  Keep for:    %s\n", retentionLimit(params.KeepDays, s.cfg.KeepDays, " days"))
+	fmt.Fprintf(s.out, "  Keep newest: %s\n", retentionLimit(params.KeepPosts, s.cfg.KeepPosts, ""))
+	return nil
+}
+
+// parseRetentionLimit reads a --days or --posts value, where "default"
+// leaves the limit to the config file.
+func parseRetentionLimit(value string) (sql.NullInt32, error) {
+	if value == "default" {
+		return sql.NullInt32{}, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || n < 0 {
+		return sql.NullInt32{}, errors.New("must be a number of at least 0, or default.")
+	}
+	return sql.NullInt32{Int32: int32(n), Valid: true}, nil
+}
+
+func handlerFeedDelete(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return cmd.usageError("Expected the feed's URL.")
+	}
+	ctx := context.Background()
+	if _, err := feedForCommand(ctx, s, user, cmd.args[0]); err != nil {
+		return err
+	}
+	feed, err := s.db.GetFeedDetails(ctx, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "Deleting %s will remove its %d posts and unfollow it for %d users\n",
+		feed.Name, feed.Posts, feed.Followers)
+	ok, err := confirm(s, cmd, fmt.Sprintf("Delete %s?", feed.Name))
+	if err != nil || !ok {
+		return err
+	}
+	if err := s.db.DeleteFeed(ctx, feed.ID); err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "Deleted %s\n", feed.Name)
+	return nil
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/interyx/gator/internal/database"
+)
+
+// Fever API (https://feedafever.com/api), served at /fever/ by `gator serve`
+// so mobile readers such as Reeder and NetNewsWire can sync with gator.
+//
+// Clients authenticate with md5("<email>:<password>"); users sign in with
+// their gator user name as the email and their API key as the password.
+// Like the API key, that md5 is only kept hashed, and since it is made from
+// the name, renaming a user voids it until they rotate their key.
+// Fever identifies feeds and items by integer, for which the seq columns
+// are used.  Folders have no such IDs, so every feed is put in one group.
+
+const (
+	feverAPIVersion = 3
+	feverGroupID    = 1
+	feverItemLimit  = 50
+)
+
+type feverGroup struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID int    `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int    `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverItem struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// feverKeyHash is what is kept of the key a Fever client signs in with.
+func feverKeyHash(name, apiKey string) string {
+	sum := md5.Sum([]byte(name + ":" + apiKey))
+	return hashToken(hex.EncodeToString(sum[:]))
+}
+
+func (a *apiServer) handleFever(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Malformed request")
+		return
+	}
+	ctx := r.Context()
+	resp := map[string]any{
+		"api_version": feverAPIVersion,
+		"auth":        0,
+	}
+	user, err := a.s.db.GetUserByFeverKey(ctx, hashToken(strings.ToLower(r.Form.Get("api_key"))))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithJSON(w, http.StatusOK, resp)
+		return
+	}
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	resp["auth"] = 1
+
+	feeds, err := a.s.db.GetFeverFeeds(ctx, user.ID)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	var lastRefreshed int64
+	feedIDs := make([]string, 0, len(feeds))
+	for _, feed := range feeds {
+		// The last time gator fetched any of the feeds, whether or not the
+		// fetch found anything new.
+		if feed.LastFetchedAt.Valid {
+			lastRefreshed = max(lastRefreshed, feed.LastFetchedAt.Time.Unix())
+		}
+		feedIDs = append(feedIDs, strconv.FormatInt(feed.Seq, 10))
+	}
+	resp["last_refreshed_on_time"] = lastRefreshed
+	feedsGroups := []feverFeedsGroup{{GroupID: feverGroupID, FeedIDs: strings.Join(feedIDs, ",")}}
+
+	if r.Form.Has("mark") {
+		if err := a.feverMark(r, user); err != nil {
+			respondWithQueryError(w, err)
+			return
+		}
+	}
+	if r.Form.Has("groups") {
+		resp["groups"] = []feverGroup{{ID: feverGroupID, Title: "All"}}
+		resp["feeds_groups"] = feedsGroups
+	}
+	if r.Form.Has("feeds") {
+		list := make([]feverFeed, 0, len(feeds))
+		for _, feed := range feeds {
+			list = append(list, feverFeed{
+				ID:                feed.Seq,
+				Title:             feed.Name,
+				URL:               feed.Url,
+				SiteURL:           feed.Url,
+				LastUpdatedOnTime: feed.UpdatedAt.Unix(),
+			})
+		}
+		resp["feeds"] = list
+		resp["feeds_groups"] = feedsGroups
+	}
+	if r.Form.Has("favicons") {
+		resp["favicons"] = []struct{}{}
+	}
+	if r.Form.Has("links") {
+		resp["links"] = []struct{}{}
+	}
+	if r.Form.Has("items") {
+		items, total, err := a.feverItems(r, user)
+		if err != nil {
+			respondWithQueryError(w, err)
+			return
+		}
+		resp["items"] = items
+		resp["total_items"] = total
+	}
+	if r.Form.Has("unread_item_ids") || r.Form.Has("mark") {
+		seqs, err := a.s.db.GetUnreadItemSeqs(ctx, user.ID)
+		if err != nil {
+			respondWithDBError(w, err)
+			return
+		}
+		resp["unread_item_ids"] = joinSeqs(seqs)
+	}
+	if r.Form.Has("saved_item_ids") || r.Form.Has("mark") {
+		seqs, err := a.s.db.GetStarredItemSeqs(ctx, user.ID)
+		if err != nil {
+			respondWithDBError(w, err)
+			return
+		}
+		resp["saved_item_ids"] = joinSeqs(seqs)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// feverItems reads up to 50 items after since_id, before max_id, or listed
+// in with_ids.
+func (a *apiServer) feverItems(r *http.Request, user database.User) ([]feverItem, int64, error) {
+	params := database.GetFeverItemsParams{
+		UserID: user.ID,
+		Limit:  feverItemLimit,
+	}
+	var err error
+	if params.SinceID, err = formInt(r, "since_id"); err != nil {
+		return nil, 0, err
+	}
+	if params.MaxID, err = formInt(r, "max_id"); err != nil {
+		return nil, 0, err
+	}
+	if withIDs := r.Form.Get("with_ids"); withIDs != "" {
+		params.WithIds = []int64{}
+		for _, field := range strings.Split(withIDs, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+			if err != nil {
+				return nil, 0, invalidQueryError{"with_ids must be a list of numbers"}
+			}
+			params.WithIds = append(params.WithIds, id)
+		}
+	}
+	rows, err := a.s.db.GetFeverItems(r.Context(), params)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := a.s.db.CountFeverItems(r.Context(), user.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	items := make([]feverItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, feverItem{
+			ID:            row.Seq,
+			FeedID:        row.FeedSeq,
+			Title:         row.Title,
+			Author:        row.Author.String,
+			HTML:          sanitizeHTML(row.Description.String, row.Url),
+			URL:           row.Url,
+			IsSaved:       boolInt(row.Starred),
+			IsRead:        boolInt(row.Read),
+			CreatedOnTime: row.SortDate.Unix(),
+		})
+	}
+	return items, total, nil
+}
+
+// feverMark applies mark=item|feed|group requests.
+func (a *apiServer) feverMark(r *http.Request, user database.User) error {
+	ctx := r.Context()
+	id, err := formInt(r, "id")
+	if err != nil {
+		return err
+	}
+	if !id.Valid {
+		return invalidQueryError{"mark requires an id"}
+	}
+	as := r.Form.Get("as")
+	switch r.Form.Get("mark") {
+	case "item":
+		postID, err := a.s.db.GetPostIDBySeq(ctx, id.Int64)
+		if err != nil {
+			return err
+		}
+		switch as {
+		case "read", "unread":
+			return setPostRead(ctx, a.s, user.ID, postID, as == "read")
+		case "saved", "unsaved":
+			return setPostStarred(ctx, a.s, user.ID, postID, as == "saved")
+		}
+	case "feed", "group":
+		if as != "read" {
+			break
+		}
+		before, err := formInt(r, "before")
+		if err != nil {
+			return err
+		}
+		params := database.MarkFeedsReadBeforeParams{
+			UserID: user.ID,
+			Before: time.Now(),
+		}
+		if before.Valid {
+			params.Before = time.Unix(before.Int64, 0).UTC()
+		}
+		if r.Form.Get("mark") == "feed" {
+			params.FeedSeq = id
+		} else if id.Int64 != 0 && id.Int64 != feverGroupID {
+			// Only the "Kindling" super-group (0) and our one group exist.
+			return nil
+		}
+		return a.s.db.MarkFeedsReadBefore(ctx, params)
+	}
+	return invalidQueryError{fmt.Sprintf("Cannot mark %s as %s", r.Form.Get("mark"), as)}
+}
+
+func formInt(r *http.Request, name string) (sql.NullInt64, error) {
+	value := r.Form.Get(name)
+	if value == "" {
+		return sql.NullInt64{}, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return sql.NullInt64{}, invalidQueryError{fmt.Sprintf("%s must be a number", name)}
+	}
+	return sql.NullInt64{Int64: n, Valid: true}, nil
+}
+
+func joinSeqs(seqs []int64) string {
+	parts := make([]string, 0, len(seqs))
+	for _, seq := range seqs {
+		parts = append(parts, strconv.FormatInt(seq, 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strings"
+)
+
+// parseFlags parses args against fs, allowing flags and positional arguments
+// to be mixed (e.g. `browse 10 --unread`).  The positional arguments are
+// returned in order.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// stringList is a flag.Value for flags that may be given more than once,
+// collecting every value in order.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func (l *stringList) Get() any {
+	return []string(*l)
+}
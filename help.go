@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+func (c *commands) handlerHelp(s *state, cmd command) error {
+	if len(cmd.args) > 1 {
+		return cmd.usageError("Too many arguments.")
+	}
+	if len(cmd.args) == 0 {
+		c.printHelp(s.out)
+		return nil
+	}
+	spec, ok := c.names[cmd.args[0]]
+	if !ok {
+		if suggestion := c.suggest(cmd.args[0]); suggestion != "" {
+			return fmt.Errorf("Command %s not found.  Did you mean %s?", cmd.args[0], suggestion)
+		}
+		return fmt.Errorf("Command %s not found", cmd.args[0])
+	}
+	spec.printHelp(s.out)
+	return nil
+}
+
+// sortedNames lists the registered commands alphabetically.
+func (c *commands) sortedNames() []string {
+	names := make([]string, 0, len(c.names))
+	for name := range c.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *commands) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "gator - a blog feed aggregator")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage: gator <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	for _, name := range c.visibleNames() {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, c.names[name].description)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run \"gator help <command>\" or \"gator <command> --help\" for details.")
+}
+
+func (spec commandSpec) printHelp(w io.Writer) {
+	fmt.Fprintf(w, "Usage: gator %s\n\n%s\n", spec.usage, spec.description)
+	fs := spec.flagSet()
+	var hasFlags bool
+	fs.VisitAll(func(f *flag.Flag) {
+		if !hasFlags {
+			fmt.Fprintln(w, "\nFlags:")
+			hasFlags = true
+		}
+		typeName, usage := flag.UnquoteUsage(f)
+		line := "  --" + f.Name
+		if typeName != "" {
+			line += " <" + typeName + ">"
+		}
+		fmt.Fprintln(w, line)
+		if f.DefValue != "" && f.DefValue != "false" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintf(w, "      %s\n", usage)
+	})
+}
+
+// suggest returns the registered command closest to a mistyped name, or an
+// empty string if nothing is close enough to be a likely typo.
+func (c *commands) suggest(name string) string {
+	best := ""
+	bestDistance := 3
+	for _, candidate := range c.visibleNames() {
+		if strings.HasPrefix(candidate, name) && len(name) >= 2 {
+			return candidate
+		}
+		if d := editDistance(name, candidate); d < bestDistance {
+			best = candidate
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/term"
+)
+
+// htmlText renders an HTML description as plain text for the terminal:
+// paragraphs wrapped to width, lists with bullets or numbers, quotes marked
+// with "> ", and links numbered in the text and listed at the end.  base is
+// the post's URL, which relative links are resolved against.
+func htmlText(s, base string, width int) []string {
+	root := parseHTMLFragment(sanitizeHTML(s, base))
+	if root == nil {
+		return nil
+	}
+	r := &textRenderer{}
+	r.walk(root)
+	r.flush()
+	lines := r.lines(width)
+	if len(r.links) > 0 {
+		lines = append(lines, "")
+		for i, link := range r.links {
+			lines = append(lines, fmt.Sprintf("[%d] %s", i+1, link))
+		}
+	}
+	return lines
+}
+
+// terminalWidth is the width of the terminal text is written to, or 80 when
+// it is not a terminal.
+func terminalWidth() int {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	return 80
+}
+
+// textBlock is a paragraph, list item or the like.  Its first line starts
+// with first, and the lines after with indent.
+type textBlock struct {
+	first, indent string
+	text          string
+	pre           bool
+	gap           bool // a blank line before
+}
+
+type textRenderer struct {
+	blocks []textBlock
+	text   strings.Builder
+	indent string
+	bullet string // for the next block, in place of the end of indent
+	gap    bool
+	pre    bool
+	lists  []int // the next number of each open ordered list, or -1
+	links  []string
+}
+
+// flush ends the current block.
+func (r *textRenderer) flush() {
+	text := r.text.String()
+	r.text.Reset()
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	first := r.indent
+	if r.bullet != "" {
+		first = r.indent[:len(r.indent)-len(r.bullet)] + r.bullet
+		r.bullet = ""
+	}
+	r.blocks = append(r.blocks, textBlock{
+		first:  first,
+		indent: r.indent,
+		text:   text,
+		pre:    r.pre,
+		gap:    r.gap && len(r.blocks) > 0,
+	})
+	r.gap = false
+}
+
+// paragraph starts a new block with a blank line before it.
+func (r *textRenderer) paragraph() {
+	r.flush()
+	r.gap = true
+}
+
+func (r *textRenderer) walk(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case html.TextNode:
+			if r.pre {
+				r.text.WriteString(c.Data)
+			} else {
+				r.text.WriteString(strings.NewReplacer("\n", " ", "\r", " ", "\t", " ").Replace(c.Data))
+			}
+		case html.ElementNode:
+			r.element(c)
+		}
+	}
+}
+
+func (r *textRenderer) element(n *html.Node) {
+	switch n.DataAtom {
+	case atom.Br:
+		if r.pre {
+			r.text.WriteString("\n")
+		} else {
+			r.flush()
+		}
+	case atom.Hr:
+		r.paragraph()
+		r.text.WriteString("---")
+		r.paragraph()
+	case atom.P, atom.Div, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Figure, atom.Table, atom.Dl, atom.Section, atom.Article, atom.Header, atom.Footer:
+		r.paragraph()
+		r.walk(n)
+		r.paragraph()
+	case atom.Tr, atom.Dt, atom.Caption, atom.Figcaption:
+		r.flush()
+		r.walk(n)
+		r.flush()
+	case atom.Td, atom.Th:
+		r.text.WriteString(" ")
+		r.walk(n)
+		r.text.WriteString(" ")
+	case atom.Pre:
+		r.paragraph()
+		r.pre = true
+		r.walk(n)
+		r.flush()
+		r.pre = false
+		r.gap = true
+	case atom.Blockquote, atom.Dd:
+		prefix := "  "
+		if n.DataAtom == atom.Blockquote {
+			prefix = "> "
+			r.paragraph()
+		} else {
+			r.flush()
+		}
+		indent := r.indent
+		r.indent += prefix
+		r.walk(n)
+		r.flush()
+		r.indent = indent
+		if n.DataAtom == atom.Blockquote {
+			r.gap = true
+		}
+	case atom.Ul, atom.Ol:
+		if len(r.lists) == 0 {
+			r.paragraph()
+		} else {
+			r.flush()
+		}
+		next := -1
+		if n.DataAtom == atom.Ol {
+			next = 1
+			for _, a := range n.Attr {
+				if a.Key == "start" {
+					fmt.Sscan(a.Val, &next)
+				}
+			}
+		}
+		r.lists = append(r.lists, next)
+		r.walk(n)
+		r.flush()
+		r.lists = r.lists[:len(r.lists)-1]
+		if len(r.lists) == 0 {
+			r.gap = true
+		}
+	case atom.Li:
+		r.flush()
+		bullet := "- "
+		if len(r.lists) > 0 && r.lists[len(r.lists)-1] >= 0 {
+			bullet = fmt.Sprintf("%d. ", r.lists[len(r.lists)-1])
+			r.lists[len(r.lists)-1]++
+		}
+		indent := r.indent
+		r.indent += strings.Repeat(" ", len(bullet))
+		r.bullet = bullet
+		r.walk(n)
+		r.flush()
+		r.indent, r.bullet = indent, ""
+	case atom.A:
+		start := r.text.Len()
+		r.walk(n)
+		label := strings.TrimSpace(r.text.String()[start:])
+		href := strings.TrimSpace(attr(n, "href"))
+		if href == "" || href == label || strings.HasPrefix(href, "#") {
+			break
+		}
+		i := slices.Index(r.links, href)
+		if i < 0 {
+			r.links = append(r.links, href)
+			i = len(r.links) - 1
+		}
+		fmt.Fprintf(&r.text, "[%d]", i+1)
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			fmt.Fprintf(&r.text, "[image: %s]", alt)
+		}
+	default:
+		r.walk(n)
+	}
+}
+
+// lines wraps the blocks to width.
+func (r *textRenderer) lines(width int) []string {
+	var lines []string
+	for _, b := range r.blocks {
+		if b.gap && len(lines) > 0 && lines[len(lines)-1] != "" {
+			lines = append(lines, "")
+		}
+		var body []string
+		if b.pre {
+			body = strings.Split(strings.Trim(b.text, "\n"), "\n")
+		} else {
+			body = wrapText(b.text, width-displayWidth(b.indent))
+		}
+		for i, line := range body {
+			prefix := b.indent
+			if i == 0 {
+				prefix = b.first
+			}
+			lines = append(lines, strings.TrimRight(prefix+cleanLine(line), " "))
+		}
+	}
+	return lines
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
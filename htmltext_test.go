@@ -0,0 +1,61 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHTMLText(t *testing.T) {
+	const base = "https://example.com/blog/post.html"
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"paragraphs", "<p>One</p><p>Two</p>", []string{"One", "", "Two"}},
+		{"wrapped", "<p>one two three four</p>", []string{"one two three", "four"}},
+		{"line break", "a<br>b", []string{"a", "b"}},
+		{"list", "<ul><li>a</li><li>b</li></ul>", []string{"- a", "- b"}},
+		{"numbered list", `<ol start="3"><li>a</li><li>b</li></ol>`, []string{"3. a", "4. b"}},
+		{"quote", "<p>said</p><blockquote>hello</blockquote>", []string{"said", "", "> hello"}},
+		{"pre", "<pre>a  b\n  c</pre>", []string{"a  b", "  c"}},
+		{"image", `<img src="/a.png" alt="A cat">`, []string{"[image: A cat]"}},
+		{"script", "<p>hi</p><script>alert(1)</script>", []string{"hi"}},
+
+		// Links are numbered in the text and listed at the end.
+		{"link", `<p>See <a href="https://example.com/a">this</a>.</p>`, []string{"See this[1].", "", "[1] https://example.com/a"}},
+		{"links", `<a href="https://a.example/">a</a> <a href="https://b.example/">b</a>`, []string{"a[1] b[2]", "", "[1] https://a.example/", "[2] https://b.example/"}},
+		{"repeated link", `<a href="https://a.example/">a</a> <a href="https://a.example/">again</a>`, []string{"a[1] again[1]", "", "[1] https://a.example/"}},
+		{"relative link", `<a href="other.html">next</a>`, []string{"next[1]", "", "[1] https://example.com/blog/other.html"}},
+		{"link that is its own label", `<a href="https://a.ex/">https://a.ex/</a>`, []string{"https://a.ex/"}},
+		{"fragment link", `<a href="#note">note</a>`, []string{"note"}},
+		{"unsafe link", `<a href="javascript:alert(1)">x</a>`, []string{"x"}},
+
+		// Escape sequences in the text are not passed to the terminal.
+		{"escape sequence", "<p>a\x1b[2Jb</p>", []string{"a [2Jb"}},
+	}
+	for _, tc := range tests {
+		if got := htmlText(tc.in, base, 14); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: htmlText(%q)\n got %q\nwant %q", tc.name, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestCleanLine(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"ünïcödé ✓", "ünïcödé ✓"},
+		{"a\x1b[31mred", "a [31mred"},
+		{"a\x1b]0;title\x07b", "a ]0;title b"},
+		{"tab\tand\nnewline\r", "tab and newline "},
+		{"c1\u009bcontrol", "c1 control"},
+	}
+	for _, tc := range tests {
+		if got := cleanLine(tc.in); got != tc.want {
+			t.Errorf("cleanLine(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -8,8 +8,15 @@ import (
 const configFileName = ".gatorconfig.json"
 
 type Config struct {
-	Db_url string `json:"db_url"`
-	User   string `json:"user"`
+	Db_url       string `json:"db_url"`
+	User         string `json:"user"`
+	SessionToken string `json:"session_token,omitempty"`
+	// KeepDays and KeepPosts are the retention limits `gator prune` applies
+	// to feeds without their own; 0 keeps posts forever.
+	KeepDays  int `json:"keep_days,omitempty"`
+	KeepPosts int `json:"keep_posts,omitempty"`
+	// path is the file the config was read from, and is written back to.
+	path string
 }
 
 func getConfigFilePath() (string, error) {
@@ -20,17 +27,23 @@ func getConfigFilePath() (string, error) {
 	return homeDir + "/" + configFileName, nil
 }
 
+// Read reads the config file in the user's home directory.
 func Read() (Config, error) {
 	path, err := getConfigFilePath()
 	if err != nil {
 		return Config{}, err
 	}
+	return ReadFile(path)
+}
+
+// ReadFile reads the config file at path.  Changes are written back to it.
+func ReadFile(path string) (Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return Config{}, err
 	}
 
-	var cfg Config
+	cfg := Config{path: path}
 	err = json.Unmarshal(data, &cfg)
 	if err != nil {
 		return Config{}, err
@@ -39,19 +52,34 @@ func Read() (Config, error) {
 	return cfg, nil
 }
 
-func (c Config) SetUser(user string) error {
+func (c *Config) SetUser(user string) error {
+	c.User = user
+	return c.write()
+}
+
+// SetSession records the logged-in user and their session token.  An empty
+// token logs the user out.
+func (c *Config) SetSession(user, token string) error {
 	c.User = user
+	c.SessionToken = token
+	return c.write()
+}
+
+func (c Config) write() error {
 	output, err := json.Marshal(c)
 	if err != nil {
 		return err
 	}
-	path, err := getConfigFilePath()
-	if err != nil {
-		return err
+	path := c.path
+	if path == "" {
+		path, err = getConfigFilePath()
+		if err != nil {
+			return err
+		}
 	}
-	err = os.WriteFile(path, output, 0644)
-	if err != nil {
+	// The file holds a session token, so keep it private.
+	if err := os.WriteFile(path, output, 0600); err != nil {
 		return err
 	}
-	return nil
+	return os.Chmod(path, 0600)
 }
@@ -7,9 +7,11 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
@@ -22,9 +24,9 @@ WITH inserted_feed_follow AS (
     $4,
     $5
     )
-    RETURNING id, created_at, updated_at, user_id, feed_id
+    RETURNING id, created_at, updated_at, user_id, feed_id, alias, folders
 )
-  SELECT inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, 
+  SELECT inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.alias, inserted_feed_follow.folders, 
   feeds.name AS feed_name, 
   users.name AS user_name
   FROM inserted_feed_follow
@@ -46,6 +48,8 @@ type CreateFeedFollowRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Alias     sql.NullString
+	Folders   []string
 	FeedName  string
 	UserName  string
 }
@@ -65,6 +69,8 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Alias,
+		pq.Array(&i.Folders),
 		&i.FeedName,
 		&i.UserName,
 	)
@@ -93,15 +99,22 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT users.name AS user_name, feeds.name AS feed_name FROM feed_follows
+SELECT users.name AS user_name, feeds.name AS feed_name, feeds.url AS feed_url, feed_follows.created_at,
+  feed_follows.alias, feed_follows.folders
+FROM feed_follows
 INNER JOIN users ON user_id = users.id
 INNER JOIN feeds ON feed_id = feeds.id
 WHERE users.name = $1
+ORDER BY COALESCE(feed_follows.alias, feeds.name)
 `
 
 type GetFeedFollowsForUserRow struct {
-	UserName string
-	FeedName string
+	UserName  string
+	FeedName  string
+	FeedUrl   string
+	CreatedAt time.Time
+	Alias     sql.NullString
+	Folders   []string
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, name string) ([]GetFeedFollowsForUserRow, error) {
@@ -113,7 +126,91 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, name string) ([]Get
 	var items []GetFeedFollowsForUserRow
 	for rows.Next() {
 		var i GetFeedFollowsForUserRow
-		if err := rows.Scan(&i.UserName, &i.FeedName); err != nil {
+		if err := rows.Scan(
+			&i.UserName,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.CreatedAt,
+			&i.Alias,
+			pq.Array(&i.Folders),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowFolders = `-- name: GetFollowFolders :many
+SELECT DISTINCT unnest(folders)::text AS folder
+FROM feed_follows
+WHERE user_id = $1
+ORDER BY folder
+`
+
+func (q *Queries) GetFollowFolders(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowFolders, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var folder string
+		if err := rows.Scan(&folder); err != nil {
+			return nil, err
+		}
+		items = append(items, folder)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowedFeedsWithUnread = `-- name: GetFollowedFeedsWithUnread :many
+SELECT feeds.id, COALESCE(feed_follows.alias, feeds.name) AS name, feeds.url,
+  COUNT(posts.id) FILTER (WHERE post_reads.post_id IS NULL) AS unread
+FROM feed_follows
+INNER JOIN feeds ON feed_follows.feed_id = feeds.id
+LEFT JOIN posts ON posts.feed_id = feeds.id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+GROUP BY feeds.id, feed_follows.id
+ORDER BY name
+`
+
+type GetFollowedFeedsWithUnreadRow struct {
+	ID     uuid.UUID
+	Name   string
+	Url    string
+	Unread int64
+}
+
+func (q *Queries) GetFollowedFeedsWithUnread(ctx context.Context, userID uuid.UUID) ([]GetFollowedFeedsWithUnreadRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedsWithUnread, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowedFeedsWithUnreadRow
+	for rows.Next() {
+		var i GetFollowedFeedsWithUnreadRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.Unread,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -126,3 +223,49 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, name string) ([]Get
 	}
 	return items, nil
 }
+
+const setFollowAlias = `-- name: SetFollowAlias :execrows
+UPDATE feed_follows
+SET alias = $1, updated_at = NOW()
+FROM feeds
+WHERE feed_follows.feed_id = feeds.id
+AND feed_follows.user_id = $2
+AND feeds.url = $3
+`
+
+type SetFollowAliasParams struct {
+	Alias  sql.NullString
+	UserID uuid.UUID
+	Url    string
+}
+
+func (q *Queries) SetFollowAlias(ctx context.Context, arg SetFollowAliasParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFollowAlias, arg.Alias, arg.UserID, arg.Url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFollowFolders = `-- name: SetFollowFolders :execrows
+UPDATE feed_follows
+SET folders = $1, updated_at = NOW()
+FROM feeds
+WHERE feed_follows.feed_id = feeds.id
+AND feed_follows.user_id = $2
+AND feeds.url = $3
+`
+
+type SetFollowFoldersParams struct {
+	Folders []string
+	UserID  uuid.UUID
+	Url     string
+}
+
+func (q *Queries) SetFollowFolders(ctx context.Context, arg SetFollowFoldersParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFollowFolders, pq.Array(arg.Folders), arg.UserID, arg.Url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
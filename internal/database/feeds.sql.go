@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,7 +23,7 @@ VALUES(
   $5,
   $6
   )
-  RETURNING id, created_at, updated_at, name, user_id, url, last_fetched_at
+  RETURNING id, created_at, updated_at, name, user_id, url, last_fetched_at, seq, last_fetch_error, keep_days, keep_posts
 `
 
 type CreateFeedParams struct {
@@ -52,19 +53,32 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.UserID,
 		&i.Url,
 		&i.LastFetchedAt,
+		&i.Seq,
+		&i.LastFetchError,
+		&i.KeepDays,
+		&i.KeepPosts,
 	)
 	return i, err
 }
 
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
 const getAllFeeds = `-- name: GetAllFeeds :many
-SELECT feeds.name, feeds.url, users.name FROM feeds
+SELECT feeds.name, feeds.url, users.name AS owner FROM feeds
 INNER JOIN users ON user_id = users.id
 `
 
 type GetAllFeedsRow struct {
-	Name   string
-	Url    string
-	Name_2 string
+	Name  string
+	Url   string
+	Owner string
 }
 
 func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
@@ -76,7 +90,7 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
 	var items []GetAllFeedsRow
 	for rows.Next() {
 		var i GetAllFeedsRow
-		if err := rows.Scan(&i.Name, &i.Url, &i.Name_2); err != nil {
+		if err := rows.Scan(&i.Name, &i.Url, &i.Owner); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -91,7 +105,7 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
 }
 
 const getFeedByUrl = `-- name: GetFeedByUrl :one
-SELECT id, created_at, updated_at, name, user_id, url, last_fetched_at FROM feeds WHERE url = $1
+SELECT id, created_at, updated_at, name, user_id, url, last_fetched_at, seq, last_fetch_error, keep_days, keep_posts FROM feeds WHERE url = $1
 `
 
 func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
@@ -105,13 +119,111 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.UserID,
 		&i.Url,
 		&i.LastFetchedAt,
+		&i.Seq,
+		&i.LastFetchError,
+		&i.KeepDays,
+		&i.KeepPosts,
+	)
+	return i, err
+}
+
+const getFeedDetails = `-- name: GetFeedDetails :one
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.user_id, feeds.url, feeds.last_fetched_at, feeds.seq, feeds.last_fetch_error, feeds.keep_days, feeds.keep_posts, users.name AS owner,
+  (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id) AS followers,
+  (SELECT COUNT(*) FROM posts WHERE posts.feed_id = feeds.id) AS posts,
+  (SELECT COUNT(*) FROM feeds AS queued
+    WHERE queued.id <> feeds.id
+    AND (queued.last_fetched_at IS NULL OR queued.last_fetched_at < feeds.last_fetched_at)) AS queued_ahead
+FROM feeds
+INNER JOIN users ON feeds.user_id = users.id
+WHERE feeds.url = $1
+`
+
+type GetFeedDetailsRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	UserID         uuid.UUID
+	Url            string
+	LastFetchedAt  sql.NullTime
+	Seq            int64
+	LastFetchError sql.NullString
+	KeepDays       sql.NullInt32
+	KeepPosts      sql.NullInt32
+	Owner          string
+	Followers      int64
+	Posts          int64
+	QueuedAhead    int64
+}
+
+func (q *Queries) GetFeedDetails(ctx context.Context, url string) (GetFeedDetailsRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedDetails, url)
+	var i GetFeedDetailsRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.UserID,
+		&i.Url,
+		&i.LastFetchedAt,
+		&i.Seq,
+		&i.LastFetchError,
+		&i.KeepDays,
+		&i.KeepPosts,
+		&i.Owner,
+		&i.Followers,
+		&i.Posts,
+		&i.QueuedAhead,
 	)
 	return i, err
 }
 
+const getFeedsOwnedBy = `-- name: GetFeedsOwnedBy :many
+SELECT feeds.name, feeds.url,
+  (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id) AS followers
+FROM feeds
+WHERE feeds.user_id = $1
+ORDER BY feeds.name
+`
+
+type GetFeedsOwnedByRow struct {
+	Name      string
+	Url       string
+	Followers int64
+}
+
+func (q *Queries) GetFeedsOwnedBy(ctx context.Context, userID uuid.UUID) ([]GetFeedsOwnedByRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsOwnedBy, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedsOwnedByRow
+	for rows.Next() {
+		var i GetFeedsOwnedByRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.Followers,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
 SELECT id, url FROM feeds
-ORDER BY updated_at ASC NULLS FIRST
+ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT 1
 `
 
@@ -129,11 +241,84 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (GetNextFeedToFetchRow
 
 const markFeedFetched = `-- name: MarkFeedFetched :exec
 UPDATE feeds
-SET updated_at = NOW()
+SET last_fetched_at = NOW(), updated_at = NOW(), last_fetch_error = $2
+WHERE id = $1
+`
+
+type MarkFeedFetchedParams struct {
+	ID             uuid.UUID
+	LastFetchError sql.NullString
+}
+
+func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.ID, arg.LastFetchError)
+	return err
+}
+
+const renameFeed = `-- name: RenameFeed :exec
+UPDATE feeds
+SET name = $2, updated_at = NOW()
 WHERE id = $1
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+type RenameFeedParams struct {
+	ID   uuid.UUID
+	Name string
+}
+
+func (q *Queries) RenameFeed(ctx context.Context, arg RenameFeedParams) error {
+	_, err := q.db.ExecContext(ctx, renameFeed, arg.ID, arg.Name)
 	return err
 }
+
+const setFeedRetention = `-- name: SetFeedRetention :exec
+UPDATE feeds
+SET keep_days = $2, keep_posts = $3, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedRetentionParams struct {
+	ID        uuid.UUID
+	KeepDays  sql.NullInt32
+	KeepPosts sql.NullInt32
+}
+
+func (q *Queries) SetFeedRetention(ctx context.Context, arg SetFeedRetentionParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedRetention, arg.ID, arg.KeepDays, arg.KeepPosts)
+	return err
+}
+
+const setFeedUrl = `-- name: SetFeedUrl :exec
+UPDATE feeds
+SET url = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedUrlParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) SetFeedUrl(ctx context.Context, arg SetFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedUrl, arg.ID, arg.Url)
+	return err
+}
+
+const transferFeeds = `-- name: TransferFeeds :execrows
+UPDATE feeds
+SET user_id = $1, updated_at = NOW()
+WHERE user_id = $2
+`
+
+type TransferFeedsParams struct {
+	ToUserID   uuid.UUID
+	FromUserID uuid.UUID
+}
+
+func (q *Queries) TransferFeeds(ctx context.Context, arg TransferFeedsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, transferFeeds, arg.ToUserID, arg.FromUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
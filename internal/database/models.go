@@ -12,13 +12,17 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	UserID        uuid.UUID
-	Url           string
-	LastFetchedAt sql.NullTime
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	UserID         uuid.UUID
+	Url            string
+	LastFetchedAt  sql.NullTime
+	Seq            int64
+	LastFetchError sql.NullString
+	KeepDays       sql.NullInt32
+	KeepPosts      sql.NullInt32
 }
 
 type FeedFollow struct {
@@ -27,6 +31,8 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Alias     sql.NullString
+	Folders   []string
 }
 
 type Post struct {
@@ -38,11 +44,48 @@ type Post struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Author      sql.NullString
+	Categories  []string
+	Seq         int64
+	EffectiveAt time.Time
+	DateSource  string
 }
 
-type User struct {
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostStar struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	StarredAt time.Time
+}
+
+type SavedSearch struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
+	UserID    uuid.UUID
 	Name      string
+	Filters   string
+	TokenHash string
+}
+
+type Session struct {
+	TokenHash string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type User struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Name         string
+	ApiKeyHash   string
+	PasswordHash sql.NullString
+	Role         string
+	FeverKeyHash sql.NullString
 }
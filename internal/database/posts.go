@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The sources of a post's effective_at, the date it sorts by.
+const (
+	DateSourcePublished = "published" // the item's own publish date
+	DateSourceFeed      = "feed"      // the feed's lastBuildDate
+	DateSourceFetched   = "fetched"   // when gator first stored the post
+)
+
+// NewPost is one of the items passed to CreatePosts.  Nil fields are stored
+// as NULL.
+type NewPost struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Description *string    `json:"description"`
+	PublishedAt *time.Time `json:"published_at"`
+	Author      *string    `json:"author"`
+	Categories  []string   `json:"categories"`
+	EffectiveAt time.Time  `json:"effective_at"`
+	DateSource  string     `json:"date_source"`
+}
+
+// EncodePosts encodes posts as the items argument of CreatePosts.  Times are
+// written in UTC: Postgres reads them into timestamp columns, which drop an
+// offset rather than apply it.
+func EncodePosts(posts []NewPost) (json.RawMessage, error) {
+	items := make([]NewPost, len(posts))
+	for i, post := range posts {
+		if post.PublishedAt != nil {
+			published := post.PublishedAt.UTC()
+			post.PublishedAt = &published
+		}
+		post.EffectiveAt = post.EffectiveAt.UTC()
+		items[i] = post
+	}
+	return json.Marshal(items)
+}
+
+// GetPostsForUserParams are the parameters of GetNewestPostsForUser and
+// GetOldestPostsForUser, with OldestFirst choosing between them.
+type GetPostsForUserParams struct {
+	UserID      uuid.UUID
+	FeedUrl     sql.NullString
+	Since       sql.NullTime
+	Until       sql.NullTime
+	Author      sql.NullString
+	Tag         sql.NullString
+	Search      sql.NullString
+	Folder      sql.NullString
+	UnreadOnly  bool
+	StarredOnly bool
+	AfterDate   sql.NullTime
+	AfterID     uuid.NullUUID
+	OldestFirst bool
+	Limit       int32
+}
+
+// GetPostsForUserRow is a post in a user's timeline.
+type GetPostsForUserRow = GetNewestPostsForUserRow
+
+// GetPostsForUser reads a page of a user's timeline from q, newest or oldest
+// first.
+func GetPostsForUser(ctx context.Context, q Querier, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	params := GetNewestPostsForUserParams{
+		UserID:      arg.UserID,
+		FeedUrl:     arg.FeedUrl,
+		Since:       arg.Since,
+		Until:       arg.Until,
+		Author:      arg.Author,
+		Tag:         arg.Tag,
+		Search:      arg.Search,
+		Folder:      arg.Folder,
+		UnreadOnly:  arg.UnreadOnly,
+		StarredOnly: arg.StarredOnly,
+		AfterDate:   arg.AfterDate,
+		AfterID:     arg.AfterID,
+		Limit:       arg.Limit,
+	}
+	if !arg.OldestFirst {
+		return q.GetNewestPostsForUser(ctx, params)
+	}
+	rows, err := q.GetOldestPostsForUser(ctx, GetOldestPostsForUserParams(params))
+	if err != nil {
+		return nil, err
+	}
+	var posts []GetPostsForUserRow
+	for _, row := range rows {
+		posts = append(posts, GetPostsForUserRow(row))
+	}
+	return posts, nil
+}
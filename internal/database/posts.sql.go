@@ -8,13 +8,76 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countPrunablePosts = `-- name: CountPrunablePosts :many
+SELECT feeds.name, feeds.url, COUNT(*) AS posts
+FROM (
+  SELECT posts.id, posts.feed_id, posts.created_at,
+    ROW_NUMBER() OVER (PARTITION BY posts.feed_id
+      ORDER BY posts.effective_at DESC, posts.seq DESC) AS newness
+  FROM posts
+) AS ranked
+INNER JOIN feeds ON ranked.feed_id = feeds.id
+WHERE (
+  ranked.created_at < $1::timestamp
+    - make_interval(days => NULLIF(COALESCE(feeds.keep_days, $2::integer), 0))
+  OR ranked.newness > NULLIF(COALESCE(feeds.keep_posts, $3::integer), 0)
+)
+AND NOT EXISTS (SELECT 1 FROM post_stars WHERE post_stars.post_id = ranked.id)
+GROUP BY feeds.id
+ORDER BY feeds.name
+`
+
+type CountPrunablePostsParams struct {
+	Now       time.Time
+	KeepDays  int32
+	KeepPosts int32
+}
+
+type CountPrunablePostsRow struct {
+	Name  string
+	Url   string
+	Posts int64
+}
+
+// CountPrunablePosts counts, for each feed, the posts PrunePosts would
+// delete: those stored more than keep_days ago, and those past the
+// keep_posts newest of their feed.  keep_days runs from created_at, when
+// gator stored the post, rather than from its own date: a post still in its
+// feed that was deleted for being old would be stored again, as unread, on
+// the next fetch.  A feed's own limits override the given ones, and a limit
+// of 0 keeps posts forever.  Starred posts are never pruned.
+func (q *Queries) CountPrunablePosts(ctx context.Context, arg CountPrunablePostsParams) ([]CountPrunablePostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countPrunablePosts, arg.Now, arg.KeepDays, arg.KeepPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountPrunablePostsRow
+	for rows.Next() {
+		var i CountPrunablePostsRow
+		if err := rows.Scan(&i.Name, &i.Url, &i.Posts); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories, effective_at, date_source)
 VALUES(
   $1,
   $2,
@@ -23,9 +86,13 @@ VALUES(
   $5,
   $6,
   $7,
-  $8
+  $8,
+  $9,
+  $10,
+  $11,
+  $12
   )
-  RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+  RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories, seq, effective_at, date_source
 `
 
 type CreatePostParams struct {
@@ -37,6 +104,10 @@ type CreatePostParams struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Author      sql.NullString
+	Categories  []string
+	EffectiveAt time.Time
+	DateSource  string
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -49,6 +120,10 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Author,
+		pq.Array(arg.Categories),
+		arg.EffectiveAt,
+		arg.DateSource,
 	)
 	var i Post
 	err := row.Scan(
@@ -60,43 +135,277 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.Seq,
+		&i.EffectiveAt,
+		&i.DateSource,
 	)
 	return i, err
 }
 
-const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.title, posts.description, posts.url FROM posts
-WHERE posts.feed_id IN (
-  SELECT feed_follows.feed_id FROM feed_follows 
-  INNER JOIN users ON user_id = users.id
-  INNER JOIN feeds ON feed_id = feeds.id
-  WHERE users.name = $1
+const createPosts = `-- name: CreatePosts :execrows
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories,
+  effective_at, date_source)
+SELECT item.id, $1::timestamp, $1::timestamp, item.title, item.url,
+  item.description, item.published_at, $2::uuid, item.author, COALESCE(item.categories, '{}'),
+  item.effective_at, item.date_source
+FROM jsonb_to_recordset($3::jsonb)
+  AS item(id uuid, title text, url text, description text, published_at timestamp, author text, categories text[],
+    effective_at timestamp, date_source text)
+ON CONFLICT DO NOTHING
+`
+
+type CreatePostsParams struct {
+	CreatedAt time.Time
+	FeedID    uuid.UUID
+	Items     json.RawMessage
+}
+
+// CreatePosts stores a batch of a feed's items in one statement.  items is a
+// JSON array of database.NewPost.  Items whose URL is already stored are
+// skipped, so the row count is the number of new posts.
+func (q *Queries) CreatePosts(ctx context.Context, arg CreatePostsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createPosts, arg.CreatedAt, arg.FeedID, arg.Items)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const followsPost = `-- name: FollowsPost :one
+SELECT EXISTS (
+  SELECT 1 FROM posts
+  INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+  WHERE posts.id = $2 AND feed_follows.user_id = $1
 )
-ORDER BY published_at DESC
-LIMIT $2
 `
 
-type GetPostsForUserParams struct {
-	Name  string
-	Limit int32
+type FollowsPostParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+// FollowsPost reports whether the user follows the feed a post is in, which
+// they must to mark it read or star it.
+func (q *Queries) FollowsPost(ctx context.Context, arg FollowsPostParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, followsPost, arg.UserID, arg.PostID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const getNewestPostsForUser = `-- name: GetNewestPostsForUser :many
+SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+  posts.effective_at AS sort_date, posts.date_source,
+  COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+  (post_reads.post_id IS NOT NULL)::boolean AS read,
+  (post_stars.post_id IS NOT NULL)::boolean AS starred
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = $1
+LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = $1
+WHERE ($2::text IS NULL OR feeds.url = $2)
+AND ($3::timestamp IS NULL OR posts.effective_at >= $3)
+AND ($4::timestamp IS NULL OR posts.effective_at < $4)
+AND ($5::text IS NULL OR posts.author ILIKE '%' || $5 || '%')
+AND ($6::text IS NULL OR $6 = ANY(posts.categories))
+AND ($7::text IS NULL
+  OR posts.title ILIKE '%' || $7 || '%'
+  OR posts.description ILIKE '%' || $7 || '%')
+AND ($8::text IS NULL OR $8 = ANY(feed_follows.folders))
+AND (NOT $9::boolean OR post_reads.post_id IS NULL)
+AND (NOT $10::boolean OR post_stars.post_id IS NOT NULL)
+AND ($11::timestamp IS NULL
+  OR (posts.effective_at, posts.id) < ($11, $12::uuid))
+ORDER BY posts.effective_at DESC, posts.id DESC
+LIMIT $13
+`
+
+type GetNewestPostsForUserParams struct {
+	UserID      uuid.UUID
+	FeedUrl     sql.NullString
+	Since       sql.NullTime
+	Until       sql.NullTime
+	Author      sql.NullString
+	Tag         sql.NullString
+	Search      sql.NullString
+	Folder      sql.NullString
+	UnreadOnly  bool
+	StarredOnly bool
+	AfterDate   sql.NullTime
+	AfterID     uuid.NullUUID
+	Limit       int32
+}
+
+type GetNewestPostsForUserRow struct {
+	ID          uuid.UUID
+	Title       string
+	Description sql.NullString
+	Url         string
+	Author      sql.NullString
+	Categories  []string
+	SortDate    time.Time
+	DateSource  string
+	FeedName    string
+	FeedID      uuid.UUID
+	Read        bool
+	Starred     bool
+}
+
+// GetNewestPostsForUser reads a page of a user's timeline, newest first,
+// starting after the post at after_date and after_id if they are given.  It
+// and GetOldestPostsForUser differ only in direction, so that each sorts by
+// the columns of posts_sort_idx and posts_feed_sort_idx and can read a page
+// from one of them in order.
+func (q *Queries) GetNewestPostsForUser(ctx context.Context, arg GetNewestPostsForUserParams) ([]GetNewestPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNewestPostsForUser,
+		arg.UserID,
+		arg.FeedUrl,
+		arg.Since,
+		arg.Until,
+		arg.Author,
+		arg.Tag,
+		arg.Search,
+		arg.Folder,
+		arg.UnreadOnly,
+		arg.StarredOnly,
+		arg.AfterDate,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNewestPostsForUserRow
+	for rows.Next() {
+		var i GetNewestPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Url,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.SortDate,
+			&i.DateSource,
+			&i.FeedName,
+			&i.FeedID,
+			&i.Read,
+			&i.Starred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-type GetPostsForUserRow struct {
+const getOldestPostsForUser = `-- name: GetOldestPostsForUser :many
+SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+  posts.effective_at AS sort_date, posts.date_source,
+  COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+  (post_reads.post_id IS NOT NULL)::boolean AS read,
+  (post_stars.post_id IS NOT NULL)::boolean AS starred
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = $1
+LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = $1
+WHERE ($2::text IS NULL OR feeds.url = $2)
+AND ($3::timestamp IS NULL OR posts.effective_at >= $3)
+AND ($4::timestamp IS NULL OR posts.effective_at < $4)
+AND ($5::text IS NULL OR posts.author ILIKE '%' || $5 || '%')
+AND ($6::text IS NULL OR $6 = ANY(posts.categories))
+AND ($7::text IS NULL
+  OR posts.title ILIKE '%' || $7 || '%'
+  OR posts.description ILIKE '%' || $7 || '%')
+AND ($8::text IS NULL OR $8 = ANY(feed_follows.folders))
+AND (NOT $9::boolean OR post_reads.post_id IS NULL)
+AND (NOT $10::boolean OR post_stars.post_id IS NOT NULL)
+AND ($11::timestamp IS NULL
+  OR (posts.effective_at, posts.id) > ($11, $12::uuid))
+ORDER BY posts.effective_at ASC, posts.id ASC
+LIMIT $13
+`
+
+type GetOldestPostsForUserParams struct {
+	UserID      uuid.UUID
+	FeedUrl     sql.NullString
+	Since       sql.NullTime
+	Until       sql.NullTime
+	Author      sql.NullString
+	Tag         sql.NullString
+	Search      sql.NullString
+	Folder      sql.NullString
+	UnreadOnly  bool
+	StarredOnly bool
+	AfterDate   sql.NullTime
+	AfterID     uuid.NullUUID
+	Limit       int32
+}
+
+type GetOldestPostsForUserRow struct {
+	ID          uuid.UUID
 	Title       string
 	Description sql.NullString
 	Url         string
+	Author      sql.NullString
+	Categories  []string
+	SortDate    time.Time
+	DateSource  string
+	FeedName    string
+	FeedID      uuid.UUID
+	Read        bool
+	Starred     bool
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.Name, arg.Limit)
+// GetOldestPostsForUser is GetNewestPostsForUser, oldest first.
+func (q *Queries) GetOldestPostsForUser(ctx context.Context, arg GetOldestPostsForUserParams) ([]GetOldestPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getOldestPostsForUser,
+		arg.UserID,
+		arg.FeedUrl,
+		arg.Since,
+		arg.Until,
+		arg.Author,
+		arg.Tag,
+		arg.Search,
+		arg.Folder,
+		arg.UnreadOnly,
+		arg.StarredOnly,
+		arg.AfterDate,
+		arg.AfterID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetPostsForUserRow
+	var items []GetOldestPostsForUserRow
 	for rows.Next() {
-		var i GetPostsForUserRow
-		if err := rows.Scan(&i.Title, &i.Description, &i.Url); err != nil {
+		var i GetOldestPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Url,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.SortDate,
+			&i.DateSource,
+			&i.FeedName,
+			&i.FeedID,
+			&i.Read,
+			&i.Starred,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -109,3 +418,102 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const markPostRead = `-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type MarkPostReadParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+func (q *Queries) MarkPostRead(ctx context.Context, arg MarkPostReadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostRead, arg.UserID, arg.PostID, arg.ReadAt)
+	return err
+}
+
+const markPostUnread = `-- name: MarkPostUnread :exec
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id = $2
+`
+
+type MarkPostUnreadParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) MarkPostUnread(ctx context.Context, arg MarkPostUnreadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostUnread, arg.UserID, arg.PostID)
+	return err
+}
+
+const prunePosts = `-- name: PrunePosts :execrows
+DELETE FROM posts
+WHERE posts.id IN (
+  SELECT ranked.id
+  FROM (
+    SELECT posts.id, posts.feed_id, posts.created_at,
+      ROW_NUMBER() OVER (PARTITION BY posts.feed_id
+        ORDER BY posts.effective_at DESC, posts.seq DESC) AS newness
+    FROM posts
+  ) AS ranked
+  INNER JOIN feeds ON ranked.feed_id = feeds.id
+  WHERE (
+    ranked.created_at < $1::timestamp
+      - make_interval(days => NULLIF(COALESCE(feeds.keep_days, $2::integer), 0))
+    OR ranked.newness > NULLIF(COALESCE(feeds.keep_posts, $3::integer), 0)
+  )
+  AND NOT EXISTS (SELECT 1 FROM post_stars WHERE post_stars.post_id = ranked.id)
+)
+`
+
+type PrunePostsParams struct {
+	Now       time.Time
+	KeepDays  int32
+	KeepPosts int32
+}
+
+// PrunePosts deletes the posts CountPrunablePosts counts.
+func (q *Queries) PrunePosts(ctx context.Context, arg PrunePostsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, prunePosts, arg.Now, arg.KeepDays, arg.KeepPosts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const starPost = `-- name: StarPost :exec
+INSERT INTO post_stars (user_id, post_id, starred_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type StarPostParams struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	StarredAt time.Time
+}
+
+func (q *Queries) StarPost(ctx context.Context, arg StarPostParams) error {
+	_, err := q.db.ExecContext(ctx, starPost, arg.UserID, arg.PostID, arg.StarredAt)
+	return err
+}
+
+const unstarPost = `-- name: UnstarPost :exec
+DELETE FROM post_stars
+WHERE user_id = $1 AND post_id = $2
+`
+
+type UnstarPostParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) UnstarPost(ctx context.Context, arg UnstarPostParams) error {
+	_, err := q.db.ExecContext(ctx, unstarPost, arg.UserID, arg.PostID)
+	return err
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestEncodePostsInUTC(t *testing.T) {
+	paris := time.FixedZone("CEST", 2*60*60)
+	published := time.Date(2026, 10, 19, 10, 0, 0, 0, paris)
+	posts := []NewPost{{Title: "Post", PublishedAt: &published, EffectiveAt: published}}
+	data, err := EncodePosts(posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"published_at", "effective_at"} {
+		if got := items[0][field]; got != "2026-10-19T08:00:00Z" {
+			t.Errorf("%s = %v, want 2026-10-19T08:00:00Z", field, got)
+		}
+	}
+	if posts[0].PublishedAt.Location() != paris {
+		t.Error("EncodePosts changed the caller's post")
+	}
+
+	data, err = EncodePosts(nil)
+	if err != nil || string(data) != "[]" {
+		t.Errorf("EncodePosts(nil) = %s, %v; want []", data, err)
+	}
+}
@@ -7,27 +7,45 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const countAdmins = `-- name: CountAdmins :one
+SELECT COUNT(*) FROM users WHERE role = 'admin' AND password_hash IS NOT NULL
+`
+
+// CountAdmins counts the admins who can log in, which those without a
+// password cannot.
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, password_hash, role)
 VALUES(
   $1,
   $2,
   $3,
-  $4
+  $4,
+  $5,
+  $6
 )
-RETURNING id, created_at, updated_at, name
+RETURNING id, created_at, updated_at, name, api_key_hash, password_hash, role, fever_key_hash
 `
 
 type CreateUserParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Name         string
+	PasswordHash sql.NullString
+	Role         string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -36,6 +54,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Name,
+		arg.PasswordHash,
+		arg.Role,
 	)
 	var i User
 	err := row.Scan(
@@ -43,6 +63,10 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.ApiKeyHash,
+		&i.PasswordHash,
+		&i.Role,
+		&i.FeverKeyHash,
 	)
 	return i, err
 }
@@ -56,8 +80,17 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
 const getAllUsers = `-- name: GetAllUsers :many
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, api_key_hash, password_hash, role, fever_key_hash FROM users
 `
 
 func (q *Queries) GetAllUsers(ctx context.Context) ([]User, error) {
@@ -74,6 +107,10 @@ func (q *Queries) GetAllUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.ApiKeyHash,
+			&i.PasswordHash,
+			&i.Role,
+			&i.FeverKeyHash,
 		); err != nil {
 			return nil, err
 		}
@@ -89,7 +126,7 @@ func (q *Queries) GetAllUsers(ctx context.Context) ([]User, error) {
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, name FROM users WHERE name = $1
+SELECT id, created_at, updated_at, name, api_key_hash, password_hash, role, fever_key_hash FROM users WHERE name = $1
 `
 
 func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
@@ -100,6 +137,129 @@ func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.ApiKeyHash,
+		&i.PasswordHash,
+		&i.Role,
+		&i.FeverKeyHash,
+	)
+	return i, err
+}
+
+const getUserByAPIKey = `-- name: GetUserByAPIKey :one
+SELECT id, created_at, updated_at, name, api_key_hash, password_hash, role, fever_key_hash FROM users WHERE api_key_hash = $1
+`
+
+func (q *Queries) GetUserByAPIKey(ctx context.Context, apiKeyHash string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByAPIKey, apiKeyHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKeyHash,
+		&i.PasswordHash,
+		&i.Role,
+		&i.FeverKeyHash,
+	)
+	return i, err
+}
+
+const getUserStats = `-- name: GetUserStats :one
+SELECT
+  (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = users.id) AS follows,
+  (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = users.id) AS feeds_owned,
+  (SELECT COUNT(*) FROM posts
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = users.id
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = users.id
+    WHERE post_reads.post_id IS NULL) AS unread,
+  (SELECT COUNT(*) FROM post_stars WHERE post_stars.user_id = users.id) AS starred
+FROM users
+WHERE users.id = $1
+`
+
+type GetUserStatsRow struct {
+	Follows    int64
+	FeedsOwned int64
+	Unread     int64
+	Starred    int64
+}
+
+func (q *Queries) GetUserStats(ctx context.Context, id uuid.UUID) (GetUserStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserStats, id)
+	var i GetUserStatsRow
+	err := row.Scan(
+		&i.Follows,
+		&i.FeedsOwned,
+		&i.Unread,
+		&i.Starred,
 	)
 	return i, err
 }
+
+const renameUser = `-- name: RenameUser :exec
+UPDATE users
+SET name = $2, fever_key_hash = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+type RenameUserParams struct {
+	ID   uuid.UUID
+	Name string
+}
+
+// The Fever key is made from the name, so renaming a user voids it.
+func (q *Queries) RenameUser(ctx context.Context, arg RenameUserParams) error {
+	_, err := q.db.ExecContext(ctx, renameUser, arg.ID, arg.Name)
+	return err
+}
+
+const setAPIKey = `-- name: SetAPIKey :exec
+UPDATE users
+SET api_key_hash = $2, fever_key_hash = $3, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetAPIKeyParams struct {
+	ID           uuid.UUID
+	ApiKeyHash   string
+	FeverKeyHash sql.NullString
+}
+
+// SetAPIKey replaces the user's API key, and the Fever key made from it.
+func (q *Queries) SetAPIKey(ctx context.Context, arg SetAPIKeyParams) error {
+	_, err := q.db.ExecContext(ctx, setAPIKey, arg.ID, arg.ApiKeyHash, arg.FeverKeyHash)
+	return err
+}
+
+const setPassword = `-- name: SetPassword :exec
+UPDATE users
+SET password_hash = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetPasswordParams struct {
+	ID           uuid.UUID
+	PasswordHash sql.NullString
+}
+
+func (q *Queries) SetPassword(ctx context.Context, arg SetPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setPassword, arg.ID, arg.PasswordHash)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :exec
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, setUserRole, arg.ID, arg.Role)
+	return err
+}
@@ -0,0 +1,160 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+func (s *Store) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, feed := s.user(arg.UserID), s.feed(arg.FeedID)
+	if user == nil || feed == nil {
+		return database.CreateFeedFollowRow{}, ErrForeignKeyViolation
+	}
+	if s.follow(arg.UserID, arg.FeedID) != nil {
+		return database.CreateFeedFollowRow{}, ErrUniqueViolation
+	}
+	for _, f := range s.follows {
+		if f.ID == arg.ID {
+			return database.CreateFeedFollowRow{}, ErrUniqueViolation
+		}
+	}
+	s.follows = append(s.follows, database.FeedFollow{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+		Folders:   []string{},
+	})
+	return database.CreateFeedFollowRow{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+		Folders:   []string{},
+		FeedName:  feed.Name,
+		UserName:  user.Name,
+	}, nil
+}
+
+func (s *Store) DeleteFeedFollow(ctx context.Context, arg database.DeleteFeedFollowParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, feed := s.userNamed(arg.Name), s.feedByURL(arg.Url)
+	if user == nil || feed == nil {
+		return nil
+	}
+	s.follows = slices.DeleteFunc(s.follows, func(f database.FeedFollow) bool {
+		return f.UserID == user.ID && f.FeedID == feed.ID
+	})
+	return nil
+}
+
+func (s *Store) GetFeedFollowsForUser(ctx context.Context, name string) ([]database.GetFeedFollowsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user := s.userNamed(name)
+	if user == nil {
+		return nil, nil
+	}
+	var rows []database.GetFeedFollowsForUserRow
+	for _, f := range s.follows {
+		if f.UserID != user.ID {
+			continue
+		}
+		feed := s.feed(f.FeedID)
+		rows = append(rows, database.GetFeedFollowsForUserRow{
+			UserName:  user.Name,
+			FeedName:  feed.Name,
+			FeedUrl:   feed.Url,
+			CreatedAt: f.CreatedAt,
+			Alias:     f.Alias,
+			Folders:   slices.Clone(f.Folders),
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetFeedFollowsForUserRow) int {
+		return strings.Compare(followName(a.Alias, a.FeedName), followName(b.Alias, b.FeedName))
+	})
+	return rows, nil
+}
+
+func (s *Store) GetFollowFolders(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var folders []string
+	for _, f := range s.follows {
+		if f.UserID == userID {
+			folders = append(folders, f.Folders...)
+		}
+	}
+	slices.Sort(folders)
+	return slices.Compact(folders), nil
+}
+
+func (s *Store) GetFollowedFeedsWithUnread(ctx context.Context, userID uuid.UUID) ([]database.GetFollowedFeedsWithUnreadRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetFollowedFeedsWithUnreadRow
+	for _, f := range s.follows {
+		if f.UserID != userID {
+			continue
+		}
+		feed := s.feed(f.FeedID)
+		row := database.GetFollowedFeedsWithUnreadRow{
+			ID:   feed.ID,
+			Name: followName(f.Alias, feed.Name),
+			Url:  feed.Url,
+		}
+		for _, p := range s.posts {
+			if _, read := s.reads[postKey{userID, p.ID}]; p.FeedID == feed.ID && !read {
+				row.Unread++
+			}
+		}
+		rows = append(rows, row)
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetFollowedFeedsWithUnreadRow) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return rows, nil
+}
+
+func (s *Store) SetFollowAlias(ctx context.Context, arg database.SetFollowAliasParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.followOfURL(arg.UserID, arg.Url)
+	if f == nil {
+		return 0, nil
+	}
+	f.Alias = arg.Alias
+	f.UpdatedAt = time.Now()
+	return 1, nil
+}
+
+func (s *Store) SetFollowFolders(ctx context.Context, arg database.SetFollowFoldersParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.followOfURL(arg.UserID, arg.Url)
+	if f == nil {
+		return 0, nil
+	}
+	f.Folders = slices.Clone(arg.Folders)
+	f.UpdatedAt = time.Now()
+	return 1, nil
+}
+
+// followName is COALESCE(feed_follows.alias, feeds.name).
+func followName(alias sql.NullString, name string) string {
+	if alias.Valid {
+		return alias.String
+	}
+	return name
+}
@@ -0,0 +1,219 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+func (s *Store) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.feed(arg.ID) != nil || s.feedByURL(arg.Url) != nil {
+		return database.Feed{}, ErrUniqueViolation
+	}
+	if s.user(arg.UserID) == nil {
+		return database.Feed{}, ErrForeignKeyViolation
+	}
+	s.feedSeq++
+	feed := database.Feed{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+		UserID:    arg.UserID,
+		Url:       arg.Url,
+		Seq:       s.feedSeq,
+	}
+	s.feeds = append(s.feeds, feed)
+	return feed, nil
+}
+
+func (s *Store) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteFeed(id)
+	return nil
+}
+
+func (s *Store) GetAllFeeds(ctx context.Context) ([]database.GetAllFeedsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetAllFeedsRow
+	for _, f := range s.feeds {
+		rows = append(rows, database.GetAllFeedsRow{
+			Name:  f.Name,
+			Url:   f.Url,
+			Owner: s.user(f.UserID).Name,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) GetFeedByUrl(ctx context.Context, url string) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f := s.feedByURL(url); f != nil {
+		return *f, nil
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (s *Store) GetFeedDetails(ctx context.Context, url string) (database.GetFeedDetailsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.feedByURL(url)
+	if f == nil {
+		return database.GetFeedDetailsRow{}, sql.ErrNoRows
+	}
+	row := database.GetFeedDetailsRow{
+		ID:             f.ID,
+		CreatedAt:      f.CreatedAt,
+		UpdatedAt:      f.UpdatedAt,
+		Name:           f.Name,
+		UserID:         f.UserID,
+		Url:            f.Url,
+		LastFetchedAt:  f.LastFetchedAt,
+		Seq:            f.Seq,
+		LastFetchError: f.LastFetchError,
+		KeepDays:       f.KeepDays,
+		KeepPosts:      f.KeepPosts,
+		Owner:          s.user(f.UserID).Name,
+	}
+	for _, follow := range s.follows {
+		if follow.FeedID == f.ID {
+			row.Followers++
+		}
+	}
+	for _, p := range s.posts {
+		if p.FeedID == f.ID {
+			row.Posts++
+		}
+	}
+	for _, queued := range s.feeds {
+		if queued.ID == f.ID {
+			continue
+		}
+		// As in the query, feeds never fetched count even when this one
+		// has not been fetched either.
+		if !queued.LastFetchedAt.Valid || fetchedBefore(queued, *f) {
+			row.QueuedAhead++
+		}
+	}
+	return row, nil
+}
+
+func (s *Store) GetFeedsOwnedBy(ctx context.Context, userID uuid.UUID) ([]database.GetFeedsOwnedByRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetFeedsOwnedByRow
+	for _, f := range s.feeds {
+		if f.UserID != userID {
+			continue
+		}
+		row := database.GetFeedsOwnedByRow{Name: f.Name, Url: f.Url}
+		for _, follow := range s.follows {
+			if follow.FeedID == f.ID {
+				row.Followers++
+			}
+		}
+		rows = append(rows, row)
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetFeedsOwnedByRow) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return rows, nil
+}
+
+func (s *Store) GetNextFeedToFetch(ctx context.Context) (database.GetNextFeedToFetchRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next *database.Feed
+	for i, f := range s.feeds {
+		if next == nil || fetchedBefore(f, *next) {
+			next = &s.feeds[i]
+		}
+	}
+	if next == nil {
+		return database.GetNextFeedToFetchRow{}, sql.ErrNoRows
+	}
+	return database.GetNextFeedToFetchRow{ID: next.ID, Url: next.Url}, nil
+}
+
+func (s *Store) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f := s.feed(arg.ID); f != nil {
+		now := time.Now()
+		f.LastFetchedAt = sql.NullTime{Time: now, Valid: true}
+		f.UpdatedAt = now
+		f.LastFetchError = arg.LastFetchError
+	}
+	return nil
+}
+
+func (s *Store) RenameFeed(ctx context.Context, arg database.RenameFeedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f := s.feed(arg.ID); f != nil {
+		f.Name = arg.Name
+		f.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *Store) SetFeedRetention(ctx context.Context, arg database.SetFeedRetentionParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f := s.feed(arg.ID); f != nil {
+		f.KeepDays = arg.KeepDays
+		f.KeepPosts = arg.KeepPosts
+		f.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *Store) SetFeedUrl(ctx context.Context, arg database.SetFeedUrlParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if other := s.feedByURL(arg.Url); other != nil && other.ID != arg.ID {
+		return ErrUniqueViolation
+	}
+	if f := s.feed(arg.ID); f != nil {
+		f.Url = arg.Url
+		f.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *Store) TransferFeeds(ctx context.Context, arg database.TransferFeedsParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for i := range s.feeds {
+		if s.feeds[i].UserID != arg.FromUserID {
+			continue
+		}
+		if s.user(arg.ToUserID) == nil {
+			return 0, ErrForeignKeyViolation
+		}
+		s.feeds[i].UserID = arg.ToUserID
+		s.feeds[i].UpdatedAt = time.Now()
+		n++
+	}
+	return n, nil
+}
+
+// fetchedBefore reports whether a comes before b in the fetch queue, where
+// feeds that have never been fetched come first.
+func fetchedBefore(a, b database.Feed) bool {
+	if !a.LastFetchedAt.Valid {
+		return b.LastFetchedAt.Valid
+	}
+	return b.LastFetchedAt.Valid && a.LastFetchedAt.Time.Before(b.LastFetchedAt.Time)
+}
@@ -0,0 +1,138 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+func (s *Store) CountFeverItems(ctx context.Context, userID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, p := range s.posts {
+		if s.follow(userID, p.FeedID) != nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) GetFeverFeeds(ctx context.Context, userID uuid.UUID) ([]database.GetFeverFeedsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetFeverFeedsRow
+	// Feeds are kept in seq order.
+	for _, f := range s.feeds {
+		if s.follow(userID, f.ID) != nil {
+			rows = append(rows, database.GetFeverFeedsRow{
+				Seq:           f.Seq,
+				Name:          f.Name,
+				Url:           f.Url,
+				UpdatedAt:     f.UpdatedAt,
+				LastFetchedAt: f.LastFetchedAt,
+			})
+		}
+	}
+	return rows, nil
+}
+
+func (s *Store) GetFeverItems(ctx context.Context, arg database.GetFeverItemsParams) ([]database.GetFeverItemsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetFeverItemsRow
+	for _, p := range s.posts {
+		if s.follow(arg.UserID, p.FeedID) == nil ||
+			arg.SinceID.Valid && p.Seq <= arg.SinceID.Int64 ||
+			arg.MaxID.Valid && p.Seq >= arg.MaxID.Int64 ||
+			arg.WithIds != nil && !slices.Contains(arg.WithIds, p.Seq) {
+			continue
+		}
+		_, read := s.reads[postKey{arg.UserID, p.ID}]
+		_, starred := s.stars[postKey{arg.UserID, p.ID}]
+		rows = append(rows, database.GetFeverItemsRow{
+			Seq:         p.Seq,
+			FeedSeq:     s.feed(p.FeedID).Seq,
+			Title:       p.Title,
+			Author:      p.Author,
+			Description: p.Description,
+			Url:         p.Url,
+			SortDate:    p.EffectiveAt,
+			Read:        read,
+			Starred:     starred,
+		})
+	}
+	// Posts are kept in seq order; paging back from max_id wants the newest.
+	if arg.MaxID.Valid {
+		slices.Reverse(rows)
+	}
+	return rows[:min(len(rows), int(max(arg.Limit, 0)))], nil
+}
+
+func (s *Store) GetPostIDBySeq(ctx context.Context, seq int64) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.posts {
+		if p.Seq == seq {
+			return p.ID, nil
+		}
+	}
+	return uuid.UUID{}, sql.ErrNoRows
+}
+
+func (s *Store) GetStarredItemSeqs(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var seqs []int64
+	for _, p := range s.posts {
+		if _, ok := s.stars[postKey{userID, p.ID}]; ok {
+			seqs = append(seqs, p.Seq)
+		}
+	}
+	return seqs, nil
+}
+
+func (s *Store) GetUnreadItemSeqs(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var seqs []int64
+	for _, p := range s.posts {
+		if _, read := s.reads[postKey{userID, p.ID}]; !read && s.follow(userID, p.FeedID) != nil {
+			seqs = append(seqs, p.Seq)
+		}
+	}
+	return seqs, nil
+}
+
+func (s *Store) GetUserByFeverKey(ctx context.Context, feverKeyHash string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.FeverKeyHash.Valid && u.FeverKeyHash.String == feverKeyHash {
+			return u, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) MarkFeedsReadBefore(ctx context.Context, arg database.MarkFeedsReadBeforeParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, p := range s.posts {
+		if s.follow(arg.UserID, p.FeedID) == nil ||
+			arg.FeedSeq.Valid && s.feed(p.FeedID).Seq != arg.FeedSeq.Int64 ||
+			p.CreatedAt.After(arg.Before) {
+			continue
+		}
+		key := postKey{arg.UserID, p.ID}
+		if _, ok := s.reads[key]; !ok {
+			s.reads[key] = now
+		}
+	}
+	return nil
+}
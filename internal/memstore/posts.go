@@ -0,0 +1,308 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+func (s *Store) CountPrunablePosts(ctx context.Context, arg database.CountPrunablePostsParams) ([]database.CountPrunablePostsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prunable := s.prunable(arg.Now, arg.KeepDays, arg.KeepPosts)
+	var rows []database.CountPrunablePostsRow
+	for _, f := range s.feeds {
+		row := database.CountPrunablePostsRow{Name: f.Name, Url: f.Url}
+		for _, p := range s.posts {
+			if p.FeedID == f.ID && prunable[p.ID] {
+				row.Posts++
+			}
+		}
+		if row.Posts > 0 {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b database.CountPrunablePostsRow) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return rows, nil
+}
+
+func (s *Store) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	post, err := s.insertPost(database.Post{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Title:       arg.Title,
+		Url:         arg.Url,
+		Description: arg.Description,
+		PublishedAt: arg.PublishedAt,
+		FeedID:      arg.FeedID,
+		Author:      arg.Author,
+		Categories:  slices.Clone(arg.Categories),
+		EffectiveAt: arg.EffectiveAt,
+		DateSource:  arg.DateSource,
+	})
+	post.Categories = slices.Clone(post.Categories)
+	return post, err
+}
+
+func (s *Store) CreatePosts(ctx context.Context, arg database.CreatePostsParams) (int64, error) {
+	var items []database.NewPost
+	if err := json.Unmarshal(arg.Items, &items); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.feed(arg.FeedID) == nil && len(items) > 0 {
+		return 0, ErrForeignKeyViolation
+	}
+	var n int64
+	for _, item := range items {
+		post := database.Post{
+			ID:          item.ID,
+			CreatedAt:   arg.CreatedAt,
+			UpdatedAt:   arg.CreatedAt,
+			Title:       item.Title,
+			Url:         item.Url,
+			FeedID:      arg.FeedID,
+			Categories:  slices.Clone(item.Categories),
+			EffectiveAt: item.EffectiveAt,
+			DateSource:  item.DateSource,
+		}
+		if item.Description != nil {
+			post.Description = sql.NullString{String: *item.Description, Valid: true}
+		}
+		if item.PublishedAt != nil {
+			post.PublishedAt = sql.NullTime{Time: *item.PublishedAt, Valid: true}
+		}
+		if item.Author != nil {
+			post.Author = sql.NullString{String: *item.Author, Valid: true}
+		}
+		if post.Categories == nil {
+			post.Categories = []string{}
+		}
+		// ON CONFLICT DO NOTHING
+		if _, err := s.insertPost(post); err == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// insertPost stores post with the next seq, unless its ID or URL is taken.
+func (s *Store) insertPost(post database.Post) (database.Post, error) {
+	if s.post(post.ID) != nil {
+		return database.Post{}, ErrUniqueViolation
+	}
+	for _, p := range s.posts {
+		if p.Url == post.Url {
+			return database.Post{}, ErrUniqueViolation
+		}
+	}
+	if s.feed(post.FeedID) == nil {
+		return database.Post{}, ErrForeignKeyViolation
+	}
+	s.postSeq++
+	post.Seq = s.postSeq
+	s.posts = append(s.posts, post)
+	return post, nil
+}
+
+func (s *Store) FollowsPost(ctx context.Context, arg database.FollowsPostParams) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	post := s.post(arg.PostID)
+	return post != nil && s.follow(arg.UserID, post.FeedID) != nil, nil
+}
+
+func (s *Store) GetNewestPostsForUser(ctx context.Context, arg database.GetNewestPostsForUserParams) ([]database.GetNewestPostsForUserRow, error) {
+	return s.timeline(arg, false), nil
+}
+
+func (s *Store) GetOldestPostsForUser(ctx context.Context, arg database.GetOldestPostsForUserParams) ([]database.GetOldestPostsForUserRow, error) {
+	var rows []database.GetOldestPostsForUserRow
+	for _, row := range s.timeline(database.GetNewestPostsForUserParams(arg), true) {
+		rows = append(rows, database.GetOldestPostsForUserRow(row))
+	}
+	return rows, nil
+}
+
+// timeline runs GetNewestPostsForUser, or GetOldestPostsForUser if
+// oldestFirst is set.
+func (s *Store) timeline(arg database.GetNewestPostsForUserParams, oldestFirst bool) []database.GetPostsForUserRow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// compare orders posts newest first, or oldest first if asked.
+	compare := func(a, b database.GetPostsForUserRow) int {
+		c := a.SortDate.Compare(b.SortDate)
+		if c == 0 {
+			c = compareUUID(a.ID, b.ID)
+		}
+		if oldestFirst {
+			return c
+		}
+		return -c
+	}
+	var after *database.GetPostsForUserRow
+	if arg.AfterDate.Valid {
+		after = &database.GetPostsForUserRow{SortDate: arg.AfterDate.Time, ID: arg.AfterID.UUID}
+	}
+	var rows []database.GetPostsForUserRow
+	for _, p := range s.posts {
+		follow := s.follow(arg.UserID, p.FeedID)
+		if follow == nil {
+			continue
+		}
+		feed := s.feed(p.FeedID)
+		_, read := s.reads[postKey{arg.UserID, p.ID}]
+		_, starred := s.stars[postKey{arg.UserID, p.ID}]
+		row := database.GetPostsForUserRow{
+			ID:          p.ID,
+			Title:       p.Title,
+			Description: p.Description,
+			Url:         p.Url,
+			Author:      p.Author,
+			Categories:  slices.Clone(p.Categories),
+			SortDate:    p.EffectiveAt,
+			DateSource:  p.DateSource,
+			FeedName:    followName(follow.Alias, feed.Name),
+			FeedID:      feed.ID,
+			Read:        read,
+			Starred:     starred,
+		}
+		switch {
+		case arg.FeedUrl.Valid && feed.Url != arg.FeedUrl.String,
+			arg.Since.Valid && row.SortDate.Before(arg.Since.Time),
+			arg.Until.Valid && !row.SortDate.Before(arg.Until.Time),
+			arg.Author.Valid && !(p.Author.Valid && containsFold(p.Author.String, arg.Author.String)),
+			arg.Tag.Valid && !slices.Contains(p.Categories, arg.Tag.String),
+			arg.Search.Valid && !containsFold(p.Title, arg.Search.String) &&
+				!(p.Description.Valid && containsFold(p.Description.String, arg.Search.String)),
+			arg.Folder.Valid && !slices.Contains(follow.Folders, arg.Folder.String),
+			arg.UnreadOnly && read,
+			arg.StarredOnly && !starred:
+			continue
+		}
+		if after != nil {
+			// Without an ID, posts at the cursor's date are left out, as the
+			// row comparison in the query is then NULL.
+			c := compare(row, *after)
+			if c <= 0 || !arg.AfterID.Valid && row.SortDate.Equal(after.SortDate) {
+				continue
+			}
+		}
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, compare)
+	return rows[:min(len(rows), int(max(arg.Limit, 0)))]
+}
+
+func (s *Store) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user(arg.UserID) == nil || s.post(arg.PostID) == nil {
+		return ErrForeignKeyViolation
+	}
+	key := postKey{arg.UserID, arg.PostID}
+	if _, ok := s.reads[key]; !ok {
+		s.reads[key] = arg.ReadAt
+	}
+	return nil
+}
+
+func (s *Store) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.reads, postKey{arg.UserID, arg.PostID})
+	return nil
+}
+
+func (s *Store) PrunePosts(ctx context.Context, arg database.PrunePostsParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prunable := s.prunable(arg.Now, arg.KeepDays, arg.KeepPosts)
+	s.posts = slices.DeleteFunc(s.posts, func(p database.Post) bool { return prunable[p.ID] })
+	for key := range s.reads {
+		if prunable[key.postID] {
+			delete(s.reads, key)
+		}
+	}
+	return int64(len(prunable)), nil
+}
+
+// prunable returns the IDs of the posts that CountPrunablePosts counts and
+// PrunePosts deletes.
+func (s *Store) prunable(now time.Time, keepDays, keepPosts int32) map[uuid.UUID]bool {
+	starred := make(map[uuid.UUID]bool)
+	for key := range s.stars {
+		starred[key.postID] = true
+	}
+	ids := make(map[uuid.UUID]bool)
+	for _, f := range s.feeds {
+		days, posts := keepDays, keepPosts
+		if f.KeepDays.Valid {
+			days = f.KeepDays.Int32
+		}
+		if f.KeepPosts.Valid {
+			posts = f.KeepPosts.Int32
+		}
+		var ranked []database.Post
+		for _, p := range s.posts {
+			if p.FeedID == f.ID {
+				ranked = append(ranked, p)
+			}
+		}
+		// Newest first, as ROW_NUMBER() numbers them.
+		slices.SortFunc(ranked, func(a, b database.Post) int {
+			if c := b.EffectiveAt.Compare(a.EffectiveAt); c != 0 {
+				return c
+			}
+			return cmp.Compare(b.Seq, a.Seq)
+		})
+		cutoff := now.AddDate(0, 0, -int(days))
+		for i, p := range ranked {
+			if starred[p.ID] {
+				continue
+			}
+			if days != 0 && p.CreatedAt.Before(cutoff) || posts != 0 && i >= int(posts) {
+				ids[p.ID] = true
+			}
+		}
+	}
+	return ids
+}
+
+func (s *Store) StarPost(ctx context.Context, arg database.StarPostParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user(arg.UserID) == nil || s.post(arg.PostID) == nil {
+		return ErrForeignKeyViolation
+	}
+	key := postKey{arg.UserID, arg.PostID}
+	if _, ok := s.stars[key]; !ok {
+		s.stars[key] = arg.StarredAt
+	}
+	return nil
+}
+
+func (s *Store) UnstarPost(ctx context.Context, arg database.UnstarPostParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.stars, postKey{arg.UserID, arg.PostID})
+	return nil
+}
+
+// containsFold is ILIKE '%' || substr || '%'.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
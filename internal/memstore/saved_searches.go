@@ -0,0 +1,72 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+func (s *Store) CreateSavedSearch(ctx context.Context, arg database.CreateSavedSearchParams) (database.SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ss := range s.searches {
+		if ss.ID == arg.ID || ss.TokenHash == arg.TokenHash || ss.UserID == arg.UserID && ss.Name == arg.Name {
+			return database.SavedSearch{}, ErrUniqueViolation
+		}
+	}
+	if s.user(arg.UserID) == nil {
+		return database.SavedSearch{}, ErrForeignKeyViolation
+	}
+	ss := database.SavedSearch(arg)
+	s.searches = append(s.searches, ss)
+	return ss, nil
+}
+
+func (s *Store) DeleteSavedSearch(ctx context.Context, arg database.DeleteSavedSearchParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.searches)
+	s.searches = slices.DeleteFunc(s.searches, func(ss database.SavedSearch) bool {
+		return ss.UserID == arg.UserID && ss.Name == arg.Name
+	})
+	return int64(n - len(s.searches)), nil
+}
+
+func (s *Store) GetSavedSearch(ctx context.Context, arg database.GetSavedSearchParams) (database.SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ss := range s.searches {
+		if ss.UserID == arg.UserID && ss.Name == arg.Name {
+			return ss, nil
+		}
+	}
+	return database.SavedSearch{}, sql.ErrNoRows
+}
+
+func (s *Store) GetSavedSearchByToken(ctx context.Context, tokenHash string) (database.GetSavedSearchByTokenRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ss := range s.searches {
+		if ss.TokenHash == tokenHash {
+			return database.GetSavedSearchByTokenRow{SavedSearch: ss, User: *s.user(ss.UserID)}, nil
+		}
+	}
+	return database.GetSavedSearchByTokenRow{}, sql.ErrNoRows
+}
+
+func (s *Store) GetSavedSearches(ctx context.Context, userID uuid.UUID) ([]database.SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var searches []database.SavedSearch
+	for _, ss := range s.searches {
+		if ss.UserID == userID {
+			searches = append(searches, ss)
+		}
+	}
+	slices.SortFunc(searches, func(a, b database.SavedSearch) int { return strings.Compare(a.Name, b.Name) })
+	return searches, nil
+}
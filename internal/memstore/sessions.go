@@ -0,0 +1,62 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/interyx/gator/internal/database"
+)
+
+func (s *Store) CreateSession(ctx context.Context, arg database.CreateSessionParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[arg.TokenHash]; ok {
+		return ErrUniqueViolation
+	}
+	if s.user(arg.UserID) == nil {
+		return ErrForeignKeyViolation
+	}
+	s.sessions[arg.TokenHash] = database.Session(arg)
+	return nil
+}
+
+func (s *Store) DeleteExpiredSessions(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for hash, session := range s.sessions {
+		if !session.ExpiresAt.After(now) {
+			delete(s.sessions, hash)
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteOtherSessions(ctx context.Context, arg database.DeleteOtherSessionsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, session := range s.sessions {
+		if session.UserID == arg.UserID && hash != arg.TokenHash {
+			delete(s.sessions, hash)
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteSession(ctx context.Context, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, tokenHash)
+	return nil
+}
+
+func (s *Store) GetUserBySession(ctx context.Context, tokenHash string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[tokenHash]
+	if !ok || !session.ExpiresAt.After(time.Now()) {
+		return database.User{}, sql.ErrNoRows
+	}
+	return *s.user(session.UserID), nil
+}
@@ -0,0 +1,181 @@
+// Package memstore keeps gator's data in memory, so that code written
+// against database.Querier can be exercised without a database server.
+// It follows the rules of the schema in sql/schema: names and URLs are
+// unique, rows must refer to rows that exist, and deletes cascade.
+package memstore
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+// ErrUniqueViolation and ErrForeignKeyViolation are returned where the
+// database would reject a statement for breaking the matching constraint.
+var (
+	ErrUniqueViolation     = errors.New("memstore: duplicate key value violates unique constraint")
+	ErrForeignKeyViolation = errors.New("memstore: insert or update violates foreign key constraint")
+	ErrCheckViolation      = errors.New("memstore: new row violates check constraint")
+)
+
+type postKey struct {
+	userID uuid.UUID
+	postID uuid.UUID
+}
+
+// Store implements database.Querier.  The zero value is not usable; call New.
+type Store struct {
+	mu       sync.Mutex
+	users    []database.User
+	feeds    []database.Feed
+	follows  []database.FeedFollow
+	posts    []database.Post
+	reads    map[postKey]time.Time
+	stars    map[postKey]time.Time
+	sessions map[string]database.Session
+	searches []database.SavedSearch
+	feedSeq  int64
+	postSeq  int64
+}
+
+var _ database.Querier = (*Store)(nil)
+
+func New() *Store {
+	return &Store{
+		reads:    map[postKey]time.Time{},
+		stars:    map[postKey]time.Time{},
+		sessions: map[string]database.Session{},
+	}
+}
+
+func (s *Store) user(id uuid.UUID) *database.User {
+	for i := range s.users {
+		if s.users[i].ID == id {
+			return &s.users[i]
+		}
+	}
+	return nil
+}
+
+func (s *Store) userNamed(name string) *database.User {
+	for i := range s.users {
+		if s.users[i].Name == name {
+			return &s.users[i]
+		}
+	}
+	return nil
+}
+
+func (s *Store) feed(id uuid.UUID) *database.Feed {
+	for i := range s.feeds {
+		if s.feeds[i].ID == id {
+			return &s.feeds[i]
+		}
+	}
+	return nil
+}
+
+func (s *Store) feedByURL(url string) *database.Feed {
+	for i := range s.feeds {
+		if s.feeds[i].Url == url {
+			return &s.feeds[i]
+		}
+	}
+	return nil
+}
+
+func (s *Store) post(id uuid.UUID) *database.Post {
+	for i := range s.posts {
+		if s.posts[i].ID == id {
+			return &s.posts[i]
+		}
+	}
+	return nil
+}
+
+// follow returns userID's follow of feedID, if any.
+func (s *Store) follow(userID, feedID uuid.UUID) *database.FeedFollow {
+	for i := range s.follows {
+		if s.follows[i].UserID == userID && s.follows[i].FeedID == feedID {
+			return &s.follows[i]
+		}
+	}
+	return nil
+}
+
+// followOfURL returns userID's follow of the feed at url, if any.
+func (s *Store) followOfURL(userID uuid.UUID, url string) *database.FeedFollow {
+	feed := s.feedByURL(url)
+	if feed == nil {
+		return nil
+	}
+	return s.follow(userID, feed.ID)
+}
+
+// deleteUser removes a user and, as ON DELETE CASCADE would, everything
+// that refers to them.
+func (s *Store) deleteUser(id uuid.UUID) {
+	s.users = slices.DeleteFunc(s.users, func(u database.User) bool { return u.ID == id })
+	for _, feed := range slices.Clone(s.feeds) {
+		if feed.UserID == id {
+			s.deleteFeed(feed.ID)
+		}
+	}
+	s.follows = slices.DeleteFunc(s.follows, func(f database.FeedFollow) bool { return f.UserID == id })
+	for key := range s.reads {
+		if key.userID == id {
+			delete(s.reads, key)
+		}
+	}
+	for key := range s.stars {
+		if key.userID == id {
+			delete(s.stars, key)
+		}
+	}
+	for hash, session := range s.sessions {
+		if session.UserID == id {
+			delete(s.sessions, hash)
+		}
+	}
+	s.searches = slices.DeleteFunc(s.searches, func(ss database.SavedSearch) bool { return ss.UserID == id })
+}
+
+// deleteFeed removes a feed along with its follows and posts.
+func (s *Store) deleteFeed(id uuid.UUID) {
+	s.feeds = slices.DeleteFunc(s.feeds, func(f database.Feed) bool { return f.ID == id })
+	s.follows = slices.DeleteFunc(s.follows, func(f database.FeedFollow) bool { return f.FeedID == id })
+	s.posts = slices.DeleteFunc(s.posts, func(p database.Post) bool {
+		if p.FeedID != id {
+			return false
+		}
+		for key := range s.reads {
+			if key.postID == p.ID {
+				delete(s.reads, key)
+			}
+		}
+		for key := range s.stars {
+			if key.postID == p.ID {
+				delete(s.stars, key)
+			}
+		}
+		return true
+	})
+}
+
+// compareUUID orders UUIDs as Postgres does, byte by byte.
+func compareUUID(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+func randomKey() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
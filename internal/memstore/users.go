@@ -0,0 +1,177 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+func (s *Store) CountAdmins(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, u := range s.users {
+		if u.Role == "admin" && u.PasswordHash.Valid {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user(arg.ID) != nil || s.userNamed(arg.Name) != nil {
+		return database.User{}, ErrUniqueViolation
+	}
+	if arg.Role != "admin" && arg.Role != "member" {
+		return database.User{}, ErrCheckViolation
+	}
+	u := database.User{
+		ID:           arg.ID,
+		CreatedAt:    arg.CreatedAt,
+		UpdatedAt:    arg.UpdatedAt,
+		Name:         arg.Name,
+		ApiKeyHash:   randomKey(),
+		PasswordHash: arg.PasswordHash,
+		Role:         arg.Role,
+	}
+	s.users = append(s.users, u)
+	return u, nil
+}
+
+func (s *Store) DeleteAllUsers(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Every other row belongs to a user, so nothing is left.
+	s.users = nil
+	s.feeds = nil
+	s.follows = nil
+	s.posts = nil
+	clear(s.reads)
+	clear(s.stars)
+	clear(s.sessions)
+	s.searches = nil
+	return nil
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteUser(id)
+	return nil
+}
+
+func (s *Store) GetAllUsers(ctx context.Context) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.users), nil
+}
+
+func (s *Store) GetUser(ctx context.Context, name string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u := s.userNamed(name); u != nil {
+		return *u, nil
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUserByAPIKey(ctx context.Context, apiKeyHash string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.ApiKeyHash == apiKeyHash {
+			return u, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUserStats(ctx context.Context, id uuid.UUID) (database.GetUserStatsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user(id) == nil {
+		return database.GetUserStatsRow{}, sql.ErrNoRows
+	}
+	var stats database.GetUserStatsRow
+	for _, f := range s.follows {
+		if f.UserID == id {
+			stats.Follows++
+		}
+	}
+	for _, f := range s.feeds {
+		if f.UserID == id {
+			stats.FeedsOwned++
+		}
+	}
+	for _, p := range s.posts {
+		if _, read := s.reads[postKey{id, p.ID}]; !read && s.follow(id, p.FeedID) != nil {
+			stats.Unread++
+		}
+	}
+	for key := range s.stars {
+		if key.userID == id {
+			stats.Starred++
+		}
+	}
+	return stats, nil
+}
+
+func (s *Store) RenameUser(ctx context.Context, arg database.RenameUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if other := s.userNamed(arg.Name); other != nil && other.ID != arg.ID {
+		return ErrUniqueViolation
+	}
+	if u := s.user(arg.ID); u != nil {
+		u.Name = arg.Name
+		u.FeverKeyHash = sql.NullString{}
+		u.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *Store) SetAPIKey(ctx context.Context, arg database.SetAPIKeyParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.ID != arg.ID && (u.ApiKeyHash == arg.ApiKeyHash ||
+			arg.FeverKeyHash.Valid && u.FeverKeyHash == arg.FeverKeyHash) {
+			return ErrUniqueViolation
+		}
+	}
+	if u := s.user(arg.ID); u != nil {
+		u.ApiKeyHash = arg.ApiKeyHash
+		u.FeverKeyHash = arg.FeverKeyHash
+		u.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *Store) SetPassword(ctx context.Context, arg database.SetPasswordParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u := s.user(arg.ID); u != nil {
+		u.PasswordHash = arg.PasswordHash
+		u.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *Store) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if arg.Role != "admin" && arg.Role != "member" {
+		return ErrCheckViolation
+	}
+	if u := s.user(arg.ID); u != nil {
+		u.Role = arg.Role
+		u.UpdatedAt = time.Now()
+	}
+	return nil
+}
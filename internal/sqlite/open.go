@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	sqlite3 "modernc.org/sqlite"
+)
+
+// Result codes for the constraint errors callers need to tell apart.
+const (
+	ErrConstraintForeignKey = 787
+	ErrConstraintPrimaryKey = 1555
+	ErrConstraintUnique     = 2067
+)
+
+func init() {
+	// The migration that hashes API keys needs md5 and sha256, as Postgres
+	// has them.  Both return hex.
+	sqlite3.MustRegisterDeterministicScalarFunction("md5", 1, hashFunc(func(data []byte) []byte {
+		sum := md5.Sum(data)
+		return sum[:]
+	}))
+	sqlite3.MustRegisterDeterministicScalarFunction("sha256", 1, hashFunc(func(data []byte) []byte {
+		sum := sha256.Sum256(data)
+		return sum[:]
+	}))
+}
+
+// hashFunc makes a SQL function that returns the hex of sum over its
+// argument, or NULL for NULL.
+func hashFunc(sum func([]byte) []byte) func(*sqlite3.FunctionContext, []driver.Value) (driver.Value, error) {
+	return func(ctx *sqlite3.FunctionContext, args []driver.Value) (driver.Value, error) {
+		var data []byte
+		switch v := args[0].(type) {
+		case string:
+			data = []byte(v)
+		case []byte:
+			data = v
+		case nil:
+			return nil, nil
+		default:
+			data = []byte(fmt.Sprint(v))
+		}
+		return hex.EncodeToString(sum(data)), nil
+	}
+}
+
+// Open opens the SQLite database at path, creating it if need be.  Foreign
+// keys are enforced, and timestamps are written in SQLite's own format so
+// that its date functions can read them.
+func Open(path string) (*sql.DB, error) {
+	return sql.Open("sqlite", "file:"+path+"?_time_format=sqlite&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+}
+
+// ErrorCode returns the extended result code of a SQLite error, or 0 if err
+// did not come from SQLite.
+func ErrorCode(err error) int {
+	var e *sqlite3.Error
+	if errors.As(err, &e) {
+		return e.Code()
+	}
+	return 0
+}
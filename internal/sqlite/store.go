@@ -0,0 +1,489 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+// Store implements database.Querier on SQLite, so the rest of gator can use
+// either database.  The queries in sql/sqlite/queries return the same rows as
+// their Postgres counterparts, except that text arrays are stored as JSON
+// and timestamps that Postgres coalesces come back separately.
+type Store struct {
+	q *Queries
+}
+
+var _ database.Querier = (*Store)(nil)
+
+func NewStore(db DBTX) *Store {
+	return &Store{q: New(db)}
+}
+
+// WithTx returns a Store that runs its queries in tx.
+func (s *Store) WithTx(tx *sql.Tx) *Store {
+	return &Store{q: s.q.WithTx(tx)}
+}
+
+func (s *Store) CountAdmins(ctx context.Context) (int64, error) {
+	return s.q.CountAdmins(ctx)
+}
+
+func (s *Store) CountFeverItems(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.q.CountFeverItems(ctx, userID)
+}
+
+func (s *Store) CountPrunablePosts(ctx context.Context, arg database.CountPrunablePostsParams) ([]database.CountPrunablePostsRow, error) {
+	rows, err := s.q.CountPrunablePosts(ctx, CountPrunablePostsParams{
+		Now:       arg.Now,
+		KeepDays:  int64(arg.KeepDays),
+		KeepPosts: int64(arg.KeepPosts),
+	})
+	return convertRows(rows, err, func(row CountPrunablePostsRow) database.CountPrunablePostsRow {
+		return database.CountPrunablePostsRow(row)
+	})
+}
+
+func (s *Store) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	row, err := s.q.CreateFeed(ctx, CreateFeedParams(arg))
+	return convertFeed(row), err
+}
+
+func (s *Store) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	row, err := s.q.CreateFeedFollow(ctx, CreateFeedFollowParams(arg))
+	if err != nil {
+		return database.CreateFeedFollowRow{}, err
+	}
+	return database.CreateFeedFollowRow{
+		ID:        row.ID,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+		UserID:    row.UserID,
+		FeedID:    row.FeedID,
+		Alias:     row.Alias,
+		Folders:   decodeList(row.Folders),
+		FeedName:  row.FeedName,
+		UserName:  row.UserName,
+	}, nil
+}
+
+func (s *Store) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	post, err := s.q.CreatePost(ctx, CreatePostParams{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Title:       arg.Title,
+		Url:         arg.Url,
+		Description: arg.Description,
+		PublishedAt: arg.PublishedAt,
+		FeedID:      arg.FeedID,
+		Author:      arg.Author,
+		Categories:  encodeList(arg.Categories),
+		EffectiveAt: arg.EffectiveAt,
+		DateSource:  arg.DateSource,
+	})
+	if err != nil {
+		return database.Post{}, err
+	}
+	return database.Post{
+		ID:          post.ID,
+		CreatedAt:   post.CreatedAt,
+		UpdatedAt:   post.UpdatedAt,
+		Title:       post.Title,
+		Url:         post.Url,
+		Description: post.Description,
+		PublishedAt: post.PublishedAt,
+		FeedID:      post.FeedID,
+		Author:      post.Author,
+		Categories:  decodeList(post.Categories),
+		Seq:         post.Seq,
+		EffectiveAt: post.EffectiveAt,
+		DateSource:  post.DateSource,
+	}, nil
+}
+
+func (s *Store) CreatePosts(ctx context.Context, arg database.CreatePostsParams) (int64, error) {
+	return s.q.CreatePosts(ctx, CreatePostsParams{
+		CreatedAt: arg.CreatedAt,
+		FeedID:    arg.FeedID,
+		Items:     string(arg.Items),
+	})
+}
+
+func (s *Store) CreateSavedSearch(ctx context.Context, arg database.CreateSavedSearchParams) (database.SavedSearch, error) {
+	row, err := s.q.CreateSavedSearch(ctx, CreateSavedSearchParams(arg))
+	return database.SavedSearch(row), err
+}
+
+func (s *Store) CreateSession(ctx context.Context, arg database.CreateSessionParams) error {
+	return s.q.CreateSession(ctx, CreateSessionParams(arg))
+}
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	row, err := s.q.CreateUser(ctx, CreateUserParams(arg))
+	return database.User(row), err
+}
+
+func (s *Store) DeleteAllUsers(ctx context.Context) error {
+	return s.q.DeleteAllUsers(ctx)
+}
+
+func (s *Store) DeleteExpiredSessions(ctx context.Context) error {
+	return s.q.DeleteExpiredSessions(ctx)
+}
+
+func (s *Store) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	return s.q.DeleteFeed(ctx, id)
+}
+
+func (s *Store) DeleteFeedFollow(ctx context.Context, arg database.DeleteFeedFollowParams) error {
+	return s.q.DeleteFeedFollow(ctx, DeleteFeedFollowParams(arg))
+}
+
+func (s *Store) DeleteOtherSessions(ctx context.Context, arg database.DeleteOtherSessionsParams) error {
+	return s.q.DeleteOtherSessions(ctx, DeleteOtherSessionsParams(arg))
+}
+
+func (s *Store) DeleteSavedSearch(ctx context.Context, arg database.DeleteSavedSearchParams) (int64, error) {
+	return s.q.DeleteSavedSearch(ctx, DeleteSavedSearchParams(arg))
+}
+
+func (s *Store) DeleteSession(ctx context.Context, tokenHash string) error {
+	return s.q.DeleteSession(ctx, tokenHash)
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return s.q.DeleteUser(ctx, id)
+}
+
+func (s *Store) FollowsPost(ctx context.Context, arg database.FollowsPostParams) (bool, error) {
+	exists, err := s.q.FollowsPost(ctx, FollowsPostParams(arg))
+	return exists != 0, err
+}
+
+func (s *Store) GetAllFeeds(ctx context.Context) ([]database.GetAllFeedsRow, error) {
+	rows, err := s.q.GetAllFeeds(ctx)
+	return convertRows(rows, err, func(row GetAllFeedsRow) database.GetAllFeedsRow {
+		return database.GetAllFeedsRow(row)
+	})
+}
+
+func (s *Store) GetAllUsers(ctx context.Context) ([]database.User, error) {
+	rows, err := s.q.GetAllUsers(ctx)
+	return convertRows(rows, err, func(row User) database.User {
+		return database.User(row)
+	})
+}
+
+func (s *Store) GetFeedByUrl(ctx context.Context, url string) (database.Feed, error) {
+	row, err := s.q.GetFeedByUrl(ctx, url)
+	return convertFeed(row), err
+}
+
+func (s *Store) GetFeedDetails(ctx context.Context, url string) (database.GetFeedDetailsRow, error) {
+	row, err := s.q.GetFeedDetails(ctx, url)
+	return database.GetFeedDetailsRow{
+		ID:             row.ID,
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt,
+		Name:           row.Name,
+		UserID:         row.UserID,
+		Url:            row.Url,
+		LastFetchedAt:  row.LastFetchedAt,
+		Seq:            row.Seq,
+		LastFetchError: row.LastFetchError,
+		KeepDays:       narrow(row.KeepDays),
+		KeepPosts:      narrow(row.KeepPosts),
+		Owner:          row.Owner,
+		Followers:      row.Followers,
+		Posts:          row.Posts,
+		QueuedAhead:    row.QueuedAhead,
+	}, err
+}
+
+func (s *Store) GetFeedFollowsForUser(ctx context.Context, name string) ([]database.GetFeedFollowsForUserRow, error) {
+	rows, err := s.q.GetFeedFollowsForUser(ctx, name)
+	return convertRows(rows, err, func(row GetFeedFollowsForUserRow) database.GetFeedFollowsForUserRow {
+		return database.GetFeedFollowsForUserRow{
+			UserName:  row.UserName,
+			FeedName:  row.FeedName,
+			FeedUrl:   row.FeedUrl,
+			CreatedAt: row.CreatedAt,
+			Alias:     row.Alias,
+			Folders:   decodeList(row.Folders),
+		}
+	})
+}
+
+func (s *Store) GetFeedsOwnedBy(ctx context.Context, userID uuid.UUID) ([]database.GetFeedsOwnedByRow, error) {
+	rows, err := s.q.GetFeedsOwnedBy(ctx, userID)
+	return convertRows(rows, err, func(row GetFeedsOwnedByRow) database.GetFeedsOwnedByRow {
+		return database.GetFeedsOwnedByRow(row)
+	})
+}
+
+func (s *Store) GetFeverFeeds(ctx context.Context, userID uuid.UUID) ([]database.GetFeverFeedsRow, error) {
+	rows, err := s.q.GetFeverFeeds(ctx, userID)
+	return convertRows(rows, err, func(row GetFeverFeedsRow) database.GetFeverFeedsRow {
+		return database.GetFeverFeedsRow(row)
+	})
+}
+
+func (s *Store) GetFeverItems(ctx context.Context, arg database.GetFeverItemsParams) ([]database.GetFeverItemsRow, error) {
+	params := GetFeverItemsParams{
+		UserID:  arg.UserID,
+		SinceID: arg.SinceID,
+		MaxID:   arg.MaxID,
+		Limit:   arg.Limit,
+	}
+	// As with pq.Array, a nil slice is NULL and matches every item.
+	if arg.WithIds != nil {
+		ids, err := json.Marshal(arg.WithIds)
+		if err != nil {
+			return nil, err
+		}
+		params.WithIds = sql.NullString{String: string(ids), Valid: true}
+	}
+	rows, err := s.q.GetFeverItems(ctx, params)
+	return convertRows(rows, err, func(row GetFeverItemsRow) database.GetFeverItemsRow {
+		return database.GetFeverItemsRow(row)
+	})
+}
+
+func (s *Store) GetFollowFolders(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return s.q.GetFollowFolders(ctx, userID)
+}
+
+func (s *Store) GetFollowedFeedsWithUnread(ctx context.Context, userID uuid.UUID) ([]database.GetFollowedFeedsWithUnreadRow, error) {
+	rows, err := s.q.GetFollowedFeedsWithUnread(ctx, userID)
+	return convertRows(rows, err, func(row GetFollowedFeedsWithUnreadRow) database.GetFollowedFeedsWithUnreadRow {
+		return database.GetFollowedFeedsWithUnreadRow(row)
+	})
+}
+
+func (s *Store) GetNewestPostsForUser(ctx context.Context, arg database.GetNewestPostsForUserParams) ([]database.GetNewestPostsForUserRow, error) {
+	rows, err := s.q.GetNewestPostsForUser(ctx, GetNewestPostsForUserParams(arg))
+	return convertRows(rows, err, convertTimelineRow)
+}
+
+func (s *Store) GetNextFeedToFetch(ctx context.Context) (database.GetNextFeedToFetchRow, error) {
+	row, err := s.q.GetNextFeedToFetch(ctx)
+	return database.GetNextFeedToFetchRow(row), err
+}
+
+func (s *Store) GetOldestPostsForUser(ctx context.Context, arg database.GetOldestPostsForUserParams) ([]database.GetOldestPostsForUserRow, error) {
+	rows, err := s.q.GetOldestPostsForUser(ctx, GetOldestPostsForUserParams(arg))
+	return convertRows(rows, err, func(row GetOldestPostsForUserRow) database.GetOldestPostsForUserRow {
+		return database.GetOldestPostsForUserRow(convertTimelineRow(GetNewestPostsForUserRow(row)))
+	})
+}
+
+func (s *Store) GetPostIDBySeq(ctx context.Context, seq int64) (uuid.UUID, error) {
+	return s.q.GetPostIDBySeq(ctx, seq)
+}
+
+func (s *Store) GetSavedSearch(ctx context.Context, arg database.GetSavedSearchParams) (database.SavedSearch, error) {
+	row, err := s.q.GetSavedSearch(ctx, GetSavedSearchParams(arg))
+	return database.SavedSearch(row), err
+}
+
+func (s *Store) GetSavedSearchByToken(ctx context.Context, tokenHash string) (database.GetSavedSearchByTokenRow, error) {
+	row, err := s.q.GetSavedSearchByToken(ctx, tokenHash)
+	return database.GetSavedSearchByTokenRow{
+		SavedSearch: database.SavedSearch(row.SavedSearch),
+		User:        database.User(row.User),
+	}, err
+}
+
+func (s *Store) GetSavedSearches(ctx context.Context, userID uuid.UUID) ([]database.SavedSearch, error) {
+	rows, err := s.q.GetSavedSearches(ctx, userID)
+	return convertRows(rows, err, func(row SavedSearch) database.SavedSearch {
+		return database.SavedSearch(row)
+	})
+}
+
+func (s *Store) GetStarredItemSeqs(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	return s.q.GetStarredItemSeqs(ctx, userID)
+}
+
+func (s *Store) GetUnreadItemSeqs(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	return s.q.GetUnreadItemSeqs(ctx, userID)
+}
+
+func (s *Store) GetUser(ctx context.Context, name string) (database.User, error) {
+	row, err := s.q.GetUser(ctx, name)
+	return database.User(row), err
+}
+
+func (s *Store) GetUserByAPIKey(ctx context.Context, apiKeyHash string) (database.User, error) {
+	row, err := s.q.GetUserByAPIKey(ctx, apiKeyHash)
+	return database.User(row), err
+}
+
+func (s *Store) GetUserByFeverKey(ctx context.Context, feverKeyHash string) (database.User, error) {
+	row, err := s.q.GetUserByFeverKey(ctx, feverKeyHash)
+	return database.User(row), err
+}
+
+func (s *Store) GetUserBySession(ctx context.Context, tokenHash string) (database.User, error) {
+	row, err := s.q.GetUserBySession(ctx, tokenHash)
+	return database.User(row), err
+}
+
+func (s *Store) GetUserStats(ctx context.Context, id uuid.UUID) (database.GetUserStatsRow, error) {
+	row, err := s.q.GetUserStats(ctx, id)
+	return database.GetUserStatsRow(row), err
+}
+
+func (s *Store) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error {
+	return s.q.MarkFeedFetched(ctx, MarkFeedFetchedParams(arg))
+}
+
+func (s *Store) MarkFeedsReadBefore(ctx context.Context, arg database.MarkFeedsReadBeforeParams) error {
+	return s.q.MarkFeedsReadBefore(ctx, MarkFeedsReadBeforeParams(arg))
+}
+
+func (s *Store) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	return s.q.MarkPostRead(ctx, MarkPostReadParams(arg))
+}
+
+func (s *Store) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) error {
+	return s.q.MarkPostUnread(ctx, MarkPostUnreadParams(arg))
+}
+
+func (s *Store) PrunePosts(ctx context.Context, arg database.PrunePostsParams) (int64, error) {
+	return s.q.PrunePosts(ctx, PrunePostsParams{
+		Now:       arg.Now,
+		KeepDays:  int64(arg.KeepDays),
+		KeepPosts: int64(arg.KeepPosts),
+	})
+}
+
+func (s *Store) RenameFeed(ctx context.Context, arg database.RenameFeedParams) error {
+	return s.q.RenameFeed(ctx, RenameFeedParams(arg))
+}
+
+func (s *Store) RenameUser(ctx context.Context, arg database.RenameUserParams) error {
+	return s.q.RenameUser(ctx, RenameUserParams(arg))
+}
+
+func (s *Store) SetAPIKey(ctx context.Context, arg database.SetAPIKeyParams) error {
+	return s.q.SetAPIKey(ctx, SetAPIKeyParams(arg))
+}
+
+func (s *Store) SetFeedRetention(ctx context.Context, arg database.SetFeedRetentionParams) error {
+	return s.q.SetFeedRetention(ctx, SetFeedRetentionParams{
+		ID:        arg.ID,
+		KeepDays:  widen(arg.KeepDays),
+		KeepPosts: widen(arg.KeepPosts),
+	})
+}
+
+func (s *Store) SetFeedUrl(ctx context.Context, arg database.SetFeedUrlParams) error {
+	return s.q.SetFeedUrl(ctx, SetFeedUrlParams(arg))
+}
+
+func (s *Store) SetFollowAlias(ctx context.Context, arg database.SetFollowAliasParams) (int64, error) {
+	return s.q.SetFollowAlias(ctx, SetFollowAliasParams(arg))
+}
+
+func (s *Store) SetFollowFolders(ctx context.Context, arg database.SetFollowFoldersParams) (int64, error) {
+	return s.q.SetFollowFolders(ctx, SetFollowFoldersParams{
+		Folders: encodeList(arg.Folders),
+		UserID:  arg.UserID,
+		Url:     arg.Url,
+	})
+}
+
+func (s *Store) SetPassword(ctx context.Context, arg database.SetPasswordParams) error {
+	return s.q.SetPassword(ctx, SetPasswordParams(arg))
+}
+
+func (s *Store) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) error {
+	return s.q.SetUserRole(ctx, SetUserRoleParams(arg))
+}
+
+func (s *Store) StarPost(ctx context.Context, arg database.StarPostParams) error {
+	return s.q.StarPost(ctx, StarPostParams(arg))
+}
+
+func (s *Store) TransferFeeds(ctx context.Context, arg database.TransferFeedsParams) (int64, error) {
+	return s.q.TransferFeeds(ctx, TransferFeedsParams(arg))
+}
+
+func (s *Store) UnstarPost(ctx context.Context, arg database.UnstarPostParams) error {
+	return s.q.UnstarPost(ctx, UnstarPostParams(arg))
+}
+
+func convertRows[From, To any](rows []From, err error, convert func(From) To) ([]To, error) {
+	if err != nil {
+		return nil, err
+	}
+	items := make([]To, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, convert(row))
+	}
+	return items, nil
+}
+
+// convertTimelineRow converts a row of GetNewestPostsForUser or
+// GetOldestPostsForUser, which have the same columns.
+func convertTimelineRow(row GetNewestPostsForUserRow) database.GetNewestPostsForUserRow {
+	return database.GetNewestPostsForUserRow{
+		ID:          row.ID,
+		Title:       row.Title,
+		Description: row.Description,
+		Url:         row.Url,
+		Author:      row.Author,
+		Categories:  decodeList(row.Categories),
+		SortDate:    row.SortDate,
+		DateSource:  row.DateSource,
+		FeedName:    row.FeedName,
+		FeedID:      row.FeedID,
+		Read:        row.Read,
+		Starred:     row.Starred,
+	}
+}
+
+func convertFeed(feed Feed) database.Feed {
+	return database.Feed{
+		ID:             feed.ID,
+		CreatedAt:      feed.CreatedAt,
+		UpdatedAt:      feed.UpdatedAt,
+		Name:           feed.Name,
+		UserID:         feed.UserID,
+		Url:            feed.Url,
+		LastFetchedAt:  feed.LastFetchedAt,
+		Seq:            feed.Seq,
+		LastFetchError: feed.LastFetchError,
+		KeepDays:       narrow(feed.KeepDays),
+		KeepPosts:      narrow(feed.KeepPosts),
+	}
+}
+
+// narrow and widen convert between SQLite's integers and the integer
+// columns of Postgres.
+func narrow(n sql.NullInt64) sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(n.Int64), Valid: n.Valid}
+}
+
+func widen(n sql.NullInt32) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n.Int32), Valid: n.Valid}
+}
+
+func encodeList(list []string) string {
+	if list == nil {
+		return "[]"
+	}
+	data, _ := json.Marshal(list)
+	return string(data)
+}
+
+func decodeList(data string) []string {
+	var list []string
+	json.Unmarshal([]byte(data), &list)
+	return list
+}
@@ -1,86 +1,492 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/interyx/gator/internal/config"
 	"github.com/interyx/gator/internal/database"
-	_ "github.com/lib/pq"
+	"io"
+	"maps"
 	"os"
+	"slices"
+	"strconv"
+	"time"
 )
 
 type state struct {
-	db  *database.Queries
-	cfg *config.Config
+	db      database.Querier
+	conn    *sql.DB
+	backend backend
+	cfg     *config.Config
+	// out is where commands write their output: standard output, or a
+	// buffer in tests.
+	out io.Writer
 }
 
 type command struct {
-	name string
-	args []string
+	name  string
+	args  []string
+	usage string
+	flags *flag.FlagSet
+}
+
+// commandSpec describes a registered command.  The usage and description are
+// shown by `gator help`, and flags (if set) declares the flags the command
+// accepts before its handler runs.
+type commandSpec struct {
+	name        string
+	usage       string
+	description string
+	flags       func(fs *flag.FlagSet)
+	// subcommands, for commands such as `gator feed`, declares the flags of
+	// each subcommand, so that one given to the wrong subcommand is rejected
+	// rather than ignored.  Help and completion list them all.
+	subcommands map[string]func(fs *flag.FlagSet)
+	handler     func(*state, command) error
+	// standalone commands run without reading the config or opening the
+	// database.
+	standalone bool
+	// hidden commands are left out of help and shell completion.
+	hidden bool
+	// anySchema commands run even when the database is missing migrations.
+	anySchema bool
+	// completes names the values offered by shell completion for the
+	// command's arguments (see completion.go).  A command with subcommands
+	// has their names offered first, and completes applies to the arguments
+	// after them.
+	completes string
 }
 
 type commands struct {
-	names map[string]func(*state, command) error
+	names map[string]commandSpec
+	// newState opens the config and database for commands that need them.
+	newState func() (*state, error)
 }
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		Item          []RSSItem `xml:"item"`
 	} `xml:"channel"`
 }
 
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate"`
+	Author      string   `xml:"author"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string `xml:"category"`
 }
 
-func (c *commands) register(name string, f func(*state, command) error) {
-	c.names[name] = f
+func (c *commands) register(spec commandSpec) {
+	c.names[spec.name] = spec
 }
 
-func (c *commands) run(s *state, cmd command) error {
-	handler, ok := c.names[cmd.name]
+func (c *commands) run(cmd command) error {
+	if cmd.name == "-h" || cmd.name == "--help" {
+		c.printHelp(os.Stdout)
+		return nil
+	}
+	spec, ok := c.names[cmd.name]
 	if !ok {
-		return fmt.Errorf("Command %s not found", cmd.name)
+		if suggestion := c.suggest(cmd.name); suggestion != "" {
+			return fmt.Errorf("Command %s not found.  Did you mean %s?", cmd.name, suggestion)
+		}
+		return fmt.Errorf("Command %s not found.  Run gator help to list commands", cmd.name)
+	}
+	fs := spec.flagSet()
+	args, err := parseFlags(fs, cmd.args)
+	if err == nil && spec.subcommands != nil && len(args) > 0 {
+		// Parse again now that the subcommand is known.
+		fs = spec.subcommandFlagSet(args[0])
+		args, err = parseFlags(fs, cmd.args)
 	}
-	return handler(s, cmd)
+	if errors.Is(err, flag.ErrHelp) {
+		spec.printHelp(os.Stdout)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%v\nUsage: gator %s", err, spec.usage)
+	}
+	cmd.args = args
+	cmd.usage = spec.usage
+	cmd.flags = fs
+
+	s := &state{out: os.Stdout}
+	if !spec.standalone {
+		s, err = c.newState()
+		if err != nil {
+			return err
+		}
+		if !spec.anySchema {
+			if err := checkSchema(context.Background(), s); err != nil {
+				return err
+			}
+		}
+	}
+	return spec.handler(s, cmd)
 }
 
-func main() {
+func (spec commandSpec) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(spec.name, flag.ContinueOnError)
+	if spec.flags != nil {
+		spec.flags(fs)
+	}
+	for _, sub := range slices.Sorted(maps.Keys(spec.subcommands)) {
+		// Subcommands may share a flag, such as --yes; declare it once.
+		spec.subcommandFlagSet(sub).VisitAll(func(f *flag.Flag) {
+			if fs.Lookup(f.Name) == nil {
+				fs.Var(f.Value, f.Name, f.Usage)
+			}
+		})
+	}
+	return fs
+}
+
+// subcommandFlagSet returns the flags accepted by the subcommand sub, which
+// are none for a subcommand the command does not have.
+func (spec commandSpec) subcommandFlagSet(sub string) *flag.FlagSet {
+	fs := flag.NewFlagSet(spec.name+" "+sub, flag.ContinueOnError)
+	if spec.flags != nil {
+		spec.flags(fs)
+	}
+	if declare := spec.subcommands[sub]; declare != nil {
+		declare(fs)
+	}
+	return fs
+}
+
+// usageError reports a problem with the command line along with the
+// command's usage string.
+func (cmd command) usageError(msg string) error {
+	return fmt.Errorf("%s\nUsage: gator %s", msg, cmd.usage)
+}
+
+// The flag accessors return the zero value for a flag the command does not
+// declare, as for one that was not given.
+
+func (cmd command) lookupFlag(name string) *flag.Flag {
+	if cmd.flags == nil {
+		return nil
+	}
+	return cmd.flags.Lookup(name)
+}
+
+func (cmd command) flagValue(name string) any {
+	f := cmd.lookupFlag(name)
+	if f == nil {
+		return nil
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return nil
+	}
+	return getter.Get()
+}
+
+func (cmd command) flagString(name string) string {
+	f := cmd.lookupFlag(name)
+	if f == nil {
+		return ""
+	}
+	return f.Value.String()
+}
+
+func (cmd command) flagBool(name string) bool {
+	b, _ := cmd.flagValue(name).(bool)
+	return b
+}
+
+func (cmd command) flagStrings(name string) []string {
+	l, _ := cmd.flagValue(name).([]string)
+	return l
+}
+
+func (cmd command) flagInt(name string) int {
+	n, _ := strconv.Atoi(cmd.flagString(name))
+	return n
+}
+
+func (cmd command) flagDuration(name string) time.Duration {
+	d, _ := cmd.flagValue(name).(time.Duration)
+	return d
+}
+
+func newState() (*state, error) {
 	cfg, err := config.Read()
-	handleError(err)
-	db, err := sql.Open("postgres", cfg.Db_url)
-	handleError(err)
-	thisState := state{
-		cfg: &cfg,
-		db:  database.New(db),
-	}
-	cmds := commands{}
-	cmds.names = make(map[string]func(*state, command) error, 5)
-	cmds.register("login", handlerLogin)
-	cmds.register("register", handlerRegister)
-	cmds.register("reset", handlerReset)
-	cmds.register("users", handlerUsers)
-	cmds.register("agg", handlerAgg)
-	cmds.register("addfeed", middlewareLoggedIn(handlerAddFeed))
-	cmds.register("feeds", handlerFeeds)
-	cmds.register("follow", middlewareLoggedIn(handlerFollow))
-	cmds.register("following", middlewareLoggedIn(handlerFollowing))
-	cmds.register("unfollow", middlewareLoggedIn(handlerUnfollow))
-	cmds.register("browse", middlewareLoggedIn(handlerBrowse))
+	if err != nil {
+		return nil, err
+	}
+	conn, db, backend, err := openDatabase(cfg.Db_url)
+	if err != nil {
+		return nil, err
+	}
+	return &state{
+		cfg:     &cfg,
+		db:      db,
+		conn:    conn,
+		backend: backend,
+		out:     os.Stdout,
+	}, nil
+}
+
+// newCommands returns every command gator has.
+func newCommands() *commands {
+	cmds := &commands{
+		names:    make(map[string]commandSpec),
+		newState: newState,
+	}
+	cmds.register(commandSpec{
+		name:        "help",
+		usage:       "help [command]",
+		description: "Show the list of commands, or the details of one command",
+		handler:     cmds.handlerHelp,
+		standalone:  true,
+		completes:   completeCommands,
+	})
+	cmds.register(commandSpec{
+		name:        "completion",
+		usage:       "completion <bash|zsh|fish>",
+		description: "Print a shell completion script",
+		handler:     cmds.handlerCompletion,
+		standalone:  true,
+		completes:   completeShells,
+	})
+	cmds.register(commandSpec{
+		name:        "__complete",
+		usage:       "__complete <feeds|following|users>",
+		description: "Print completion candidates for the shell completion scripts",
+		handler:     handlerComplete,
+		hidden:      true,
+	})
+	cmds.register(commandSpec{
+		name:        "migrate",
+		usage:       "migrate [flags] <up|down|status>",
+		description: "Apply, roll back or list the database migrations",
+		flags:       yesFlag,
+		handler:     handlerMigrate,
+		anySchema:   true,
+		completes:   completeMigrate,
+	})
+	cmds.register(commandSpec{
+		name:        "login",
+		usage:       "login <username>",
+		description: "Log in as a registered user with their password",
+		handler:     handlerLogin,
+		completes:   completeUsers,
+	})
+	cmds.register(commandSpec{
+		name:        "register",
+		usage:       "register [flags] <username>",
+		description: "Register a new user and log in as them",
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("admin", false, "register the installation's first admin")
+		},
+		handler: handlerRegister,
+	})
+	cmds.register(commandSpec{
+		name:        "logout",
+		usage:       "logout",
+		description: "End your login session",
+		handler:     handlerLogout,
+	})
+	cmds.register(commandSpec{
+		name:        "passwd",
+		usage:       "passwd [username]",
+		description: "Change your password, or set another user's (admins only)",
+		handler:     middlewareLoggedIn(handlerPasswd),
+		completes:   completeUsers,
+	})
+	cmds.register(commandSpec{
+		name:        "reset",
+		usage:       "reset [flags]",
+		description: "Delete every user, along with their feeds, follows and posts (admins only)",
+		flags:       yesFlag,
+		handler:     middlewareAdmin(handlerReset),
+	})
+	cmds.register(commandSpec{
+		name: "user",
+		usage: "user show [username]\n" +
+			"       gator user rename <username> <new name>\n" +
+			"       gator user delete [flags] <username>",
+		description: "Show, rename or delete a user; others' accounts need an admin",
+		subcommands: userSubcommands,
+		handler:     middlewareLoggedIn(handlerUser),
+		completes:   completeUsers,
+	})
+	cmds.register(commandSpec{
+		name:        "role",
+		usage:       "role <username> <admin|member>",
+		description: "Change a user's role (admins only)",
+		handler:     middlewareAdmin(handlerRole),
+		completes:   completeUsers,
+	})
+	cmds.register(commandSpec{
+		name:        "users",
+		usage:       "users [flags]",
+		description: "List registered users",
+		flags:       outputFlags,
+		handler:     handlerUsers,
+	})
+	cmds.register(commandSpec{
+		name:        "agg",
+		usage:       "agg [flags] [duration]",
+		description: "Fetch feeds continuously, waiting the given duration (default 1m) between requests",
+		flags: func(fs *flag.FlagSet) {
+			fs.Duration("prune", 0, "also prune old posts, as gator prune does, once per `duration`")
+		},
+		handler: handlerAgg,
+	})
+	cmds.register(commandSpec{
+		name:        "prune",
+		usage:       "prune [flags]",
+		description: "Delete posts older or further back than their feed's retention limits",
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("dry-run", false, "only report the posts that would be deleted")
+		},
+		handler: middlewareAdmin(handlerPrune),
+	})
+	cmds.register(commandSpec{
+		name:        "addfeed",
+		usage:       "addfeed [flags] <feed name> <url>",
+		description: "Add a feed to the aggregator and follow it",
+		flags:       outputFlags,
+		handler:     middlewareLoggedIn(handlerAddFeed),
+	})
+	cmds.register(commandSpec{
+		name:        "feeds",
+		usage:       "feeds [flags]",
+		description: "List every feed in the aggregator",
+		flags:       outputFlags,
+		handler:     handlerFeeds,
+	})
+	cmds.register(commandSpec{
+		name: "feed",
+		usage: "feed show [flags] <url>\n" +
+			"       gator feed rename <url> <new name>\n" +
+			"       gator feed set-url <url> <new url>\n" +
+			"       gator feed retain [flags] <url>\n" +
+			"       gator feed delete [flags] <url>",
+		description: "Show, rename, move, set retention for or delete a feed; changes need its owner or an admin",
+		subcommands: feedSubcommands,
+		handler:     middlewareLoggedIn(handlerFeed),
+		completes:   completeFeeds,
+	})
+	cmds.register(commandSpec{
+		name:        "follow",
+		usage:       "follow [flags] <url>",
+		description: "Follow a feed that has already been added",
+		flags:       outputFlags,
+		handler:     middlewareLoggedIn(handlerFollow),
+		completes:   completeFeeds,
+	})
+	cmds.register(commandSpec{
+		name:        "following",
+		usage:       "following [flags]",
+		description: "List the feeds you follow",
+		flags:       outputFlags,
+		handler:     middlewareLoggedIn(handlerFollowing),
+	})
+	cmds.register(commandSpec{
+		name:        "unfollow",
+		usage:       "unfollow <url>",
+		description: "Stop following a feed",
+		handler:     middlewareLoggedIn(handlerUnfollow),
+		completes:   completeFollowing,
+	})
+	cmds.register(commandSpec{
+		name:        "alias",
+		usage:       "alias <url> [name]",
+		description: "Show a feed you follow under your own name for it, or its own name again",
+		handler:     middlewareLoggedIn(handlerAlias),
+		completes:   completeFollowing,
+	})
+	cmds.register(commandSpec{
+		name:        "folder",
+		usage:       "folder <url> [folder...]",
+		description: "File a feed you follow in folders, or in none",
+		handler:     middlewareLoggedIn(handlerFolder),
+		completes:   completeFollowing,
+	})
+	cmds.register(commandSpec{
+		name:        "browse",
+		usage:       "browse [flags] [limit]",
+		description: "Show posts from the feeds you follow, newest first",
+		flags:       browseFlags,
+		handler:     middlewareLoggedIn(handlerBrowse),
+	})
+	cmds.register(commandSpec{
+		name:        "export",
+		usage:       "export feed [flags]",
+		description: "Print a timeline as an Atom or RSS feed",
+		flags:       exportFlags,
+		handler:     middlewareLoggedIn(handlerExport),
+	})
+	cmds.register(commandSpec{
+		name: "publish",
+		usage: "publish add [flags] <name>\n" +
+			"       gator publish list\n" +
+			"       gator publish remove <name>",
+		description: "Save searches that gator serve publishes as feeds",
+		subcommands: publishSubcommands,
+		handler:     middlewareLoggedIn(handlerPublish),
+	})
+	cmds.register(commandSpec{
+		name:        "site",
+		usage:       "site build [flags] <outdir>",
+		description: "Build a static planet site from a user's feeds",
+		flags:       siteFlags,
+		handler:     middlewareLoggedIn(handlerSite),
+	})
+	cmds.register(commandSpec{
+		name:        "apikey",
+		usage:       "apikey [flags]",
+		description: "Make a key for the HTTP and Fever APIs",
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("rotate", false, "replace the key with a new one and print it")
+		},
+		handler: middlewareLoggedIn(handlerAPIKey),
+	})
+	cmds.register(commandSpec{
+		name:        "serve",
+		usage:       "serve [flags]",
+		description: "Serve the web reader and JSON API over HTTP",
+		flags: func(fs *flag.FlagSet) {
+			fs.String("addr", ":8080", "`address` to listen on")
+		},
+		handler: handlerServe,
+	})
+	cmds.register(commandSpec{
+		name:        "tui",
+		usage:       "tui [flags]",
+		description: "Read posts in a full-screen terminal reader",
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("unread", false, "start by showing only unread posts")
+		},
+		handler: middlewareLoggedIn(handlerTUI),
+	})
+	return cmds
+}
+
+func main() {
+	cmds := newCommands()
 	args := os.Args
 	if len(args) < 2 {
-		handleError(fmt.Errorf("Not enough arguments provided"))
+		cmds.printHelp(os.Stderr)
+		os.Exit(1)
 	}
 	cmd := command{
 		name: args[1],
 		args: args[2:],
 	}
-	err = cmds.run(&thisState, cmd)
+	err := cmds.run(cmd)
 	handleError(err)
 }
@@ -2,25 +2,36 @@ package main
 
 import (
 	"context"
-	// "encoding/xml"
-	// "fmt"
-	// "github.com/google/uuid"
+	"database/sql"
+	"errors"
+	"fmt"
+
 	"github.com/interyx/gator/internal/database"
 	_ "github.com/lib/pq"
-	// "html"
-	// "io"
-	// "net/http"
-	// "net/url"
-	// "os"
-	// "time"
 )
 
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
 	return func(s *state, cmd command) error {
-		user, err := s.db.GetUser(context.Background(), s.cfg.User)
+		if s.cfg.SessionToken == "" {
+			return errors.New("You are not logged in; run `gator login <name>`")
+		}
+		user, err := userForSession(context.Background(), s, s.cfg.SessionToken)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("Your session has expired; run `gator login <name>` again")
+		}
 		if err != nil {
 			return err
 		}
 		return handler(s, cmd, user)
 	}
 }
+
+// middlewareAdmin is middlewareLoggedIn for commands that only admins may run.
+func middlewareAdmin(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
+	return middlewareLoggedIn(func(s *state, cmd command, user database.User) error {
+		if user.Role != roleAdmin {
+			return fmt.Errorf("Only admins can run `gator %s`", cmd.name)
+		}
+		return handler(s, cmd, user)
+	})
+}
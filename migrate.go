@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The migrations in sql/schema, and their SQLite versions in
+// sql/sqlite/schema, are embedded in the binary and applied with
+// `gator migrate`.  They stay in goose's format and are recorded in goose's
+// goose_db_version table, so a database set up with the goose tool carries
+// on where it left off, and goose can still be used on one set up by gator.
+
+//go:embed sql/schema/*.sql sql/sqlite/schema/*.sql
+var schemaFiles embed.FS
+
+const versionTable = "goose_db_version"
+
+type migration struct {
+	version int64
+	name    string
+	up      string
+	down    string
+}
+
+// loadMigrations reads the migrations in dir, oldest first.
+func loadMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	names, err := fs.Glob(fsys, dir+"/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []migration
+	for _, name := range names {
+		text, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		m, err := parseMigration(path.Base(name), string(text))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+// parseMigration splits a goose migration into its Up and Down sections.
+// The version is the number the file name starts with.
+func parseMigration(name, text string) (migration, error) {
+	prefix, _, _ := strings.Cut(name, "_")
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil {
+		return migration{}, fmt.Errorf("Migration %s does not start with a version number", name)
+	}
+	m := migration{version: version, name: name}
+	var section *string
+	var b strings.Builder
+	flush := func() {
+		if section != nil {
+			*section = strings.TrimSpace(b.String())
+		}
+		b.Reset()
+	}
+	for _, line := range strings.SplitAfter(text, "\n") {
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			flush()
+			section = &m.up
+			continue
+		case "-- +goose Down":
+			flush()
+			section = &m.down
+			continue
+		}
+		b.WriteString(line)
+	}
+	flush()
+	if m.up == "" {
+		return migration{}, fmt.Errorf("Migration %s has no `-- +goose Up` section", name)
+	}
+	return m, nil
+}
+
+// appliedMigrations returns when each applied migration was applied.  A
+// database that goose has never touched has none.
+func appliedMigrations(ctx context.Context, s *state) (map[int64]time.Time, error) {
+	var exists bool
+	err := s.conn.QueryRowContext(ctx, s.backend.tableExists, versionTable).Scan(&exists)
+	if err != nil || !exists {
+		return map[int64]time.Time{}, err
+	}
+	rows, err := s.conn.QueryContext(ctx, "SELECT version_id, is_applied, tstamp FROM "+versionTable+" ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	// Older versions of goose recorded rollbacks as rows with is_applied
+	// unset instead of deleting the row, so only the newest row for each
+	// version counts.
+	applied := map[int64]time.Time{}
+	seen := map[int64]bool{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var at sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &at); err != nil {
+			return nil, err
+		}
+		if seen[version] {
+			continue
+		}
+		seen[version] = true
+		if isApplied && version != 0 {
+			applied[version] = at.Time
+		}
+	}
+	return applied, rows.Err()
+}
+
+func createVersionTable(ctx context.Context, s *state) error {
+	_, err := s.conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+versionTable+` (`+s.backend.versionTableDDL+`);
+INSERT INTO `+versionTable+` (version_id, is_applied)
+SELECT 0, true WHERE NOT EXISTS (SELECT 1 FROM `+versionTable+`)`)
+	return err
+}
+
+// runMigration applies one side of m and records it, in one transaction so a
+// failed migration leaves nothing half done.
+func runMigration(ctx context.Context, s *state, m migration, up bool) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	script, record := m.up, "INSERT INTO "+versionTable+" (version_id, is_applied) VALUES ($1, true)"
+	if !up {
+		script, record = m.down, "DELETE FROM "+versionTable+" WHERE version_id = $1"
+	}
+	if script != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return fmt.Errorf("Migration %s failed: %v", m.name, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, record, m.version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// pendingMigrations returns the migrations that have not been applied.
+func pendingMigrations(ctx context.Context, s *state) ([]migration, error) {
+	migrations, err := loadMigrations(schemaFiles, s.backend.schemaDir)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	var pending []migration
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// checkSchema reports a database that is missing migrations, rather than
+// letting the command fail later on a missing table or column.
+func checkSchema(ctx context.Context, s *state) error {
+	if s.conn == nil {
+		// The in-memory store has no schema to migrate.
+		return nil
+	}
+	pending, err := pendingMigrations(ctx, s)
+	if err != nil {
+		return fmt.Errorf("Could not check the database schema: %v", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("Your database is missing %d migrations needed by this version of gator.  Run `gator migrate up` to apply them", len(pending))
+	}
+	return nil
+}
+
+func handlerMigrate(s *state, cmd command) error {
+	if len(cmd.args) != 1 {
+		return cmd.usageError("Expected up, down or status.")
+	}
+	ctx := context.Background()
+	switch cmd.args[0] {
+	case "up":
+		return migrateUp(ctx, s)
+	case "down":
+		return migrateDown(ctx, s, cmd)
+	case "status":
+		return migrateStatus(ctx, s)
+	}
+	return cmd.usageError(fmt.Sprintf("Unknown subcommand %q; use up, down or status.", cmd.args[0]))
+}
+
+func migrateUp(ctx context.Context, s *state) error {
+	pending, err := pendingMigrations(ctx, s)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(s.out, "The database is up to date")
+		return nil
+	}
+	if err := createVersionTable(ctx, s); err != nil {
+		return err
+	}
+	for _, m := range pending {
+		if err := runMigration(ctx, s, m, true); err != nil {
+			return err
+		}
+		fmt.Fprintf(s.out, "Applied %s\n", m.name)
+	}
+	return nil
+}
+
+// migrateDown rolls back the newest applied migration.
+func migrateDown(ctx context.Context, s *state, cmd command) error {
+	migrations, err := loadMigrations(schemaFiles, s.backend.schemaDir)
+	if err != nil {
+		return err
+	}
+	applied, err := appliedMigrations(ctx, s)
+	if err != nil {
+		return err
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.version]; !ok {
+			continue
+		}
+		ok, err := confirm(s, cmd, fmt.Sprintf("Roll back %s?  Data in the tables and columns it added will be lost.", m.name))
+		if err != nil || !ok {
+			return err
+		}
+		if err := runMigration(ctx, s, m, false); err != nil {
+			return err
+		}
+		fmt.Fprintf(s.out, "Rolled back %s\n", m.name)
+		return nil
+	}
+	fmt.Fprintln(s.out, "No migrations have been applied")
+	return nil
+}
+
+func migrateStatus(ctx context.Context, s *state) error {
+	migrations, err := loadMigrations(schemaFiles, s.backend.schemaDir)
+	if err != nil {
+		return err
+	}
+	applied, err := appliedMigrations(ctx, s)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "%-18s  %s\n", "APPLIED", "MIGRATION")
+	for _, m := range migrations {
+		status := "pending"
+		if at, ok := applied[m.version]; ok {
+			status = formatTime(at)
+		}
+		fmt.Fprintf(s.out, "%-18s  %s\n", status, m.name)
+	}
+	return nil
+}
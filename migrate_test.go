@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseMigration(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		text     string
+		wantUp   string
+		wantDown string
+		wantErr  string
+	}{
+		{
+			name:     "up and down",
+			file:     "001_users.sql",
+			text:     "-- +goose Up\nCREATE TABLE users (id int);\n\n-- +goose Down\nDROP TABLE users;\n",
+			wantUp:   "CREATE TABLE users (id int);",
+			wantDown: "DROP TABLE users;",
+		},
+		{
+			name:   "up only",
+			file:   "002_index.sql",
+			text:   "-- +goose Up\nCREATE INDEX users_idx ON users (id);\n",
+			wantUp: "CREATE INDEX users_idx ON users (id);",
+		},
+		{
+			name:     "comments before up",
+			file:     "003_note.sql",
+			text:     "-- Explains the migration.\n\n  -- +goose Up  \nSELECT 1;\n-- +goose Down\n",
+			wantUp:   "SELECT 1;",
+			wantDown: "",
+		},
+		{
+			// The markers are SQL comments, and the statement between them
+			// is kept whole, semicolons and all.
+			name: "statement block",
+			file: "004_function.sql",
+			text: "-- +goose Up\n-- +goose StatementBegin\nCREATE FUNCTION f() RETURNS int AS $$\nBEGIN\n  RETURN 1;\nEND;\n$$ LANGUAGE plpgsql;\n-- +goose StatementEnd\n\n" +
+				"-- +goose Down\nDROP FUNCTION f;\n",
+			wantUp:   "-- +goose StatementBegin\nCREATE FUNCTION f() RETURNS int AS $$\nBEGIN\n  RETURN 1;\nEND;\n$$ LANGUAGE plpgsql;\n-- +goose StatementEnd",
+			wantDown: "DROP FUNCTION f;",
+		},
+		{
+			name:    "missing up",
+			file:    "005_plain.sql",
+			text:    "CREATE TABLE feeds (id int);\n",
+			wantErr: "has no `-- +goose Up` section",
+		},
+		{
+			name:    "down only",
+			file:    "006_down.sql",
+			text:    "-- +goose Down\nDROP TABLE feeds;\n",
+			wantErr: "has no `-- +goose Up` section",
+		},
+		{
+			name:    "empty up",
+			file:    "007_empty.sql",
+			text:    "-- +goose Up\n\n-- +goose Down\nDROP TABLE feeds;\n",
+			wantErr: "has no `-- +goose Up` section",
+		},
+		{
+			name:    "no version",
+			file:    "users.sql",
+			text:    "-- +goose Up\nSELECT 1;\n",
+			wantErr: "does not start with a version number",
+		},
+	}
+	for _, tc := range tests {
+		m, err := parseMigration(tc.file, tc.text)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%s: got error %v, want one containing %q", tc.name, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if m.up != tc.wantUp || m.down != tc.wantDown || m.name != tc.file {
+			t.Errorf("%s: got %s up %q, down %q; want up %q, down %q", tc.name, m.name, m.up, m.down, tc.wantUp, tc.wantDown)
+		}
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	// Both backends have their migrations in order, with none missing.
+	for _, b := range []backend{postgresBackend, sqliteBackend} {
+		migrations, err := loadMigrations(schemaFiles, b.schemaDir)
+		if err != nil {
+			t.Fatalf("%s: %v", b.name, err)
+		}
+		for i, m := range migrations {
+			if m.version != int64(i+1) {
+				t.Errorf("%s: migration %d is %s", b.name, i+1, m.name)
+			}
+		}
+	}
+}
+
+// newMigrateEnv is a testEnv on an empty SQLite database.
+func newMigrateEnv(t *testing.T) (*testEnv, int) {
+	e := newTestEnv(t)
+	s := openSQLiteState(t)
+	e.s.db, e.s.conn, e.s.backend = s.db, s.conn, s.backend
+	migrations, err := loadMigrations(schemaFiles, sqliteBackend.schemaDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, len(migrations)
+}
+
+func TestMigrateRoundTrip(t *testing.T) {
+	e, n := newMigrateEnv(t)
+	ctx := context.Background()
+
+	out := e.mustRun("", "migrate", "status")
+	if got := strings.Count(out, "pending"); got != n {
+		t.Errorf("status of a new database shows %d pending, want %d:\n%s", got, n, out)
+	}
+	if err := checkSchema(ctx, e.s); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("checkSchema on a new database: %v", err)
+	}
+	// Commands other than migrate refuse to run without the schema.
+	e.mustFail("gator migrate up", "password1\n", "register", "--admin", "bob")
+
+	out = e.mustRun("", "migrate", "up")
+	if got := strings.Count(out, "Applied "); got != n {
+		t.Errorf("migrate up applied %d, want %d:\n%s", got, n, out)
+	}
+	if out := e.mustRun("", "migrate", "up"); out != "The database is up to date\n" {
+		t.Errorf("migrate up again printed %q", out)
+	}
+	if err := checkSchema(ctx, e.s); err != nil {
+		t.Errorf("checkSchema after migrating: %v", err)
+	}
+	if out := e.mustRun("", "migrate", "status"); strings.Contains(out, "pending") {
+		t.Errorf("status after migrating:\n%s", out)
+	}
+	e.setUp()
+
+	// Rolling back needs confirming, which tests cannot do but with --yes.
+	e.mustFail("--yes", "", "migrate", "down")
+	migrations, err := loadMigrations(schemaFiles, sqliteBackend.schemaDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := migrations[n-1].name
+	if out := e.mustRun("", "migrate", "--yes", "down"); out != "Rolled back "+last+"\n" {
+		t.Errorf("migrate down printed %q, want it to roll back %s", out, last)
+	}
+	out = e.mustRun("", "migrate", "status")
+	if !strings.Contains(out, fmt.Sprintf("%-18s  %s", "pending", last)) || strings.Count(out, "pending") != 1 {
+		t.Errorf("status after rolling back:\n%s", out)
+	}
+	if err := checkSchema(ctx, e.s); err == nil || !strings.Contains(err.Error(), "missing 1 migrations") {
+		t.Errorf("checkSchema after rolling back: %v", err)
+	}
+
+	for range n - 1 {
+		e.mustRun("", "migrate", "--yes", "down")
+	}
+	if out := e.mustRun("", "migrate", "--yes", "down"); out != "No migrations have been applied\n" {
+		t.Errorf("migrate down with nothing applied printed %q", out)
+	}
+	// Nothing is left but goose's table and SQLite's own.
+	var tables int
+	err = e.s.conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('goose_db_version', 'sqlite_sequence')").Scan(&tables)
+	if err != nil || tables != 0 {
+		t.Errorf("%d tables left after rolling everything back, %v", tables, err)
+	}
+	out = e.mustRun("", "migrate", "up")
+	if got := strings.Count(out, "Applied "); got != n {
+		t.Errorf("migrate up after rolling back applied %d, want %d", got, n)
+	}
+}
+
+func TestCheckSchemaGooseDatabase(t *testing.T) {
+	e, n := newMigrateEnv(t)
+	ctx := context.Background()
+	exec := func(query string, args ...any) {
+		t.Helper()
+		if _, err := e.s.conn.Exec(query, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// goose starts its table with a row for version 0, which is not a
+	// migration.
+	exec("CREATE TABLE " + versionTable + " (" + sqliteBackend.versionTableDDL + ")")
+	exec("INSERT INTO " + versionTable + " (version_id, is_applied) VALUES (0, 1)")
+	if err := checkSchema(ctx, e.s); err == nil || !strings.Contains(err.Error(), fmt.Sprintf("missing %d migrations", n)) {
+		t.Errorf("checkSchema with only goose's first row: %v", err)
+	}
+	e.mustRun("", "migrate", "up")
+	var seeds int
+	if err := e.s.conn.QueryRow("SELECT COUNT(*) FROM " + versionTable + " WHERE version_id = 0").Scan(&seeds); err != nil || seeds != 1 {
+		t.Errorf("%d rows for version 0, %v; want goose's one", seeds, err)
+	}
+	if err := checkSchema(ctx, e.s); err != nil {
+		t.Errorf("checkSchema after migrating: %v", err)
+	}
+
+	// Older versions of goose recorded a rollback as a newer row with
+	// is_applied unset, and reapplying as a newer row again.  The newest
+	// row for each version is the one that counts.
+	exec("INSERT INTO "+versionTable+" (version_id, is_applied) VALUES (?, 0)", n)
+	if err := checkSchema(ctx, e.s); err == nil || !strings.Contains(err.Error(), "missing 1 migrations") {
+		t.Errorf("checkSchema after goose rolled back: %v", err)
+	}
+	exec("INSERT INTO "+versionTable+" (version_id, is_applied) VALUES (?, 1)", n)
+	if err := checkSchema(ctx, e.s); err != nil {
+		t.Errorf("checkSchema after goose reapplied: %v", err)
+	}
+	if out := e.mustRun("", "migrate", "status"); strings.Contains(out, "pending") {
+		t.Errorf("status of goose's database:\n%s", out)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/interyx/gator/internal/database"
+)
+
+// Posts are kept until they fall outside a feed's retention limits: stored
+// more than keep_days ago, or past its keep_posts newest by date.  Each feed may set its own
+// limits with `gator feed retain`; the rest use keep_days and keep_posts from
+// the config file.  Starred posts are never pruned.
+
+func handlerPrune(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return cmd.usageError("Too many arguments.")
+	}
+	return prunePosts(context.Background(), s, cmd.flagBool("dry-run"))
+}
+
+// prunePosts deletes the posts the retention limits no longer keep and
+// reports how many went from each feed.  With dryRun it only reports them.
+func prunePosts(ctx context.Context, s *state, dryRun bool) error {
+	if s.cfg.KeepDays < 0 || s.cfg.KeepPosts < 0 {
+		return errors.New("keep_days and keep_posts in ~/.gatorconfig.json cannot be negative")
+	}
+	params := database.CountPrunablePostsParams{
+		Now:       time.Now().UTC(),
+		KeepDays:  int32(s.cfg.KeepDays),
+		KeepPosts: int32(s.cfg.KeepPosts),
+	}
+	var counts []database.CountPrunablePostsRow
+	err := withTx(ctx, s, func(q database.Querier) error {
+		var err error
+		counts, err = q.CountPrunablePosts(ctx, params)
+		if err != nil || dryRun {
+			return err
+		}
+		_, err = q.PrunePosts(ctx, database.PrunePostsParams(params))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(counts) == 0 {
+		fmt.Fprintln(s.out, "No posts to prune")
+		return nil
+	}
+	var total int64
+	for _, row := range counts {
+		fmt.Fprintf(s.out, "  %s (%s): %d posts\n", row.Name, row.Url, row.Posts)
+		total += row.Posts
+	}
+	if dryRun {
+		fmt.Fprintf(s.out, "Would remove %d posts from %d feeds\n", total, len(counts))
+	} else {
+		fmt.Fprintf(s.out, "Removed %d posts from %d feeds\n", total, len(counts))
+	}
+	return nil
+}
+
+// retentionLimit describes one of a feed's retention limits, which falls
+// back to the config file's when the feed has none.  unit follows the number.
+func retentionLimit(limit sql.NullInt32, fallback int, unit string) string {
+	n, source := fallback, " (default)"
+	if limit.Valid {
+		n, source = int(limit.Int32), ""
+	}
+	if n == 0 {
+		return "no limit" + source
+	}
+	return fmt.Sprintf("%d%s%s", n, unit, source)
+}
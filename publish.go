@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+// `gator publish` manages saved searches: filtered timelines that `gator
+// serve` publishes as feeds.  Nothing is published until a user saves a
+// search, and each search is only reachable with its own secret token.
+
+// publicFilters are the browse filters a saved search may use.  Folders and
+// stars are private to their user, so they are never published.
+var publicFilters = []string{"feed", "since", "until", "author", "tag", "search"}
+
+var publishSubcommands = map[string]func(fs *flag.FlagSet){
+	"add": func(fs *flag.FlagSet) {
+		fs.String("feed", "", "only include posts from the feed with this `url`")
+		fs.String("since", "", "only include posts published on or after this `date`")
+		fs.String("until", "", "only include posts published before the end of this `date`")
+		fs.String("author", "", "only include posts whose author contains this `text`")
+		fs.String("tag", "", "only include posts with this `category`")
+		fs.String("search", "", "only include posts whose title or description contains this `text`")
+	},
+	"list":   nil,
+	"remove": nil,
+}
+
+// savedQuery reads a saved search's filters, which are stored as a URL
+// query string.
+func savedQuery(filters string, limit int) (postQuery, error) {
+	values, err := url.ParseQuery(filters)
+	if err != nil {
+		return postQuery{}, err
+	}
+	public := url.Values{}
+	for _, name := range publicFilters {
+		if v := values.Get(name); v != "" {
+			public.Set(name, v)
+		}
+	}
+	return postQueryFromValues(public, limit), nil
+}
+
+func handlerPublish(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return cmd.usageError("Expected a subcommand: add, list or remove.")
+	}
+	sub := cmd.args[0]
+	cmd.args = cmd.args[1:]
+	switch sub {
+	case "add":
+		return handlerPublishAdd(s, cmd, user)
+	case "list":
+		return handlerPublishList(s, cmd, user)
+	case "remove":
+		return handlerPublishRemove(s, cmd, user)
+	}
+	return cmd.usageError(fmt.Sprintf("Unknown subcommand %q; use add, list or remove.", sub))
+}
+
+func handlerPublishAdd(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return cmd.usageError("Expected a name for the saved search.")
+	}
+	name := cmd.args[0]
+	values := url.Values{}
+	for _, filter := range publicFilters {
+		if v := cmd.flagString(filter); v != "" {
+			values.Set(filter, v)
+		}
+	}
+	// Catch bad dates now rather than when a feed reader asks for the feed.
+	if _, err := postQueryFromValues(values, 1).params(user.ID); err != nil {
+		return err
+	}
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	_, err = s.db.CreateSavedSearch(context.Background(), database.CreateSavedSearchParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Name:      name,
+		Filters:   values.Encode(),
+		TokenHash: hashToken(token),
+	})
+	if isUniqueViolation(err) {
+		return fmt.Errorf("You already have a saved search called %s", name)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "Saved %s.  `gator serve` publishes it at:\n", name)
+	fmt.Fprintf(s.out, "  /saved/feed.atom?token=%s\n", token)
+	fmt.Fprintf(s.out, "  /saved/feed.rss?token=%s\n", token)
+	fmt.Fprintln(s.out, "Anyone with these URLs can read the feed.  The token is not kept, so note it")
+	fmt.Fprintln(s.out, "now; to replace it, remove the search and add it again.")
+	return nil
+}
+
+func handlerPublishList(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return cmd.usageError("Too many arguments.")
+	}
+	searches, err := s.db.GetSavedSearches(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+	if len(searches) == 0 {
+		fmt.Fprintln(s.out, "You have no saved searches; add one with `gator publish add <name>`")
+		return nil
+	}
+	for _, search := range searches {
+		fmt.Fprintf(s.out, "* %s: %s (saved %s)\n", search.Name, describeFilters(search.Filters), formatTime(search.CreatedAt))
+	}
+	return nil
+}
+
+// describeFilters lists a saved search's filters as flags.
+func describeFilters(filters string) string {
+	values, _ := url.ParseQuery(filters)
+	var parts []string
+	for _, name := range publicFilters {
+		if v := values.Get(name); v != "" {
+			parts = append(parts, fmt.Sprintf("--%s %q", name, v))
+		}
+	}
+	if len(parts) == 0 {
+		return "every post"
+	}
+	return strings.Join(parts, " ")
+}
+
+func handlerPublishRemove(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return cmd.usageError("Expected the name of the saved search to remove.")
+	}
+	n, err := s.db.DeleteSavedSearch(context.Background(), database.DeleteSavedSearchParams{
+		UserID: user.ID,
+		Name:   cmd.args[0],
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("No saved search called %s", cmd.args[0])
+	}
+	fmt.Fprintf(s.out, "Removed %s; its feed is no longer published\n", cmd.args[0])
+	return nil
+}
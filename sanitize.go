@@ -0,0 +1,190 @@
+package main
+
+import (
+	"html/template"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Post descriptions are HTML from whoever runs the feed.  They are cleaned
+// with sanitizeHTML before they are stored, and again as they are read out,
+// which also covers posts stored before gator cleaned them.
+
+// allowedAttrs lists the elements kept by sanitizeHTML and the attributes
+// each keeps.  Other elements are replaced by their contents, except for
+// droppedElements, which go with their contents.
+var allowedAttrs = map[atom.Atom][]string{
+	atom.A: {"href", "title"}, atom.Abbr: {"title"}, atom.B: nil,
+	atom.Blockquote: {"cite"}, atom.Br: nil, atom.Caption: nil, atom.Cite: nil,
+	atom.Code: nil, atom.Dd: nil, atom.Del: nil, atom.Div: nil, atom.Dl: nil,
+	atom.Dt: nil, atom.Em: nil, atom.Figcaption: nil, atom.Figure: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Hr: nil, atom.I: nil, atom.Img: {"src", "alt", "title", "width", "height"},
+	atom.Ins: nil, atom.Kbd: nil, atom.Li: nil, atom.Mark: nil, atom.Ol: {"start"},
+	atom.P: nil, atom.Pre: nil, atom.Q: {"cite"}, atom.S: nil, atom.Small: nil,
+	atom.Span: nil, atom.Strong: nil, atom.Sub: nil, atom.Sup: nil,
+	atom.Table: nil, atom.Tbody: nil, atom.Td: {"colspan", "rowspan"},
+	atom.Tfoot: nil, atom.Th: {"colspan", "rowspan"}, atom.Thead: nil,
+	atom.Tr: nil, atom.U: nil, atom.Ul: nil,
+}
+
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Iframe: true, atom.Frame: true, atom.Frameset: true, atom.Object: true,
+	atom.Embed: true, atom.Applet: true, atom.Param: true, atom.Source: true,
+	atom.Track: true, atom.Canvas: true, atom.Svg: true, atom.Math: true,
+	atom.Form: true, atom.Input: true, atom.Button: true, atom.Select: true,
+	atom.Textarea: true, atom.Head: true, atom.Title: true, atom.Meta: true,
+	atom.Link: true, atom.Base: true,
+}
+
+// urlAttrs are the attributes that hold URLs, which must be relative or use
+// one of the schemes listed.
+var urlAttrs = map[string][]string{
+	"href": {"http", "https", "mailto"},
+	"src":  {"http", "https"},
+	"cite": {"http", "https"},
+}
+
+// sanitizeHTML returns the parts of an HTML fragment that are safe to show:
+// formatting, links and images, without scripts, styles, embedded content,
+// event handlers or tracking pixels.  Relative URLs are resolved against
+// base, the post's own URL, as they would not work anywhere else.
+func sanitizeHTML(s, base string) string {
+	root := parseHTMLFragment(s)
+	if root == nil {
+		return ""
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil || !baseURL.IsAbs() {
+		baseURL = nil
+	}
+	sanitizeChildren(root, baseURL)
+	var b strings.Builder
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&b, c); err != nil {
+			return ""
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// sanitizedHTML is sanitizeHTML for templates, which include the result in
+// the page as HTML rather than escaping it.
+func sanitizedHTML(s string) template.HTML {
+	return template.HTML(sanitizeHTML(s, ""))
+}
+
+// parseHTMLFragment parses s as the contents of a <div>, which it returns.
+func parseHTMLFragment(s string) *html.Node {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		return nil
+	}
+	for _, n := range nodes {
+		context.AppendChild(n)
+	}
+	return context
+}
+
+func sanitizeChildren(n *html.Node, base *url.URL) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			attrs, allowed := allowedAttrs[c.DataAtom]
+			switch {
+			case droppedElements[c.DataAtom]:
+				n.RemoveChild(c)
+			case !allowed:
+				// Keep what is inside, in place of the element.
+				sanitizeChildren(c, base)
+				for gc := c.FirstChild; gc != nil; gc = c.FirstChild {
+					c.RemoveChild(gc)
+					n.InsertBefore(gc, c)
+				}
+				n.RemoveChild(c)
+			default:
+				c.Attr = sanitizeAttrs(c.Attr, attrs, base)
+				if c.DataAtom == atom.A {
+					c.Attr = append(c.Attr, html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"})
+				}
+				if c.DataAtom == atom.Img && isTrackingPixel(c) {
+					n.RemoveChild(c)
+					break
+				}
+				sanitizeChildren(c, base)
+			}
+		default:
+			// Comments and anything else the parser turns up.
+			n.RemoveChild(c)
+		}
+		c = next
+	}
+}
+
+// sanitizeAttrs keeps the attributes named in allowed whose values are safe.
+func sanitizeAttrs(attrs []html.Attribute, allowed []string, base *url.URL) []html.Attribute {
+	var kept []html.Attribute
+	for _, a := range attrs {
+		if a.Namespace != "" || !slices.Contains(allowed, a.Key) {
+			continue
+		}
+		if schemes, ok := urlAttrs[a.Key]; ok {
+			resolved, ok := safeURL(a.Val, schemes, base)
+			if !ok {
+				continue
+			}
+			a.Val = resolved
+		}
+		kept = append(kept, html.Attribute{Key: a.Key, Val: a.Val})
+	}
+	return kept
+}
+
+// safeURL resolves raw against base, if there is one, and reports whether
+// the result is relative or uses one of schemes.
+func safeURL(raw string, schemes []string, base *url.URL) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	if base != nil && !strings.HasPrefix(raw, "#") {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "" && !slices.Contains(schemes, strings.ToLower(u.Scheme)) {
+		return "", false
+	}
+	return u.String(), true
+}
+
+// isTrackingPixel reports whether img has no source or is too small to
+// show anything.
+func isTrackingPixel(img *html.Node) bool {
+	src, width, height := "", "", ""
+	for _, a := range img.Attr {
+		switch a.Key {
+		case "src":
+			src = a.Val
+		case "width":
+			width = a.Val
+		case "height":
+			height = a.Val
+		}
+	}
+	tiny := func(size string) bool {
+		size = strings.TrimSuffix(strings.TrimSpace(size), "px")
+		return size == "0" || size == "1"
+	}
+	return src == "" || tiny(width) || tiny(height)
+}
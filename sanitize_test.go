@@ -0,0 +1,82 @@
+package main
+
+import "testing"
+
+func TestSanitizeHTML(t *testing.T) {
+	const base = "https://example.com/blog/post.html"
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"text", "plain & simple", "plain &amp; simple"},
+		{"formatting", "<p>Some <b>bold</b> and <em>emphasis</em></p>", "<p>Some <b>bold</b> and <em>emphasis</em></p>"},
+		{"unknown element", "<custom>kept <b>inside</b></custom>", "kept <b>inside</b>"},
+		{"comment", "a<!-- hidden -->b", "ab"},
+
+		// Scripts and event handlers.
+		{"script", "<p>hi</p><script>alert(1)</script>", "<p>hi</p>"},
+		{"script in unknown element", "<custom><script>alert(1)</script>ok</custom>", "ok"},
+		{"noscript", "<noscript><img src=x></noscript>ok", "ok"},
+		{"onclick", `<p onclick="alert(1)">hi</p>`, "<p>hi</p>"},
+		{"onerror", `<img src="https://example.com/a.png" onerror="alert(1)">`, `<img src="https://example.com/a.png"/>`},
+		{"onmouseover on link", `<a href="https://example.com/" onmouseover="alert(1)">x</a>`, `<a href="https://example.com/" rel="nofollow noopener noreferrer">x</a>`},
+
+		// Link schemes.
+		{"https link", `<a href="https://example.com/" title="t">x</a>`, `<a href="https://example.com/" title="t" rel="nofollow noopener noreferrer">x</a>`},
+		{"mailto link", `<a href="mailto:me@example.com">x</a>`, `<a href="mailto:me@example.com" rel="nofollow noopener noreferrer">x</a>`},
+		{"javascript href", `<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"mixed case javascript href", `<a href="JaVaScRiPt:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"spaced javascript href", `<a href="  javascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"entity encoded javascript href", `<a href="&#106;avascript&#58;alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"hex entity javascript href", `<a href="&#x6A;&#x61;vascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"tab in javascript href", "<a href=\"java\tscript:alert(1)\">x</a>", `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"data href", `<a href="data:text/html;base64,PHNjcmlwdD4=">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"mixed case data src", `<img src="DaTa:image/png;base64,AAAA" alt="a">`, ""},
+		{"vbscript href", `<a href="vbscript:msgbox(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"javascript cite", `<blockquote cite="javascript:alert(1)">q</blockquote>`, "<blockquote>q</blockquote>"},
+
+		// Styles and embedded content.
+		{"style attribute", `<p style="position:fixed">hi</p>`, "<p>hi</p>"},
+		{"style element", "<style>body{display:none}</style><p>hi</p>", "<p>hi</p>"},
+		{"iframe", `<iframe src="https://example.com/"></iframe>ok`, "ok"},
+		{"object", `<object data="x.swf"><param name="a" value="b">fallback</object>ok`, "ok"},
+		{"embed", `<embed src="x.swf">ok`, "ok"},
+		{"form", `<form action="https://example.com/"><input name="q"></form>ok`, "ok"},
+		{"svg", `<svg><script>alert(1)</script></svg>ok`, "ok"},
+		{"base", `<base href="https://evil.example/">ok`, "ok"},
+		{"class and id", `<p class="x" id="y">hi</p>`, "<p>hi</p>"},
+
+		// Tracking pixels.
+		{"one pixel image", `<img src="https://t.example/p.gif" width="1" height="1">`, ""},
+		{"zero width image", `<img src="https://t.example/p.gif" width="0">`, ""},
+		{"pixel sized in px", `<img src="https://t.example/p.gif" height="1px">`, ""},
+		{"image without source", `<img alt="nothing">`, ""},
+		{"image with unsafe source", `<img src="javascript:alert(1)" alt="x">`, ""},
+		{"ordinary image", `<img src="https://example.com/a.png" alt="A" width="100">`, `<img src="https://example.com/a.png" alt="A" width="100"/>`},
+
+		// Relative URLs.
+		{"relative link", `<a href="other.html">x</a>`, `<a href="https://example.com/blog/other.html" rel="nofollow noopener noreferrer">x</a>`},
+		{"root relative image", `<img src="/img/a.png" alt="a">`, `<img src="https://example.com/img/a.png" alt="a"/>`},
+		{"protocol relative image", `<img src="//cdn.example.com/a.png" alt="a">`, `<img src="https://cdn.example.com/a.png" alt="a"/>`},
+		{"parent relative link", `<a href="../about">x</a>`, `<a href="https://example.com/about" rel="nofollow noopener noreferrer">x</a>`},
+		{"fragment link", `<a href="#note">x</a>`, `<a href="#note" rel="nofollow noopener noreferrer">x</a>`},
+	}
+	for _, tc := range tests {
+		if got := sanitizeHTML(tc.in, base); got != tc.want {
+			t.Errorf("%s: sanitizeHTML(%q)\n got %q\nwant %q", tc.name, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSanitizeHTMLWithoutBase(t *testing.T) {
+	// Relative URLs are kept as they are when there is nothing to resolve
+	// them against.
+	for _, base := range []string{"", "not/absolute"} {
+		got := sanitizeHTML(`<a href="other.html">x</a>`, base)
+		if want := `<a href="other.html" rel="nofollow noopener noreferrer">x</a>`; got != want {
+			t.Errorf("base %q: got %q, want %q", base, got, want)
+		}
+	}
+}
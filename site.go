@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+// `gator site build` renders a "planet" page: the posts from the feeds a
+// user follows, or those picked with --feed and --folder, as a static HTML
+// site with the newest posts grouped by day,
+// a page per feed, a blogroll and a combined Atom feed.  The templates are
+// embedded, and any of them can be replaced by a file of the same name in
+// the --templates directory.
+
+//go:embed site/templates
+var siteTemplates embed.FS
+
+// siteAssets are copied into the output directory as they are.
+var siteAssets = []string{"style.css"}
+
+type siteFeed struct {
+	Name string
+	URL  string
+	Page string
+}
+
+type siteDay struct {
+	Date  time.Time
+	Posts []postRecord
+}
+
+// sitePage is the data every site template receives.  Root is the relative
+// path back to the top of the site, so pages work from any directory.
+type sitePage struct {
+	Title     string
+	Root      string
+	BaseURL   string
+	Generated time.Time
+	Blogroll  []siteFeed
+	Feed      *siteFeed
+	Days      []siteDay
+}
+
+// overlayFS serves files from dir when it has them and from base otherwise.
+type overlayFS struct {
+	dir  fs.FS
+	base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if o.dir != nil {
+		f, err := o.dir.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return o.base.Open(name)
+}
+
+func siteFlags(fs *flag.FlagSet) {
+	fs.String("user", "", "build the site from this `user`'s feeds instead of your own (admins only)")
+	fs.Var(new(stringList), "feed", "include the feed with this `url`; may be repeated (default: every feed followed)")
+	fs.Var(new(stringList), "folder", "include the feeds filed in this `folder`; may be repeated")
+	fs.String("title", "Planet", "site `title`")
+	fs.String("base-url", "", "public `url` of the site, used in the Atom feed")
+	fs.Int("limit", 100, "number of posts on the front page and in the Atom feed")
+	fs.Int("per-feed", 20, "number of posts on each feed's page")
+	fs.String("templates", "", "`directory` of templates that replace the built-in ones")
+	fs.String("timezone", "UTC", "IANA time `zone` the site's dates and times are shown in, such as Europe/Berlin")
+}
+
+func handlerSite(s *state, cmd command, current database.User) error {
+	if len(cmd.args) != 2 || cmd.args[0] != "build" {
+		return cmd.usageError("Expected `site build <outdir>`.")
+	}
+	outDir := cmd.args[1]
+	zone, err := time.LoadLocation(cmd.flagString("timezone"))
+	if err != nil {
+		return cmd.usageError(fmt.Sprintf("Unknown time zone %q.", cmd.flagString("timezone")))
+	}
+	ctx := context.Background()
+	user := current
+	if name := cmd.flagString("user"); name != "" {
+		if user, err = userForCommand(ctx, s, current, name); err != nil {
+			return err
+		}
+	}
+	selected, err := siteSelection(ctx, s, user, cmd.flagStrings("feed"), cmd.flagStrings("folder"))
+	if err != nil {
+		return err
+	}
+
+	files := overlayFS{base: mustSub(siteTemplates, "site/templates")}
+	if dir := cmd.flagString("templates"); dir != "" {
+		files.dir = os.DirFS(dir)
+	}
+	pages := map[string]*template.Template{}
+	funcs := template.FuncMap{"formatTime": formatTime, "sanitize": sanitizedHTML}
+	for _, page := range []string{"index.html", "feed.html"} {
+		pages[page], err = template.New("layout.html").Funcs(funcs).ParseFS(files, "layout.html", page)
+		if err != nil {
+			return fmt.Errorf("Could not load templates: %v", err)
+		}
+	}
+
+	base := sitePage{
+		Title:     cmd.flagString("title"),
+		BaseURL:   strings.TrimSuffix(cmd.flagString("base-url"), "/"),
+		Generated: time.Now().In(zone),
+	}
+	feeds, err := s.db.GetFollowedFeedsWithUnread(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	used := map[string]bool{}
+	for _, feed := range feeds {
+		if selected != nil && !selected[feed.Url] {
+			continue
+		}
+		base.Blogroll = append(base.Blogroll, siteFeed{
+			Name: feed.Name,
+			URL:  feed.Url,
+			Page: "feeds/" + uniqueSlug(feed.Name, used) + ".html",
+		})
+	}
+	if err := os.MkdirAll(filepath.Join(outDir, "feeds"), 0o755); err != nil {
+		return err
+	}
+
+	front, err := siteTimeline(ctx, s, user, base.Blogroll, selected != nil, cmd.flagInt("limit"))
+	if err != nil {
+		return err
+	}
+	front.Title = base.Title
+	if base.BaseURL != "" {
+		front.Link = base.BaseURL + "/"
+		front.Self = base.BaseURL + "/atom.xml"
+	}
+	index := base
+	index.Days = groupByDay(front.Posts, zone)
+	if err := writeSiteFile(outDir, "index.html", func(w io.Writer) error {
+		return pages["index.html"].Execute(w, index)
+	}); err != nil {
+		return err
+	}
+	if err := writeSiteFile(outDir, "atom.xml", func(w io.Writer) error {
+		return writeTimeline(w, "atom", front)
+	}); err != nil {
+		return err
+	}
+
+	for i := range base.Blogroll {
+		feed := base.Blogroll[i]
+		rows, err := database.GetPostsForUser(ctx, s.db, database.GetPostsForUserParams{
+			UserID:  user.ID,
+			FeedUrl: optionalString(feed.URL),
+			Limit:   int32(cmd.flagInt("per-feed")),
+		})
+		if err != nil {
+			return err
+		}
+		posts := make([]postRecord, 0, len(rows))
+		for _, row := range rows {
+			posts = append(posts, newPostRecord(row))
+		}
+		page := base
+		page.Title = feed.Name + " · " + base.Title
+		page.Root = "../"
+		page.Feed = &feed
+		page.Days = groupByDay(posts, zone)
+		if err := writeSiteFile(outDir, feed.Page, func(w io.Writer) error {
+			return pages["feed.html"].Execute(w, page)
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, asset := range siteAssets {
+		if err := writeSiteFile(outDir, asset, func(w io.Writer) error {
+			f, err := files.Open(asset)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(w, f)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(s.out, "Built %s with %d posts from %d feeds\n", outDir, len(front.Posts), len(base.Blogroll))
+	return nil
+}
+
+// siteSelection returns the URLs of the feeds picked with --feed and
+// --folder, or nil when neither was given and the site has every feed the
+// user follows.
+func siteSelection(ctx context.Context, s *state, user database.User, feeds, folders []string) (map[string]bool, error) {
+	if len(feeds) == 0 && len(folders) == 0 {
+		return nil, nil
+	}
+	follows, err := s.db.GetFeedFollowsForUser(ctx, user.Name)
+	if err != nil {
+		return nil, err
+	}
+	selected := map[string]bool{}
+	for _, url := range feeds {
+		if !slices.ContainsFunc(follows, func(f database.GetFeedFollowsForUserRow) bool { return f.FeedUrl == url }) {
+			return nil, fmt.Errorf("%s does not follow %s", user.Name, url)
+		}
+		selected[url] = true
+	}
+	for _, folder := range folders {
+		found := false
+		for _, follow := range follows {
+			if slices.Contains(follow.Folders, folder) {
+				selected[follow.FeedUrl] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s has no feeds in the folder %s", user.Name, folder)
+		}
+	}
+	return selected, nil
+}
+
+// siteTimeline loads the posts for the front page and Atom feed.  When
+// feeds were picked, it merges the newest posts of each of them.
+func siteTimeline(ctx context.Context, s *state, user database.User, blogroll []siteFeed, picked bool, limit int) (timeline, error) {
+	if !picked {
+		return loadTimeline(ctx, s, user, postQuery{Limit: limit})
+	}
+	urls := make([]string, 0, len(blogroll))
+	for _, feed := range blogroll {
+		urls = append(urls, feed.URL)
+	}
+	slices.Sort(urls)
+	t := timeline{
+		// Stable across builds as long as the same feeds are picked.
+		ID:      "urn:uuid:" + uuid.NewSHA1(user.ID, []byte("site|"+strings.Join(urls, "|"))).String(),
+		Title:   user.Name + " on gator",
+		Updated: user.UpdatedAt,
+	}
+	for _, url := range urls {
+		feed, err := loadTimeline(ctx, s, user, postQuery{Limit: limit, Feed: url})
+		if err != nil {
+			return timeline{}, err
+		}
+		t.Posts = append(t.Posts, feed.Posts...)
+		if feed.Updated.After(t.Updated) {
+			t.Updated = feed.Updated
+		}
+	}
+	slices.SortStableFunc(t.Posts, func(a, b postRecord) int {
+		return b.PublishedAt.Compare(a.PublishedAt)
+	})
+	if len(t.Posts) > limit {
+		t.Posts = t.Posts[:limit]
+	}
+	return t, nil
+}
+
+// writeSiteFile writes one file of the site through a temporary file, so a
+// web server never sees a half-written page.
+func writeSiteFile(outDir, name string, write func(io.Writer) error) error {
+	path := filepath.Join(outDir, filepath.FromSlash(name))
+	f, err := os.CreateTemp(filepath.Dir(path), ".gator-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("Could not write %s: %v", name, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// groupByDay splits posts, which are already sorted newest first, by the
+// date they were published on in zone.  The posts' times are moved into zone
+// too, so the templates show them in the same zone as the days.
+func groupByDay(posts []postRecord, zone *time.Location) []siteDay {
+	var days []siteDay
+	for _, post := range posts {
+		post.PublishedAt = post.PublishedAt.In(zone)
+		date := time.Date(post.PublishedAt.Year(), post.PublishedAt.Month(), post.PublishedAt.Day(), 0, 0, 0, 0, zone)
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			days = append(days, siteDay{Date: date})
+		}
+		days[len(days)-1].Posts = append(days[len(days)-1].Posts, post)
+	}
+	return days
+}
+
+// uniqueSlug turns name into a file name, adding a number when another feed
+// already has the same one.
+func uniqueSlug(name string, used map[string]bool) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		slug = "feed"
+	}
+	candidate := slug
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-%d", slug, n)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,56 @@
+-- Seeds a migrated, empty Postgres database with a large timeline for
+-- measuring the timeline queries: 2,000 feeds of 1,000 posts each, a quarter
+-- of them followed by the user bench (password bench), who has read a third
+-- of their posts and starred a few.  See sql/bench/README.md.
+--
+--   psql "$DB_URL" -f sql/bench/seed.sql
+
+BEGIN;
+
+INSERT INTO users (id, created_at, updated_at, name, password_hash, role)
+VALUES (gen_random_uuid(), NOW(), NOW(), 'bench',
+  '$2a$10$JUQKCiNzjAgKoMn5mz8tyuL7QByPFUAsMtBzXd1N.PrWWgs20sU7y', 'admin');
+
+INSERT INTO feeds (id, created_at, updated_at, name, user_id, url)
+SELECT gen_random_uuid(), NOW(), NOW(), 'Feed ' || n,
+  (SELECT id FROM users WHERE name = 'bench'),
+  'https://bench.example/' || n || '/feed.xml'
+FROM generate_series(1, 2000) AS n;
+
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+SELECT gen_random_uuid(), NOW(), NOW(), users.id, feeds.id
+FROM users, feeds
+WHERE users.name = 'bench' AND feeds.seq % 4 = 0;
+
+-- Posts are spread a few minutes apart over the last few years; one in ten
+-- has no published date.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories,
+  effective_at, date_source)
+SELECT id, created_at, created_at, title, url, description, published_at, feed_id, author, categories,
+  COALESCE(published_at, created_at), CASE WHEN published_at IS NULL THEN 'fetched' ELSE 'published' END
+FROM (
+  SELECT gen_random_uuid() AS id,
+    NOW()::timestamp - (n * 2000 + feeds.seq) * interval '1 minute' AS created_at,
+    'Post ' || n || ' of ' || feeds.name AS title, feeds.url || '#' || n AS url,
+    'The description of post ' || n AS description,
+    CASE WHEN n % 10 <> 0 THEN NOW()::timestamp - (n * 2000 + feeds.seq) * interval '1 minute' + interval '1 hour' END
+      AS published_at,
+    feeds.id AS feed_id, 'Author ' || (n % 50) AS author, ARRAY['tag' || (n % 20)] AS categories
+  FROM feeds, generate_series(1, 1000) AS n
+) AS generated;
+
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, NOW()
+FROM feed_follows
+INNER JOIN posts ON posts.feed_id = feed_follows.feed_id
+WHERE posts.seq % 3 = 0;
+
+INSERT INTO post_stars (user_id, post_id, starred_at)
+SELECT feed_follows.user_id, posts.id, NOW()
+FROM feed_follows
+INNER JOIN posts ON posts.feed_id = feed_follows.feed_id
+WHERE posts.seq % 1000 = 0;
+
+COMMIT;
+
+ANALYZE;
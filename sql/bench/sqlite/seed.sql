@@ -0,0 +1,57 @@
+-- The SQLite version of sql/bench/seed.sql, with the same data.
+--
+--   sqlite3 /path/to/gator.db < sql/bench/sqlite/seed.sql
+
+BEGIN;
+
+-- SQLite has no UUID generator, so the UUIDs are numbered instead, with a
+-- different first group for each table.
+CREATE TEMP TABLE numbers (n integer PRIMARY KEY);
+WITH RECURSIVE counter(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM counter WHERE n < 2000)
+INSERT INTO numbers SELECT n FROM counter;
+
+INSERT INTO users (id, created_at, updated_at, name, password_hash, role)
+VALUES ('00000001-0000-4000-8000-000000000001', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'bench',
+  '$2a$10$JUQKCiNzjAgKoMn5mz8tyuL7QByPFUAsMtBzXd1N.PrWWgs20sU7y', 'admin');
+
+INSERT INTO feeds (id, created_at, updated_at, name, user_id, url)
+SELECT printf('00000002-0000-4000-8000-%012x', n), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'Feed ' || n,
+  (SELECT id FROM users WHERE name = 'bench'),
+  'https://bench.example/' || n || '/feed.xml'
+FROM numbers;
+
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+SELECT printf('00000003-0000-4000-8000-%012x', feeds.seq), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, users.id, feeds.id
+FROM users, feeds
+WHERE users.name = 'bench' AND feeds.seq % 4 = 0;
+
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories,
+  effective_at, date_source)
+SELECT id, created_at, created_at, title, url, description, published_at, feed_id, author, categories,
+  COALESCE(published_at, created_at), CASE WHEN published_at IS NULL THEN 'fetched' ELSE 'published' END
+FROM (
+  SELECT printf('00000004-0000-4000-8000-%012x', feeds.seq * 1000 + n) AS id,
+    datetime('now', '-' || (n * 2000 + feeds.seq) || ' minutes') AS created_at,
+    'Post ' || n || ' of ' || feeds.name AS title, feeds.url || '#' || n AS url,
+    'The description of post ' || n AS description,
+    CASE WHEN n % 10 <> 0 THEN datetime('now', '-' || (n * 2000 + feeds.seq - 60) || ' minutes') END AS published_at,
+    feeds.id AS feed_id, 'Author ' || (n % 50) AS author, json_array('tag' || (n % 20)) AS categories
+  FROM feeds, numbers
+  WHERE n <= 1000
+) AS generated;
+
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, CURRENT_TIMESTAMP
+FROM feed_follows
+INNER JOIN posts ON posts.feed_id = feed_follows.feed_id
+WHERE posts.seq % 3 = 0;
+
+INSERT INTO post_stars (user_id, post_id, starred_at)
+SELECT feed_follows.user_id, posts.id, CURRENT_TIMESTAMP
+FROM feed_follows
+INNER JOIN posts ON posts.feed_id = feed_follows.feed_id
+WHERE posts.seq % 1000 = 0;
+
+COMMIT;
+
+ANALYZE;
@@ -0,0 +1,422 @@
+-- The SQLite version of sql/bench/timeline.sql.  The parameters are bound,
+-- as gator's driver binds them, rather than written into the query.
+--
+--   sqlite3 /path/to/gator.db < sql/bench/sqlite/timeline.sql
+
+.timer on
+.mode list
+
+-- Newest first, the default timeline.
+.parameter init
+.parameter set ?1 "'00000001-0000-4000-8000-000000000001'"
+.parameter set ?2 "NULL"
+.parameter set ?3 "NULL"
+.parameter set ?4 "NULL"
+.parameter set ?5 "NULL"
+.parameter set ?6 "NULL"
+.parameter set ?7 "NULL"
+.parameter set ?8 "NULL"
+.parameter set ?9 "0"
+.parameter set ?10 "0"
+.parameter set ?11 "NULL"
+.parameter set ?12 "NULL"
+.parameter set ?13 "20"
+SELECT COUNT(*) FROM (
+SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred
+FROM (
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    CROSS JOIN feeds ON posts.feed_id = feeds.id
+    CROSS JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = ?1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = ?1
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = ?1
+    WHERE (?2 IS NULL OR feeds.url = ?2)
+    AND (?3 IS NULL OR julianday(posts.effective_at) >= julianday(?3))
+    AND (?4 IS NULL OR julianday(posts.effective_at) < julianday(?4))
+    AND (?5 IS NULL OR posts.author LIKE '%' || ?5 || '%')
+    AND (?6 IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = ?6))
+    AND (?7 IS NULL
+      OR posts.title LIKE '%' || ?7 || '%'
+      OR posts.description LIKE '%' || ?7 || '%')
+    AND (?8 IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = ?8))
+    AND (NOT ?9 OR post_reads.post_id IS NULL)
+    AND (NOT ?10 OR post_stars.post_id IS NOT NULL)
+    AND (?11 IS NULL
+      OR (julianday(posts.effective_at), posts.id) < (julianday(?11), ?12))
+    AND (?2 IS NULL AND ?4 IS NULL AND ?5 IS NULL
+      AND ?6 IS NULL AND ?7 IS NULL AND ?8 IS NULL
+      AND NOT ?9 AND NOT ?10)
+    ORDER BY julianday(posts.effective_at) DESC, posts.id DESC
+    LIMIT ?13
+  )
+  UNION ALL
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    INNER JOIN feeds ON posts.feed_id = feeds.id
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = ?1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = ?1
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = ?1
+    WHERE (?2 IS NULL OR feeds.url = ?2)
+    AND (?3 IS NULL OR julianday(posts.effective_at) >= julianday(?3))
+    AND (?4 IS NULL OR julianday(posts.effective_at) < julianday(?4))
+    AND (?5 IS NULL OR posts.author LIKE '%' || ?5 || '%')
+    AND (?6 IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = ?6))
+    AND (?7 IS NULL
+      OR posts.title LIKE '%' || ?7 || '%'
+      OR posts.description LIKE '%' || ?7 || '%')
+    AND (?8 IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = ?8))
+    AND (NOT ?9 OR post_reads.post_id IS NULL)
+    AND (NOT ?10 OR post_stars.post_id IS NOT NULL)
+    AND (?11 IS NULL
+      OR (julianday(posts.effective_at), posts.id) < (julianday(?11), ?12))
+    AND NOT (?2 IS NULL AND ?4 IS NULL AND ?5 IS NULL
+      AND ?6 IS NULL AND ?7 IS NULL AND ?8 IS NULL
+      AND NOT ?9 AND NOT ?10)
+    ORDER BY julianday(posts.effective_at) DESC, posts.id DESC
+    LIMIT ?13
+  )
+)
+ORDER BY julianday(sort_date) DESC, id DESC
+);
+
+-- Oldest first.
+.parameter init
+.parameter set ?1 "'00000001-0000-4000-8000-000000000001'"
+.parameter set ?2 "NULL"
+.parameter set ?3 "NULL"
+.parameter set ?4 "NULL"
+.parameter set ?5 "NULL"
+.parameter set ?6 "NULL"
+.parameter set ?7 "NULL"
+.parameter set ?8 "NULL"
+.parameter set ?9 "0"
+.parameter set ?10 "0"
+.parameter set ?11 "NULL"
+.parameter set ?12 "NULL"
+.parameter set ?13 "20"
+SELECT COUNT(*) FROM (
+SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred
+FROM (
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    CROSS JOIN feeds ON posts.feed_id = feeds.id
+    CROSS JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = ?1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = ?1
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = ?1
+    WHERE (?2 IS NULL OR feeds.url = ?2)
+    AND (?3 IS NULL OR julianday(posts.effective_at) >= julianday(?3))
+    AND (?4 IS NULL OR julianday(posts.effective_at) < julianday(?4))
+    AND (?5 IS NULL OR posts.author LIKE '%' || ?5 || '%')
+    AND (?6 IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = ?6))
+    AND (?7 IS NULL
+      OR posts.title LIKE '%' || ?7 || '%'
+      OR posts.description LIKE '%' || ?7 || '%')
+    AND (?8 IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = ?8))
+    AND (NOT ?9 OR post_reads.post_id IS NULL)
+    AND (NOT ?10 OR post_stars.post_id IS NOT NULL)
+    AND (?11 IS NULL
+      OR (julianday(posts.effective_at), posts.id) > (julianday(?11), ?12))
+    AND (?2 IS NULL AND ?4 IS NULL AND ?5 IS NULL
+      AND ?6 IS NULL AND ?7 IS NULL AND ?8 IS NULL
+      AND NOT ?9 AND NOT ?10)
+    ORDER BY julianday(posts.effective_at) ASC, posts.id ASC
+    LIMIT ?13
+  )
+  UNION ALL
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    INNER JOIN feeds ON posts.feed_id = feeds.id
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = ?1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = ?1
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = ?1
+    WHERE (?2 IS NULL OR feeds.url = ?2)
+    AND (?3 IS NULL OR julianday(posts.effective_at) >= julianday(?3))
+    AND (?4 IS NULL OR julianday(posts.effective_at) < julianday(?4))
+    AND (?5 IS NULL OR posts.author LIKE '%' || ?5 || '%')
+    AND (?6 IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = ?6))
+    AND (?7 IS NULL
+      OR posts.title LIKE '%' || ?7 || '%'
+      OR posts.description LIKE '%' || ?7 || '%')
+    AND (?8 IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = ?8))
+    AND (NOT ?9 OR post_reads.post_id IS NULL)
+    AND (NOT ?10 OR post_stars.post_id IS NOT NULL)
+    AND (?11 IS NULL
+      OR (julianday(posts.effective_at), posts.id) > (julianday(?11), ?12))
+    AND NOT (?2 IS NULL AND ?4 IS NULL AND ?5 IS NULL
+      AND ?6 IS NULL AND ?7 IS NULL AND ?8 IS NULL
+      AND NOT ?9 AND NOT ?10)
+    ORDER BY julianday(posts.effective_at) ASC, posts.id ASC
+    LIMIT ?13
+  )
+)
+ORDER BY julianday(sort_date) ASC, id ASC
+);
+
+-- One feed.
+.parameter init
+.parameter set ?1 "'00000001-0000-4000-8000-000000000001'"
+.parameter set ?2 "'https://bench.example/8/feed.xml'"
+.parameter set ?3 "NULL"
+.parameter set ?4 "NULL"
+.parameter set ?5 "NULL"
+.parameter set ?6 "NULL"
+.parameter set ?7 "NULL"
+.parameter set ?8 "NULL"
+.parameter set ?9 "0"
+.parameter set ?10 "0"
+.parameter set ?11 "NULL"
+.parameter set ?12 "NULL"
+.parameter set ?13 "20"
+SELECT COUNT(*) FROM (
+SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred
+FROM (
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    CROSS JOIN feeds ON posts.feed_id = feeds.id
+    CROSS JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = ?1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = ?1
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = ?1
+    WHERE (?2 IS NULL OR feeds.url = ?2)
+    AND (?3 IS NULL OR julianday(posts.effective_at) >= julianday(?3))
+    AND (?4 IS NULL OR julianday(posts.effective_at) < julianday(?4))
+    AND (?5 IS NULL OR posts.author LIKE '%' || ?5 || '%')
+    AND (?6 IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = ?6))
+    AND (?7 IS NULL
+      OR posts.title LIKE '%' || ?7 || '%'
+      OR posts.description LIKE '%' || ?7 || '%')
+    AND (?8 IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = ?8))
+    AND (NOT ?9 OR post_reads.post_id IS NULL)
+    AND (NOT ?10 OR post_stars.post_id IS NOT NULL)
+    AND (?11 IS NULL
+      OR (julianday(posts.effective_at), posts.id) < (julianday(?11), ?12))
+    AND (?2 IS NULL AND ?4 IS NULL AND ?5 IS NULL
+      AND ?6 IS NULL AND ?7 IS NULL AND ?8 IS NULL
+      AND NOT ?9 AND NOT ?10)
+    ORDER BY julianday(posts.effective_at) DESC, posts.id DESC
+    LIMIT ?13
+  )
+  UNION ALL
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    INNER JOIN feeds ON posts.feed_id = feeds.id
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = ?1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = ?1
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = ?1
+    WHERE (?2 IS NULL OR feeds.url = ?2)
+    AND (?3 IS NULL OR julianday(posts.effective_at) >= julianday(?3))
+    AND (?4 IS NULL OR julianday(posts.effective_at) < julianday(?4))
+    AND (?5 IS NULL OR posts.author LIKE '%' || ?5 || '%')
+    AND (?6 IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = ?6))
+    AND (?7 IS NULL
+      OR posts.title LIKE '%' || ?7 || '%'
+      OR posts.description LIKE '%' || ?7 || '%')
+    AND (?8 IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = ?8))
+    AND (NOT ?9 OR post_reads.post_id IS NULL)
+    AND (NOT ?10 OR post_stars.post_id IS NOT NULL)
+    AND (?11 IS NULL
+      OR (julianday(posts.effective_at), posts.id) < (julianday(?11), ?12))
+    AND NOT (?2 IS NULL AND ?4 IS NULL AND ?5 IS NULL
+      AND ?6 IS NULL AND ?7 IS NULL AND ?8 IS NULL
+      AND NOT ?9 AND NOT ?10)
+    ORDER BY julianday(posts.effective_at) DESC, posts.id DESC
+    LIMIT ?13
+  )
+)
+ORDER BY julianday(sort_date) DESC, id DESC
+);
+
+-- The next page of unread posts.
+.parameter init
+.parameter set ?1 "'00000001-0000-4000-8000-000000000001'"
+.parameter set ?2 "NULL"
+.parameter set ?3 "NULL"
+.parameter set ?4 "NULL"
+.parameter set ?5 "NULL"
+.parameter set ?6 "NULL"
+.parameter set ?7 "NULL"
+.parameter set ?8 "NULL"
+.parameter set ?9 "1"
+.parameter set ?10 "0"
+.parameter set ?11 "datetime('now', '-30 days')"
+.parameter set ?12 "'ffffffff-ffff-ffff-ffff-ffffffffffff'"
+.parameter set ?13 "20"
+SELECT COUNT(*) FROM (
+SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred
+FROM (
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    CROSS JOIN feeds ON posts.feed_id = feeds.id
+    CROSS JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = ?1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = ?1
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = ?1
+    WHERE (?2 IS NULL OR feeds.url = ?2)
+    AND (?3 IS NULL OR julianday(posts.effective_at) >= julianday(?3))
+    AND (?4 IS NULL OR julianday(posts.effective_at) < julianday(?4))
+    AND (?5 IS NULL OR posts.author LIKE '%' || ?5 || '%')
+    AND (?6 IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = ?6))
+    AND (?7 IS NULL
+      OR posts.title LIKE '%' || ?7 || '%'
+      OR posts.description LIKE '%' || ?7 || '%')
+    AND (?8 IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = ?8))
+    AND (NOT ?9 OR post_reads.post_id IS NULL)
+    AND (NOT ?10 OR post_stars.post_id IS NOT NULL)
+    AND (?11 IS NULL
+      OR (julianday(posts.effective_at), posts.id) < (julianday(?11), ?12))
+    AND (?2 IS NULL AND ?4 IS NULL AND ?5 IS NULL
+      AND ?6 IS NULL AND ?7 IS NULL AND ?8 IS NULL
+      AND NOT ?9 AND NOT ?10)
+    ORDER BY julianday(posts.effective_at) DESC, posts.id DESC
+    LIMIT ?13
+  )
+  UNION ALL
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    INNER JOIN feeds ON posts.feed_id = feeds.id
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = ?1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = ?1
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = ?1
+    WHERE (?2 IS NULL OR feeds.url = ?2)
+    AND (?3 IS NULL OR julianday(posts.effective_at) >= julianday(?3))
+    AND (?4 IS NULL OR julianday(posts.effective_at) < julianday(?4))
+    AND (?5 IS NULL OR posts.author LIKE '%' || ?5 || '%')
+    AND (?6 IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = ?6))
+    AND (?7 IS NULL
+      OR posts.title LIKE '%' || ?7 || '%'
+      OR posts.description LIKE '%' || ?7 || '%')
+    AND (?8 IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = ?8))
+    AND (NOT ?9 OR post_reads.post_id IS NULL)
+    AND (NOT ?10 OR post_stars.post_id IS NOT NULL)
+    AND (?11 IS NULL
+      OR (julianday(posts.effective_at), posts.id) < (julianday(?11), ?12))
+    AND NOT (?2 IS NULL AND ?4 IS NULL AND ?5 IS NULL
+      AND ?6 IS NULL AND ?7 IS NULL AND ?8 IS NULL
+      AND NOT ?9 AND NOT ?10)
+    ORDER BY julianday(posts.effective_at) DESC, posts.id DESC
+    LIMIT ?13
+  )
+)
+ORDER BY julianday(sort_date) DESC, id DESC
+);
+
+-- Starred posts.
+.parameter init
+.parameter set ?1 "'00000001-0000-4000-8000-000000000001'"
+.parameter set ?2 "NULL"
+.parameter set ?3 "NULL"
+.parameter set ?4 "NULL"
+.parameter set ?5 "NULL"
+.parameter set ?6 "NULL"
+.parameter set ?7 "NULL"
+.parameter set ?8 "NULL"
+.parameter set ?9 "0"
+.parameter set ?10 "1"
+.parameter set ?11 "NULL"
+.parameter set ?12 "NULL"
+.parameter set ?13 "20"
+SELECT COUNT(*) FROM (
+SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred
+FROM (
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    CROSS JOIN feeds ON posts.feed_id = feeds.id
+    CROSS JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = ?1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = ?1
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = ?1
+    WHERE (?2 IS NULL OR feeds.url = ?2)
+    AND (?3 IS NULL OR julianday(posts.effective_at) >= julianday(?3))
+    AND (?4 IS NULL OR julianday(posts.effective_at) < julianday(?4))
+    AND (?5 IS NULL OR posts.author LIKE '%' || ?5 || '%')
+    AND (?6 IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = ?6))
+    AND (?7 IS NULL
+      OR posts.title LIKE '%' || ?7 || '%'
+      OR posts.description LIKE '%' || ?7 || '%')
+    AND (?8 IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = ?8))
+    AND (NOT ?9 OR post_reads.post_id IS NULL)
+    AND (NOT ?10 OR post_stars.post_id IS NOT NULL)
+    AND (?11 IS NULL
+      OR (julianday(posts.effective_at), posts.id) < (julianday(?11), ?12))
+    AND (?2 IS NULL AND ?4 IS NULL AND ?5 IS NULL
+      AND ?6 IS NULL AND ?7 IS NULL AND ?8 IS NULL
+      AND NOT ?9 AND NOT ?10)
+    ORDER BY julianday(posts.effective_at) DESC, posts.id DESC
+    LIMIT ?13
+  )
+  UNION ALL
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    INNER JOIN feeds ON posts.feed_id = feeds.id
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = ?1
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = ?1
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = ?1
+    WHERE (?2 IS NULL OR feeds.url = ?2)
+    AND (?3 IS NULL OR julianday(posts.effective_at) >= julianday(?3))
+    AND (?4 IS NULL OR julianday(posts.effective_at) < julianday(?4))
+    AND (?5 IS NULL OR posts.author LIKE '%' || ?5 || '%')
+    AND (?6 IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = ?6))
+    AND (?7 IS NULL
+      OR posts.title LIKE '%' || ?7 || '%'
+      OR posts.description LIKE '%' || ?7 || '%')
+    AND (?8 IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = ?8))
+    AND (NOT ?9 OR post_reads.post_id IS NULL)
+    AND (NOT ?10 OR post_stars.post_id IS NOT NULL)
+    AND (?11 IS NULL
+      OR (julianday(posts.effective_at), posts.id) < (julianday(?11), ?12))
+    AND NOT (?2 IS NULL AND ?4 IS NULL AND ?5 IS NULL
+      AND ?6 IS NULL AND ?7 IS NULL AND ?8 IS NULL
+      AND NOT ?9 AND NOT ?10)
+    ORDER BY julianday(posts.effective_at) DESC, posts.id DESC
+    LIMIT ?13
+  )
+)
+ORDER BY julianday(sort_date) DESC, id DESC
+);
@@ -0,0 +1,81 @@
+-- Times the timeline queries, GetNewestPostsForUser and
+-- GetOldestPostsForUser, on the data sql/bench/seed.sql loads.  The
+-- statements are prepared as gator's driver prepares them, so they are
+-- planned with the actual parameter values.
+--
+--   psql "$DB_URL" -f sql/bench/timeline.sql
+
+SELECT id AS bench_id FROM users WHERE name = 'bench' \gset
+
+-- The queries from sql/queries/posts.sql.
+PREPARE newest (uuid, text, timestamp, timestamp, text, text, text, text, boolean, boolean, timestamp, uuid, integer) AS
+SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+  posts.effective_at AS sort_date, posts.date_source,
+  COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+  (post_reads.post_id IS NOT NULL)::boolean AS read,
+  (post_stars.post_id IS NOT NULL)::boolean AS starred
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = $1
+LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = $1
+WHERE ($2::text IS NULL OR feeds.url = $2)
+AND ($3::timestamp IS NULL OR posts.effective_at >= $3)
+AND ($4::timestamp IS NULL OR posts.effective_at < $4)
+AND ($5::text IS NULL OR posts.author ILIKE '%' || $5 || '%')
+AND ($6::text IS NULL OR $6 = ANY(posts.categories))
+AND ($7::text IS NULL
+  OR posts.title ILIKE '%' || $7 || '%'
+  OR posts.description ILIKE '%' || $7 || '%')
+AND ($8::text IS NULL OR $8 = ANY(feed_follows.folders))
+AND (NOT $9::boolean OR post_reads.post_id IS NULL)
+AND (NOT $10::boolean OR post_stars.post_id IS NOT NULL)
+AND ($11::timestamp IS NULL
+  OR (posts.effective_at, posts.id) < ($11, $12::uuid))
+ORDER BY posts.effective_at DESC, posts.id DESC
+LIMIT $13;
+
+PREPARE oldest (uuid, text, timestamp, timestamp, text, text, text, text, boolean, boolean, timestamp, uuid, integer) AS
+SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+  posts.effective_at AS sort_date, posts.date_source,
+  COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+  (post_reads.post_id IS NOT NULL)::boolean AS read,
+  (post_stars.post_id IS NOT NULL)::boolean AS starred
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = $1
+LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = $1
+WHERE ($2::text IS NULL OR feeds.url = $2)
+AND ($3::timestamp IS NULL OR posts.effective_at >= $3)
+AND ($4::timestamp IS NULL OR posts.effective_at < $4)
+AND ($5::text IS NULL OR posts.author ILIKE '%' || $5 || '%')
+AND ($6::text IS NULL OR $6 = ANY(posts.categories))
+AND ($7::text IS NULL
+  OR posts.title ILIKE '%' || $7 || '%'
+  OR posts.description ILIKE '%' || $7 || '%')
+AND ($8::text IS NULL OR $8 = ANY(feed_follows.folders))
+AND (NOT $9::boolean OR post_reads.post_id IS NULL)
+AND (NOT $10::boolean OR post_stars.post_id IS NOT NULL)
+AND ($11::timestamp IS NULL
+  OR (posts.effective_at, posts.id) > ($11, $12::uuid))
+ORDER BY posts.effective_at ASC, posts.id ASC
+LIMIT $13;
+
+-- Newest first, the default timeline.
+EXPLAIN (ANALYZE, BUFFERS) EXECUTE newest(:'bench_id', NULL, NULL, NULL, NULL, NULL, NULL, NULL, false, false, NULL, NULL, 20);
+
+-- Oldest first.
+EXPLAIN (ANALYZE, BUFFERS) EXECUTE oldest(:'bench_id', NULL, NULL, NULL, NULL, NULL, NULL, NULL, false, false, NULL, NULL, 20);
+
+-- One feed.
+EXPLAIN (ANALYZE, BUFFERS) EXECUTE newest(:'bench_id', 'https://bench.example/8/feed.xml', NULL, NULL, NULL, NULL, NULL, NULL, false, false, NULL, NULL, 20);
+
+-- The next page of unread posts.
+EXPLAIN (ANALYZE, BUFFERS) EXECUTE newest(:'bench_id', NULL, NULL, NULL, NULL, NULL, NULL, NULL, true, false, NOW()::timestamp - interval '30 days', 'ffffffff-ffff-ffff-ffff-ffffffffffff', 20);
+
+-- Starred posts.
+EXPLAIN (ANALYZE, BUFFERS) EXECUTE newest(:'bench_id', NULL, NULL, NULL, NULL, NULL, NULL, NULL, false, true, NULL, NULL, 20);
+
+DEALLOCATE newest;
+DEALLOCATE oldest;
@@ -18,10 +18,13 @@ WITH inserted_feed_follow AS (
   INNER JOIN feeds ON feed_id = feeds.id;
 
 -- name: GetFeedFollowsForUser :many
-SELECT users.name AS user_name, feeds.name AS feed_name FROM feed_follows
+SELECT users.name AS user_name, feeds.name AS feed_name, feeds.url AS feed_url, feed_follows.created_at,
+  feed_follows.alias, feed_follows.folders
+FROM feed_follows
 INNER JOIN users ON user_id = users.id
 INNER JOIN feeds ON feed_id = feeds.id
-WHERE users.name = $1;
+WHERE users.name = $1
+ORDER BY COALESCE(feed_follows.alias, feeds.name);
 
 -- name: DeleteFeedFollow :exec
 DELETE FROM feed_follows
@@ -32,3 +35,36 @@ SELECT feed_follows.id FROM feed_follows
   WHERE users.name = $1
   AND feeds.url = $2
 );
+
+-- name: GetFollowedFeedsWithUnread :many
+SELECT feeds.id, COALESCE(feed_follows.alias, feeds.name) AS name, feeds.url,
+  COUNT(posts.id) FILTER (WHERE post_reads.post_id IS NULL) AS unread
+FROM feed_follows
+INNER JOIN feeds ON feed_follows.feed_id = feeds.id
+LEFT JOIN posts ON posts.feed_id = feeds.id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+GROUP BY feeds.id, feed_follows.id
+ORDER BY name;
+
+-- name: SetFollowAlias :execrows
+UPDATE feed_follows
+SET alias = sqlc.narg('alias'), updated_at = NOW()
+FROM feeds
+WHERE feed_follows.feed_id = feeds.id
+AND feed_follows.user_id = sqlc.arg('user_id')
+AND feeds.url = sqlc.arg('url');
+
+-- name: SetFollowFolders :execrows
+UPDATE feed_follows
+SET folders = sqlc.arg('folders'), updated_at = NOW()
+FROM feeds
+WHERE feed_follows.feed_id = feeds.id
+AND feed_follows.user_id = sqlc.arg('user_id')
+AND feeds.url = sqlc.arg('url');
+
+-- name: GetFollowFolders :many
+SELECT DISTINCT unnest(folders)::text AS folder
+FROM feed_follows
+WHERE user_id = $1
+ORDER BY folder;
@@ -11,7 +11,7 @@ VALUES(
   RETURNING *;
 
 -- name: GetAllFeeds :many
-SELECT feeds.name, feeds.url, users.name FROM feeds
+SELECT feeds.name, feeds.url, users.name AS owner FROM feeds
 INNER JOIN users ON user_id = users.id;
 
 -- name: GetFeedByUrl :one
@@ -19,10 +19,51 @@ SELECT * FROM feeds WHERE url = $1;
 
 -- name: MarkFeedFetched :exec
 UPDATE feeds
-SET updated_at = NOW()
+SET last_fetched_at = NOW(), updated_at = NOW(), last_fetch_error = $2
 WHERE id = $1;
 
 -- name: GetNextFeedToFetch :one
 SELECT id, url FROM feeds
-ORDER BY updated_at ASC NULLS FIRST
+ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT 1;
+
+-- name: GetFeedDetails :one
+SELECT feeds.*, users.name AS owner,
+  (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id) AS followers,
+  (SELECT COUNT(*) FROM posts WHERE posts.feed_id = feeds.id) AS posts,
+  (SELECT COUNT(*) FROM feeds AS queued
+    WHERE queued.id <> feeds.id
+    AND (queued.last_fetched_at IS NULL OR queued.last_fetched_at < feeds.last_fetched_at)) AS queued_ahead
+FROM feeds
+INNER JOIN users ON feeds.user_id = users.id
+WHERE feeds.url = $1;
+
+-- name: RenameFeed :exec
+UPDATE feeds
+SET name = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetFeedUrl :exec
+UPDATE feeds
+SET url = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1;
+
+-- name: GetFeedsOwnedBy :many
+SELECT feeds.name, feeds.url,
+  (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id) AS followers
+FROM feeds
+WHERE feeds.user_id = $1
+ORDER BY feeds.name;
+
+-- name: TransferFeeds :execrows
+UPDATE feeds
+SET user_id = sqlc.arg('to_user_id'), updated_at = NOW()
+WHERE user_id = sqlc.arg('from_user_id');
+
+-- name: SetFeedRetention :exec
+UPDATE feeds
+SET keep_days = $2, keep_posts = $3, updated_at = NOW()
+WHERE id = $1;
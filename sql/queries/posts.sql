@@ -1,5 +1,5 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories, effective_at, date_source)
 VALUES(
   $1,
   $2,
@@ -8,18 +8,156 @@ VALUES(
   $5,
   $6,
   $7,
-  $8
+  $8,
+  $9,
+  $10,
+  $11,
+  $12
   )
   RETURNING *;
 
--- name: GetPostsForUser :many
-SELECT posts.title, posts.description, posts.url FROM posts
-WHERE posts.feed_id IN (
-  SELECT feed_follows.feed_id FROM feed_follows 
-  INNER JOIN users ON user_id = users.id
-  INNER JOIN feeds ON feed_id = feeds.id
-  WHERE users.name = $1
+-- name: CreatePosts :execrows
+-- CreatePosts stores a batch of a feed's items in one statement.  items is a
+-- JSON array of database.NewPost.  Items whose URL is already stored are
+-- skipped, so the row count is the number of new posts.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories,
+  effective_at, date_source)
+SELECT item.id, sqlc.arg('created_at')::timestamp, sqlc.arg('created_at')::timestamp, item.title, item.url,
+  item.description, item.published_at, sqlc.arg('feed_id')::uuid, item.author, COALESCE(item.categories, '{}'),
+  item.effective_at, item.date_source
+FROM jsonb_to_recordset(sqlc.arg('items')::jsonb)
+  AS item(id uuid, title text, url text, description text, published_at timestamp, author text, categories text[],
+    effective_at timestamp, date_source text)
+ON CONFLICT DO NOTHING;
+
+-- name: GetNewestPostsForUser :many
+-- GetNewestPostsForUser reads a page of a user's timeline, newest first,
+-- starting after the post at after_date and after_id if they are given.  It
+-- and GetOldestPostsForUser differ only in direction, so that each sorts by
+-- the columns of posts_sort_idx and posts_feed_sort_idx and can read a page
+-- from one of them in order.
+SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+  posts.effective_at AS sort_date, posts.date_source,
+  COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+  (post_reads.post_id IS NOT NULL)::boolean AS read,
+  (post_stars.post_id IS NOT NULL)::boolean AS starred
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg('user_id')
+LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg('user_id')
+WHERE (sqlc.narg('feed_url')::text IS NULL OR feeds.url = sqlc.narg('feed_url'))
+AND (sqlc.narg('since')::timestamp IS NULL OR posts.effective_at >= sqlc.narg('since'))
+AND (sqlc.narg('until')::timestamp IS NULL OR posts.effective_at < sqlc.narg('until'))
+AND (sqlc.narg('author')::text IS NULL OR posts.author ILIKE '%' || sqlc.narg('author') || '%')
+AND (sqlc.narg('tag')::text IS NULL OR sqlc.narg('tag') = ANY(posts.categories))
+AND (sqlc.narg('search')::text IS NULL
+  OR posts.title ILIKE '%' || sqlc.narg('search') || '%'
+  OR posts.description ILIKE '%' || sqlc.narg('search') || '%')
+AND (sqlc.narg('folder')::text IS NULL OR sqlc.narg('folder') = ANY(feed_follows.folders))
+AND (NOT sqlc.arg('unread_only')::boolean OR post_reads.post_id IS NULL)
+AND (NOT sqlc.arg('starred_only')::boolean OR post_stars.post_id IS NOT NULL)
+AND (sqlc.narg('after_date')::timestamp IS NULL
+  OR (posts.effective_at, posts.id) < (sqlc.narg('after_date'), sqlc.narg('after_id')::uuid))
+ORDER BY posts.effective_at DESC, posts.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetOldestPostsForUser :many
+-- GetOldestPostsForUser is GetNewestPostsForUser, oldest first.
+SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+  posts.effective_at AS sort_date, posts.date_source,
+  COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+  (post_reads.post_id IS NOT NULL)::boolean AS read,
+  (post_stars.post_id IS NOT NULL)::boolean AS starred
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg('user_id')
+LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg('user_id')
+WHERE (sqlc.narg('feed_url')::text IS NULL OR feeds.url = sqlc.narg('feed_url'))
+AND (sqlc.narg('since')::timestamp IS NULL OR posts.effective_at >= sqlc.narg('since'))
+AND (sqlc.narg('until')::timestamp IS NULL OR posts.effective_at < sqlc.narg('until'))
+AND (sqlc.narg('author')::text IS NULL OR posts.author ILIKE '%' || sqlc.narg('author') || '%')
+AND (sqlc.narg('tag')::text IS NULL OR sqlc.narg('tag') = ANY(posts.categories))
+AND (sqlc.narg('search')::text IS NULL
+  OR posts.title ILIKE '%' || sqlc.narg('search') || '%'
+  OR posts.description ILIKE '%' || sqlc.narg('search') || '%')
+AND (sqlc.narg('folder')::text IS NULL OR sqlc.narg('folder') = ANY(feed_follows.folders))
+AND (NOT sqlc.arg('unread_only')::boolean OR post_reads.post_id IS NULL)
+AND (NOT sqlc.arg('starred_only')::boolean OR post_stars.post_id IS NOT NULL)
+AND (sqlc.narg('after_date')::timestamp IS NULL
+  OR (posts.effective_at, posts.id) > (sqlc.narg('after_date'), sqlc.narg('after_id')::uuid))
+ORDER BY posts.effective_at ASC, posts.id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: FollowsPost :one
+-- FollowsPost reports whether the user follows the feed a post is in, which
+-- they must to mark it read or star it.
+SELECT EXISTS (
+  SELECT 1 FROM posts
+  INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+  WHERE posts.id = $2 AND feed_follows.user_id = $1
+);
+
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostUnread :exec
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: StarPost :exec
+INSERT INTO post_stars (user_id, post_id, starred_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: UnstarPost :exec
+DELETE FROM post_stars
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: CountPrunablePosts :many
+-- CountPrunablePosts counts, for each feed, the posts PrunePosts would
+-- delete: those stored more than keep_days ago, and those past the
+-- keep_posts newest of their feed.  keep_days runs from created_at, when
+-- gator stored the post, rather than from its own date: a post still in its
+-- feed that was deleted for being old would be stored again, as unread, on
+-- the next fetch.  A feed's own limits override the given ones, and a limit
+-- of 0 keeps posts forever.  Starred posts are never pruned.
+SELECT feeds.name, feeds.url, COUNT(*) AS posts
+FROM (
+  SELECT posts.id, posts.feed_id, posts.created_at,
+    ROW_NUMBER() OVER (PARTITION BY posts.feed_id
+      ORDER BY posts.effective_at DESC, posts.seq DESC) AS newness
+  FROM posts
+) AS ranked
+INNER JOIN feeds ON ranked.feed_id = feeds.id
+WHERE (
+  ranked.created_at < sqlc.arg('now')::timestamp
+    - make_interval(days => NULLIF(COALESCE(feeds.keep_days, sqlc.arg('keep_days')::integer), 0))
+  OR ranked.newness > NULLIF(COALESCE(feeds.keep_posts, sqlc.arg('keep_posts')::integer), 0)
 )
-ORDER BY published_at DESC
-LIMIT $2;
+AND NOT EXISTS (SELECT 1 FROM post_stars WHERE post_stars.post_id = ranked.id)
+GROUP BY feeds.id
+ORDER BY feeds.name;
 
+-- name: PrunePosts :execrows
+-- PrunePosts deletes the posts CountPrunablePosts counts.
+DELETE FROM posts
+WHERE posts.id IN (
+  SELECT ranked.id
+  FROM (
+    SELECT posts.id, posts.feed_id, posts.created_at,
+      ROW_NUMBER() OVER (PARTITION BY posts.feed_id
+        ORDER BY posts.effective_at DESC, posts.seq DESC) AS newness
+    FROM posts
+  ) AS ranked
+  INNER JOIN feeds ON ranked.feed_id = feeds.id
+  WHERE (
+    ranked.created_at < sqlc.arg('now')::timestamp
+      - make_interval(days => NULLIF(COALESCE(feeds.keep_days, sqlc.arg('keep_days')::integer), 0))
+    OR ranked.newness > NULLIF(COALESCE(feeds.keep_posts, sqlc.arg('keep_posts')::integer), 0)
+  )
+  AND NOT EXISTS (SELECT 1 FROM post_stars WHERE post_stars.post_id = ranked.id)
+);
@@ -1,10 +1,12 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, password_hash, role)
 VALUES(
   $1,
   $2,
   $3,
-  $4
+  $4,
+  $5,
+  $6
 )
 RETURNING *;
 
@@ -16,3 +18,48 @@ DELETE FROM users *;
 
 -- name: GetAllUsers :many
 SELECT * FROM users;
+
+-- name: GetUserByAPIKey :one
+SELECT * FROM users WHERE api_key_hash = $1;
+
+-- name: SetAPIKey :exec
+-- SetAPIKey replaces the user's API key, and the Fever key made from it.
+UPDATE users
+SET api_key_hash = $2, fever_key_hash = $3, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetPassword :exec
+UPDATE users
+SET password_hash = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserRole :exec
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: CountAdmins :one
+-- CountAdmins counts the admins who can log in, which those without a
+-- password cannot.
+SELECT COUNT(*) FROM users WHERE role = 'admin' AND password_hash IS NOT NULL;
+
+-- name: RenameUser :exec
+-- The Fever key is made from the name, so renaming a user voids it.
+UPDATE users
+SET name = $2, fever_key_hash = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1;
+
+-- name: GetUserStats :one
+SELECT
+  (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = users.id) AS follows,
+  (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = users.id) AS feeds_owned,
+  (SELECT COUNT(*) FROM posts
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = users.id
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = users.id
+    WHERE post_reads.post_id IS NULL) AS unread,
+  (SELECT COUNT(*) FROM post_stars WHERE post_stars.user_id = users.id) AS starred
+FROM users
+WHERE users.id = $1;
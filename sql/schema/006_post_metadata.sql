@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE posts
+ADD author text,
+ADD categories text[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN author,
+DROP COLUMN categories;
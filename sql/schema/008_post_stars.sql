@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE post_stars (
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  post_id uuid NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  starred_at timestamp NOT NULL,
+  PRIMARY KEY(user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE post_stars;
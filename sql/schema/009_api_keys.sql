@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+ADD api_key text NOT NULL UNIQUE DEFAULT encode(sha256(gen_random_uuid()::text::bytea), 'hex');
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN api_key;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD seq bigserial UNIQUE;
+
+ALTER TABLE posts
+ADD seq bigserial UNIQUE;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN seq;
+
+ALTER TABLE feeds
+DROP COLUMN seq;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE users
+ADD password_hash text;
+
+CREATE TABLE sessions (
+  token_hash text PRIMARY KEY,
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL
+);
+
+-- +goose Down
+DROP TABLE sessions;
+
+ALTER TABLE users
+DROP COLUMN password_hash;
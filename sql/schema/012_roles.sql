@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE users
+ADD role text NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member'));
+
+-- Nobody is made an admin here: the existing users have no passwords yet,
+-- so anyone could log in as them.  The first admin is registered with
+-- `gator register --admin`.
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN role;
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD last_fetch_error text;
+
+-- last_fetched_at was never filled in; the fetch queue used updated_at, which
+-- is the best guess at when each feed was last fetched.  A feed without posts
+-- may never have been fetched at all, so it is left NULL and fetched first.
+UPDATE feeds SET last_fetched_at = updated_at
+WHERE last_fetched_at IS NULL
+AND EXISTS (SELECT 1 FROM posts WHERE posts.feed_id = feeds.id);
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN last_fetch_error;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feed_follows
+ADD alias text;
+
+ALTER TABLE feed_follows
+ADD folders text[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE feed_follows
+DROP COLUMN folders;
+
+ALTER TABLE feed_follows
+DROP COLUMN alias;
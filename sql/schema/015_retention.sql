@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD keep_days integer;
+
+ALTER TABLE feeds
+ADD keep_posts integer;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN keep_posts;
+
+ALTER TABLE feeds
+DROP COLUMN keep_days;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- effective_at is the date a post sorts by: its publish date when the feed
+-- gives one that can be read, else the feed's lastBuildDate, else when gator
+-- first saw it.  date_source records which.
+ALTER TABLE posts
+ADD effective_at timestamp;
+
+ALTER TABLE posts
+ADD date_source text;
+
+UPDATE posts
+SET effective_at = COALESCE(published_at, created_at),
+  date_source = CASE WHEN published_at IS NULL THEN 'fetched' ELSE 'published' END;
+
+ALTER TABLE posts
+ALTER effective_at SET NOT NULL,
+ALTER date_source SET NOT NULL,
+ADD CONSTRAINT posts_date_source_check CHECK (date_source IN ('published', 'feed', 'fetched'));
+
+-- Timelines read posts in effective_at, id order, newest or oldest first,
+-- across the followed feeds or within one.
+CREATE INDEX posts_sort_idx ON posts (effective_at, id);
+CREATE INDEX posts_feed_sort_idx ON posts (feed_id, effective_at, id);
+
+-- +goose Down
+DROP INDEX posts_feed_sort_idx;
+DROP INDEX posts_sort_idx;
+
+ALTER TABLE posts
+DROP COLUMN date_source;
+
+ALTER TABLE posts
+DROP COLUMN effective_at;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- A saved search is a filtered timeline a user has chosen to publish as a
+-- feed.  filters holds the filters as a URL query string.  The feed's URL
+-- carries a secret token, of which only the SHA-256 is kept.
+CREATE TABLE saved_searches (
+  id uuid PRIMARY KEY,
+  created_at timestamp NOT NULL,
+  user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name text NOT NULL,
+  filters text NOT NULL,
+  token_hash text NOT NULL UNIQUE,
+  UNIQUE(user_id, name)
+);
+
+-- +goose Down
+DROP TABLE saved_searches;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- API keys are kept as SHA-256 hashes, as session tokens are, so a key is
+-- only ever seen when it is made.  Fever clients sign in with
+-- md5("<name>:<key>"), which is kept hashed in a column of its own so that
+-- signing in is a lookup rather than an md5 of every user.
+ALTER TABLE users
+ADD fever_key_hash text UNIQUE;
+
+UPDATE users
+SET fever_key_hash = encode(sha256(md5(name || ':' || api_key)::bytea), 'hex'),
+  api_key = encode(sha256(api_key::bytea), 'hex');
+
+ALTER TABLE users
+RENAME COLUMN api_key TO api_key_hash;
+
+-- +goose Down
+-- The keys cannot be read back from their hashes, so everyone gets a new one.
+ALTER TABLE users
+RENAME COLUMN api_key_hash TO api_key;
+
+UPDATE users
+SET api_key = encode(sha256(gen_random_uuid()::text::bytea), 'hex');
+
+ALTER TABLE users
+DROP COLUMN fever_key_hash;
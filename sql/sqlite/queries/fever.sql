@@ -0,0 +1,58 @@
+-- name: GetUserByFeverKey :one
+SELECT * FROM users WHERE fever_key_hash = CAST(sqlc.arg('fever_key_hash') AS TEXT);
+
+-- name: GetFeverFeeds :many
+SELECT feeds.seq, feeds.name, feeds.url, feeds.updated_at, feeds.last_fetched_at FROM feeds
+INNER JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = ?1
+ORDER BY feeds.seq;
+
+-- name: GetFeverItems :many
+-- with_ids is a JSON array of seqs.
+SELECT posts.seq, feeds.seq AS feed_seq, posts.title, posts.author, posts.description, posts.url,
+  posts.effective_at AS sort_date,
+  post_reads.post_id IS NOT NULL AS read,
+  post_stars.post_id IS NOT NULL AS starred
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON feed_follows.feed_id = feeds.id AND feed_follows.user_id = sqlc.arg('user_id')
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg('user_id')
+LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg('user_id')
+WHERE (sqlc.narg('since_id') IS NULL OR posts.seq > sqlc.narg('since_id'))
+AND (sqlc.narg('max_id') IS NULL OR posts.seq < sqlc.narg('max_id'))
+AND (sqlc.narg('with_ids') IS NULL OR posts.seq IN (SELECT value FROM json_each(sqlc.narg('with_ids'))))
+ORDER BY
+  CASE WHEN sqlc.narg('max_id') IS NULL THEN posts.seq END ASC,
+  posts.seq DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CountFeverItems :one
+SELECT COUNT(*) FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = ?1;
+
+-- name: GetUnreadItemSeqs :many
+SELECT posts.seq FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = ?1
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = ?1
+WHERE post_reads.post_id IS NULL
+ORDER BY posts.seq;
+
+-- name: GetStarredItemSeqs :many
+SELECT posts.seq FROM posts
+INNER JOIN post_stars ON post_stars.post_id = posts.id
+WHERE post_stars.user_id = ?1
+ORDER BY posts.seq;
+
+-- name: GetPostIDBySeq :one
+SELECT id FROM posts WHERE seq = ?1;
+
+-- name: MarkFeedsReadBefore :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, CURRENT_TIMESTAMP FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+AND (sqlc.narg('feed_seq') IS NULL OR feeds.seq = sqlc.narg('feed_seq'))
+AND julianday(posts.created_at) <= julianday(sqlc.arg('before'))
+ON CONFLICT (user_id, post_id) DO NOTHING;
@@ -0,0 +1,226 @@
+-- name: CreatePost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories, effective_at, date_source)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12)
+RETURNING *;
+
+-- name: CreatePosts :execrows
+-- items is the same JSON array as on Postgres.  WHERE true keeps SQLite from
+-- reading ON CONFLICT as part of the FROM clause.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, categories,
+  effective_at, date_source)
+SELECT json_extract(item.value, '$.id'), sqlc.arg('created_at'), sqlc.arg('created_at'),
+  json_extract(item.value, '$.title'), json_extract(item.value, '$.url'),
+  json_extract(item.value, '$.description'), json_extract(item.value, '$.published_at'),
+  sqlc.arg('feed_id'), json_extract(item.value, '$.author'),
+  COALESCE(json_extract(item.value, '$.categories'), '[]'),
+  json_extract(item.value, '$.effective_at'), json_extract(item.value, '$.date_source')
+FROM json_each(sqlc.arg('items')) AS item
+WHERE true
+ON CONFLICT DO NOTHING;
+
+-- name: GetNewestPostsForUser :many
+-- See sql/queries/posts.sql.  SQLite plans a query before its parameters
+-- are bound, so the two branches plan the timeline both ways and the
+-- conditions on the parameters alone pick the one that runs.  The first
+-- serves the unfiltered timeline, which has a page near the start of
+-- posts_sort_idx: the CROSS JOINs keep posts the outer table, read from the
+-- index in order until a page has been found.  Any other filter can leave a
+-- page spread over the whole index, so the second lets SQLite start from
+-- the followed feeds, read their posts from posts_feed_sort_idx and sort
+-- what matches.
+SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred
+FROM (
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    CROSS JOIN feeds ON posts.feed_id = feeds.id
+    CROSS JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg('user_id')
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg('user_id')
+    WHERE (sqlc.narg('feed_url') IS NULL OR feeds.url = sqlc.narg('feed_url'))
+    AND (sqlc.narg('since') IS NULL OR julianday(posts.effective_at) >= julianday(sqlc.narg('since')))
+    AND (sqlc.narg('until') IS NULL OR julianday(posts.effective_at) < julianday(sqlc.narg('until')))
+    AND (sqlc.narg('author') IS NULL OR posts.author LIKE '%' || sqlc.narg('author') || '%')
+    AND (sqlc.narg('tag') IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = sqlc.narg('tag')))
+    AND (sqlc.narg('search') IS NULL
+      OR posts.title LIKE '%' || sqlc.narg('search') || '%'
+      OR posts.description LIKE '%' || sqlc.narg('search') || '%')
+    AND (sqlc.narg('folder') IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = sqlc.narg('folder')))
+    AND (NOT sqlc.arg('unread_only') OR post_reads.post_id IS NULL)
+    AND (NOT sqlc.arg('starred_only') OR post_stars.post_id IS NOT NULL)
+    AND (sqlc.narg('after_date') IS NULL
+      OR (julianday(posts.effective_at), posts.id) < (julianday(sqlc.narg('after_date')), sqlc.narg('after_id')))
+    AND (sqlc.narg('feed_url') IS NULL AND sqlc.narg('until') IS NULL AND sqlc.narg('author') IS NULL
+      AND sqlc.narg('tag') IS NULL AND sqlc.narg('search') IS NULL AND sqlc.narg('folder') IS NULL
+      AND NOT sqlc.arg('unread_only') AND NOT sqlc.arg('starred_only'))
+    ORDER BY julianday(posts.effective_at) DESC, posts.id DESC
+    LIMIT sqlc.arg('limit')
+  )
+  UNION ALL
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    INNER JOIN feeds ON posts.feed_id = feeds.id
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg('user_id')
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg('user_id')
+    WHERE (sqlc.narg('feed_url') IS NULL OR feeds.url = sqlc.narg('feed_url'))
+    AND (sqlc.narg('since') IS NULL OR julianday(posts.effective_at) >= julianday(sqlc.narg('since')))
+    AND (sqlc.narg('until') IS NULL OR julianday(posts.effective_at) < julianday(sqlc.narg('until')))
+    AND (sqlc.narg('author') IS NULL OR posts.author LIKE '%' || sqlc.narg('author') || '%')
+    AND (sqlc.narg('tag') IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = sqlc.narg('tag')))
+    AND (sqlc.narg('search') IS NULL
+      OR posts.title LIKE '%' || sqlc.narg('search') || '%'
+      OR posts.description LIKE '%' || sqlc.narg('search') || '%')
+    AND (sqlc.narg('folder') IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = sqlc.narg('folder')))
+    AND (NOT sqlc.arg('unread_only') OR post_reads.post_id IS NULL)
+    AND (NOT sqlc.arg('starred_only') OR post_stars.post_id IS NOT NULL)
+    AND (sqlc.narg('after_date') IS NULL
+      OR (julianday(posts.effective_at), posts.id) < (julianday(sqlc.narg('after_date')), sqlc.narg('after_id')))
+    AND NOT (sqlc.narg('feed_url') IS NULL AND sqlc.narg('until') IS NULL AND sqlc.narg('author') IS NULL
+      AND sqlc.narg('tag') IS NULL AND sqlc.narg('search') IS NULL AND sqlc.narg('folder') IS NULL
+      AND NOT sqlc.arg('unread_only') AND NOT sqlc.arg('starred_only'))
+    ORDER BY julianday(posts.effective_at) DESC, posts.id DESC
+    LIMIT sqlc.arg('limit')
+  )
+)
+ORDER BY julianday(sort_date) DESC, id DESC;
+
+-- name: GetOldestPostsForUser :many
+-- GetOldestPostsForUser is GetNewestPostsForUser, oldest first.
+SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred
+FROM (
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    CROSS JOIN feeds ON posts.feed_id = feeds.id
+    CROSS JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg('user_id')
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg('user_id')
+    WHERE (sqlc.narg('feed_url') IS NULL OR feeds.url = sqlc.narg('feed_url'))
+    AND (sqlc.narg('since') IS NULL OR julianday(posts.effective_at) >= julianday(sqlc.narg('since')))
+    AND (sqlc.narg('until') IS NULL OR julianday(posts.effective_at) < julianday(sqlc.narg('until')))
+    AND (sqlc.narg('author') IS NULL OR posts.author LIKE '%' || sqlc.narg('author') || '%')
+    AND (sqlc.narg('tag') IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = sqlc.narg('tag')))
+    AND (sqlc.narg('search') IS NULL
+      OR posts.title LIKE '%' || sqlc.narg('search') || '%'
+      OR posts.description LIKE '%' || sqlc.narg('search') || '%')
+    AND (sqlc.narg('folder') IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = sqlc.narg('folder')))
+    AND (NOT sqlc.arg('unread_only') OR post_reads.post_id IS NULL)
+    AND (NOT sqlc.arg('starred_only') OR post_stars.post_id IS NOT NULL)
+    AND (sqlc.narg('after_date') IS NULL
+      OR (julianday(posts.effective_at), posts.id) > (julianday(sqlc.narg('after_date')), sqlc.narg('after_id')))
+    AND (sqlc.narg('feed_url') IS NULL AND sqlc.narg('until') IS NULL AND sqlc.narg('author') IS NULL
+      AND sqlc.narg('tag') IS NULL AND sqlc.narg('search') IS NULL AND sqlc.narg('folder') IS NULL
+      AND NOT sqlc.arg('unread_only') AND NOT sqlc.arg('starred_only'))
+    ORDER BY julianday(posts.effective_at) ASC, posts.id ASC
+    LIMIT sqlc.arg('limit')
+  )
+  UNION ALL
+  SELECT id, title, description, url, author, categories, sort_date, date_source, feed_name, feed_id, read, starred FROM (
+    SELECT posts.id, posts.title, posts.description, posts.url, posts.author, posts.categories,
+      posts.effective_at AS sort_date, posts.date_source,
+      COALESCE(feed_follows.alias, feeds.name) AS feed_name, posts.feed_id,
+      post_reads.post_id IS NOT NULL AS read,
+      post_stars.post_id IS NOT NULL AS starred
+    FROM posts
+    INNER JOIN feeds ON posts.feed_id = feeds.id
+    INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg('user_id')
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg('user_id')
+    WHERE (sqlc.narg('feed_url') IS NULL OR feeds.url = sqlc.narg('feed_url'))
+    AND (sqlc.narg('since') IS NULL OR julianday(posts.effective_at) >= julianday(sqlc.narg('since')))
+    AND (sqlc.narg('until') IS NULL OR julianday(posts.effective_at) < julianday(sqlc.narg('until')))
+    AND (sqlc.narg('author') IS NULL OR posts.author LIKE '%' || sqlc.narg('author') || '%')
+    AND (sqlc.narg('tag') IS NULL OR EXISTS (SELECT 1 FROM json_each(posts.categories) WHERE json_each.value = sqlc.narg('tag')))
+    AND (sqlc.narg('search') IS NULL
+      OR posts.title LIKE '%' || sqlc.narg('search') || '%'
+      OR posts.description LIKE '%' || sqlc.narg('search') || '%')
+    AND (sqlc.narg('folder') IS NULL OR EXISTS (SELECT 1 FROM json_each(feed_follows.folders) WHERE json_each.value = sqlc.narg('folder')))
+    AND (NOT sqlc.arg('unread_only') OR post_reads.post_id IS NULL)
+    AND (NOT sqlc.arg('starred_only') OR post_stars.post_id IS NOT NULL)
+    AND (sqlc.narg('after_date') IS NULL
+      OR (julianday(posts.effective_at), posts.id) > (julianday(sqlc.narg('after_date')), sqlc.narg('after_id')))
+    AND NOT (sqlc.narg('feed_url') IS NULL AND sqlc.narg('until') IS NULL AND sqlc.narg('author') IS NULL
+      AND sqlc.narg('tag') IS NULL AND sqlc.narg('search') IS NULL AND sqlc.narg('folder') IS NULL
+      AND NOT sqlc.arg('unread_only') AND NOT sqlc.arg('starred_only'))
+    ORDER BY julianday(posts.effective_at) ASC, posts.id ASC
+    LIMIT sqlc.arg('limit')
+  )
+)
+ORDER BY julianday(sort_date) ASC, id ASC;
+
+-- name: FollowsPost :one
+-- See sql/queries/posts.sql.
+SELECT EXISTS (
+  SELECT 1 FROM posts
+  INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+  WHERE posts.id = ?2 AND feed_follows.user_id = ?1
+);
+
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES (?1, ?2, ?3)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostUnread :exec
+DELETE FROM post_reads
+WHERE user_id = ?1 AND post_id = ?2;
+
+-- name: StarPost :exec
+INSERT INTO post_stars (user_id, post_id, starred_at)
+VALUES (?1, ?2, ?3)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: UnstarPost :exec
+DELETE FROM post_stars
+WHERE user_id = ?1 AND post_id = ?2;
+
+-- name: CountPrunablePosts :many
+SELECT feeds.name, feeds.url, COUNT(*) AS posts
+FROM (
+  SELECT posts.id, posts.feed_id, posts.created_at,
+    ROW_NUMBER() OVER (PARTITION BY posts.feed_id
+      ORDER BY julianday(posts.effective_at) DESC, posts.seq DESC) AS newness
+  FROM posts
+) AS ranked
+INNER JOIN feeds ON ranked.feed_id = feeds.id
+WHERE (
+  julianday(ranked.created_at) < julianday(sqlc.arg('now'))
+    - NULLIF(COALESCE(feeds.keep_days, sqlc.arg('keep_days')), 0)
+  OR ranked.newness > NULLIF(COALESCE(feeds.keep_posts, sqlc.arg('keep_posts')), 0)
+)
+AND NOT EXISTS (SELECT 1 FROM post_stars WHERE post_stars.post_id = ranked.id)
+GROUP BY feeds.id
+ORDER BY feeds.name;
+
+-- name: PrunePosts :execrows
+DELETE FROM posts
+WHERE posts.id IN (
+  SELECT ranked.id
+  FROM (
+    SELECT posts.id, posts.feed_id, posts.created_at,
+      ROW_NUMBER() OVER (PARTITION BY posts.feed_id
+        ORDER BY julianday(posts.effective_at) DESC, posts.seq DESC) AS newness
+    FROM posts
+  ) AS ranked
+  INNER JOIN feeds ON ranked.feed_id = feeds.id
+  WHERE (
+    julianday(ranked.created_at) < julianday(sqlc.arg('now'))
+      - NULLIF(COALESCE(feeds.keep_days, sqlc.arg('keep_days')), 0)
+    OR ranked.newness > NULLIF(COALESCE(feeds.keep_posts, sqlc.arg('keep_posts')), 0)
+  )
+  AND NOT EXISTS (SELECT 1 FROM post_stars WHERE post_stars.post_id = ranked.id)
+);
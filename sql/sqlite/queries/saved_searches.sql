@@ -0,0 +1,18 @@
+-- name: CreateSavedSearch :one
+INSERT INTO saved_searches (id, created_at, user_id, name, filters, token_hash)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+RETURNING *;
+
+-- name: GetSavedSearches :many
+SELECT * FROM saved_searches WHERE user_id = ?1 ORDER BY name;
+
+-- name: GetSavedSearch :one
+SELECT * FROM saved_searches WHERE user_id = ?1 AND name = ?2;
+
+-- name: GetSavedSearchByToken :one
+SELECT sqlc.embed(saved_searches), sqlc.embed(users) FROM saved_searches
+INNER JOIN users ON users.id = saved_searches.user_id
+WHERE saved_searches.token_hash = ?1;
+
+-- name: DeleteSavedSearch :execrows
+DELETE FROM saved_searches WHERE user_id = ?1 AND name = ?2;
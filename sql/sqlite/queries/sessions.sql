@@ -0,0 +1,17 @@
+-- name: CreateSession :exec
+INSERT INTO sessions (token_hash, user_id, created_at, expires_at)
+VALUES (?1, ?2, ?3, ?4);
+
+-- name: GetUserBySession :one
+SELECT users.* FROM users
+INNER JOIN sessions ON sessions.user_id = users.id
+WHERE sessions.token_hash = ?1 AND julianday(sessions.expires_at) > julianday('now');
+
+-- name: DeleteSession :exec
+DELETE FROM sessions WHERE token_hash = ?1;
+
+-- name: DeleteOtherSessions :exec
+DELETE FROM sessions WHERE user_id = ?1 AND token_hash <> ?2;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions WHERE julianday(expires_at) <= julianday('now');
@@ -0,0 +1,84 @@
+-- +goose Up
+-- SQLite databases start from the schema the Postgres migrations had built
+-- up to when SQLite support was added (sql/schema/014_follow_folders.sql).
+-- uuid columns hold the UUID as text, and the text[] columns of Postgres
+-- are JSON arrays.  Timestamps are compared through julianday() in the
+-- queries, since they are written with their UTC offsets.
+CREATE TABLE users (
+  id uuid PRIMARY KEY,
+  created_at timestamp NOT NULL,
+  updated_at timestamp NOT NULL,
+  name text NOT NULL UNIQUE,
+  api_key text NOT NULL UNIQUE DEFAULT (lower(hex(randomblob(32)))),
+  password_hash text,
+  role text NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member'))
+);
+
+-- seq is the rowid, so SQLite numbers feeds and posts itself as Postgres's
+-- bigserial columns do.
+CREATE TABLE feeds (
+  id uuid NOT NULL UNIQUE,
+  created_at timestamp NOT NULL,
+  updated_at timestamp NOT NULL,
+  name text NOT NULL,
+  user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  url text NOT NULL UNIQUE,
+  last_fetched_at timestamp,
+  seq integer PRIMARY KEY AUTOINCREMENT,
+  last_fetch_error text
+);
+
+CREATE TABLE feed_follows (
+  id uuid PRIMARY KEY,
+  created_at timestamp NOT NULL,
+  updated_at timestamp NOT NULL,
+  user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  feed_id uuid NOT NULL REFERENCES feeds (id) ON DELETE CASCADE,
+  alias text,
+  folders text NOT NULL DEFAULT '[]',
+  UNIQUE(user_id, feed_id)
+);
+
+CREATE TABLE posts (
+  id uuid NOT NULL UNIQUE,
+  created_at timestamp NOT NULL,
+  updated_at timestamp NOT NULL,
+  title text NOT NULL,
+  url text NOT NULL UNIQUE,
+  description text,
+  published_at timestamp,
+  feed_id uuid NOT NULL REFERENCES feeds (id) ON DELETE CASCADE,
+  author text,
+  categories text NOT NULL DEFAULT '[]',
+  seq integer PRIMARY KEY AUTOINCREMENT
+);
+
+CREATE TABLE post_reads (
+  user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  post_id uuid NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+  read_at timestamp NOT NULL,
+  PRIMARY KEY(user_id, post_id)
+);
+
+CREATE TABLE post_stars (
+  user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  post_id uuid NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+  starred_at timestamp NOT NULL,
+  PRIMARY KEY(user_id, post_id)
+);
+
+CREATE TABLE sessions (
+  token_hash text PRIMARY KEY,
+  user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  created_at timestamp NOT NULL,
+  expires_at timestamp NOT NULL
+);
+
+-- +goose Down
+DROP TABLE sessions;
+DROP TABLE post_stars;
+DROP TABLE post_reads;
+DROP TABLE posts;
+DROP TABLE feed_follows;
+DROP TABLE feeds;
+DROP TABLE users;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- The same indexes as sql/schema/016_indexes.sql.
+
+CREATE INDEX feed_follows_feed_id_idx ON feed_follows (feed_id);
+CREATE INDEX post_reads_post_id_idx ON post_reads (post_id);
+CREATE INDEX post_stars_post_id_idx ON post_stars (post_id);
+CREATE INDEX feeds_user_id_idx ON feeds (user_id);
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+
+CREATE INDEX feeds_last_fetched_at_idx ON feeds (julianday(last_fetched_at));
+
+-- +goose Down
+DROP INDEX feeds_last_fetched_at_idx;
+DROP INDEX sessions_user_id_idx;
+DROP INDEX feeds_user_id_idx;
+DROP INDEX post_stars_post_id_idx;
+DROP INDEX post_reads_post_id_idx;
+DROP INDEX feed_follows_feed_id_idx;
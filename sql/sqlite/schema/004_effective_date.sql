@@ -0,0 +1,28 @@
+-- +goose Up
+-- See sql/schema/017_effective_date.sql.  SQLite only adds a NOT NULL column
+-- with a default; every insert sets both columns, so the defaults only stand
+-- until the UPDATE below.
+ALTER TABLE posts
+ADD effective_at timestamp NOT NULL DEFAULT '1970-01-01 00:00:00';
+
+ALTER TABLE posts
+ADD date_source text NOT NULL DEFAULT 'fetched'
+  CHECK (date_source IN ('published', 'feed', 'fetched'));
+
+UPDATE posts
+SET effective_at = COALESCE(published_at, created_at),
+  date_source = CASE WHEN published_at IS NULL THEN 'fetched' ELSE 'published' END;
+
+-- On the julianday() the SQLite queries sort by.
+CREATE INDEX posts_sort_idx ON posts (julianday(effective_at), id);
+CREATE INDEX posts_feed_sort_idx ON posts (feed_id, julianday(effective_at), id);
+
+-- +goose Down
+DROP INDEX posts_feed_sort_idx;
+DROP INDEX posts_sort_idx;
+
+ALTER TABLE posts
+DROP COLUMN date_source;
+
+ALTER TABLE posts
+DROP COLUMN effective_at;
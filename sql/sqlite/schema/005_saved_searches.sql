@@ -0,0 +1,14 @@
+-- +goose Up
+-- See sql/schema/018_saved_searches.sql.
+CREATE TABLE saved_searches (
+  id uuid PRIMARY KEY,
+  created_at timestamp NOT NULL,
+  user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name text NOT NULL,
+  filters text NOT NULL,
+  token_hash text NOT NULL UNIQUE,
+  UNIQUE(user_id, name)
+);
+
+-- +goose Down
+DROP TABLE saved_searches;
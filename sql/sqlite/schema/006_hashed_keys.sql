@@ -0,0 +1,27 @@
+-- +goose Up
+-- See sql/schema/019_hashed_keys.sql.  SQLite cannot add a UNIQUE column, so
+-- a unique index stands in.  internal/sqlite registers sha256 and md5; its
+-- sha256 returns hex rather than bytes.
+ALTER TABLE users
+ADD fever_key_hash text;
+
+CREATE UNIQUE INDEX users_fever_key_hash_idx ON users (fever_key_hash);
+
+UPDATE users
+SET fever_key_hash = sha256(md5(name || ':' || api_key)),
+  api_key = sha256(api_key);
+
+ALTER TABLE users
+RENAME COLUMN api_key TO api_key_hash;
+
+-- +goose Down
+ALTER TABLE users
+RENAME COLUMN api_key_hash TO api_key;
+
+UPDATE users
+SET api_key = lower(hex(randomblob(32)));
+
+DROP INDEX users_fever_key_hash_idx;
+
+ALTER TABLE users
+DROP COLUMN fever_key_hash;
@@ -0,0 +1,729 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+	"github.com/interyx/gator/internal/memstore"
+	"github.com/interyx/gator/internal/sqlite"
+)
+
+// The tests in this file run the same scenarios against each store that can
+// be tested without a server: the in-memory store and SQLite, held in
+// memory.  They check that the stores agree with each other, and so that
+// the SQLite queries and the adapter in internal/sqlite work.
+
+// openSQLiteState returns a state for an empty SQLite database that lives
+// in memory for the rest of the test.
+func openSQLiteState(t *testing.T) *state {
+	t.Helper()
+	conn, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each connection would open a database of its own.
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { conn.Close() })
+	return &state{db: sqliteBackend.queries(conn), conn: conn, backend: sqliteBackend, out: io.Discard}
+}
+
+// newSQLiteState is openSQLiteState with the migrations applied.
+func newSQLiteState(t *testing.T) *state {
+	t.Helper()
+	s := openSQLiteState(t)
+	if err := migrateUp(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// forEachStore runs test against an empty store of each kind.
+func forEachStore(t *testing.T, test func(t *testing.T, q database.Querier)) {
+	t.Run("memstore", func(t *testing.T) {
+		test(t, memstore.New())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		test(t, newSQLiteState(t).db)
+	})
+}
+
+// storeFixture adds users, feeds and posts to a store.
+type storeFixture struct {
+	t   *testing.T
+	ctx context.Context
+	q   database.Querier
+	now time.Time
+}
+
+func newStoreFixture(t *testing.T, q database.Querier) *storeFixture {
+	return &storeFixture{t: t, ctx: context.Background(), q: q, now: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+}
+
+func (f *storeFixture) user(name string) database.User {
+	f.t.Helper()
+	user, err := f.q.CreateUser(f.ctx, database.CreateUserParams{
+		ID: uuid.New(), CreatedAt: f.now, UpdatedAt: f.now, Name: name, Role: roleMember,
+	})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return user
+}
+
+// feed adds a feed owned by owner, which follows it.
+func (f *storeFixture) feed(owner database.User, name, url string) database.Feed {
+	f.t.Helper()
+	feed, err := f.q.CreateFeed(f.ctx, database.CreateFeedParams{
+		ID: uuid.New(), CreatedAt: f.now, UpdatedAt: f.now, Name: name, UserID: owner.ID, Url: url,
+	})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.follow(owner, feed)
+	return feed
+}
+
+func (f *storeFixture) follow(user database.User, feed database.Feed) {
+	f.t.Helper()
+	if _, err := f.follows(user, feed); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *storeFixture) follows(user database.User, feed database.Feed) (database.CreateFeedFollowRow, error) {
+	return f.q.CreateFeedFollow(f.ctx, database.CreateFeedFollowParams{
+		ID: uuid.New(), CreatedAt: f.now, UpdatedAt: f.now, UserID: user.ID, FeedID: feed.ID,
+	})
+}
+
+// posts stores posts in feed as fetched at fetched, and returns how many
+// were new.
+func (f *storeFixture) posts(feed database.Feed, fetched time.Time, posts ...database.NewPost) int64 {
+	f.t.Helper()
+	for i := range posts {
+		if posts[i].ID == uuid.Nil {
+			posts[i].ID = uuid.New()
+		}
+		if posts[i].DateSource == "" {
+			posts[i].DateSource = database.DateSourcePublished
+		}
+	}
+	items, err := database.EncodePosts(posts)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	n, err := f.q.CreatePosts(f.ctx, database.CreatePostsParams{CreatedAt: fetched, FeedID: feed.ID, Items: items})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return n
+}
+
+// post returns a post dated days before now, with its URL made from its
+// title.
+func (f *storeFixture) post(title string, days int) database.NewPost {
+	return database.NewPost{Title: title, Url: "https://example.com/" + title, EffectiveAt: f.now.AddDate(0, 0, -days)}
+}
+
+// timeline returns the titles of the posts GetPostsForUser returns.
+func (f *storeFixture) timeline(arg database.GetPostsForUserParams) []string {
+	f.t.Helper()
+	if arg.Limit == 0 {
+		arg.Limit = 100
+	}
+	rows, err := database.GetPostsForUser(f.ctx, f.q, arg)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	var titles []string
+	for _, row := range rows {
+		titles = append(titles, row.Title)
+	}
+	return titles
+}
+
+// ids returns the IDs of the posts in user's timeline by title.
+func (f *storeFixture) ids(user database.User) map[string]uuid.UUID {
+	f.t.Helper()
+	rows, err := database.GetPostsForUser(f.ctx, f.q, database.GetPostsForUserParams{UserID: user.ID, Limit: 100})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	ids := map[string]uuid.UUID{}
+	for _, row := range rows {
+		ids[row.Title] = row.ID
+	}
+	return ids
+}
+
+func TestStoreFollowUniqueness(t *testing.T) {
+	forEachStore(t, func(t *testing.T, q database.Querier) {
+		f := newStoreFixture(t, q)
+		alice, bob := f.user("alice"), f.user("bob")
+		feed := f.feed(alice, "Blog", "https://example.com/feed")
+
+		if _, err := f.follows(alice, feed); !isUniqueViolation(err) {
+			t.Errorf("following a feed twice: got %v, want a unique violation", err)
+		}
+		row, err := f.follows(bob, feed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if row.UserName != "bob" || row.FeedName != "Blog" {
+			t.Errorf("CreateFeedFollow = %s, %s; want bob, Blog", row.UserName, row.FeedName)
+		}
+		if _, err := f.follows(bob, database.Feed{ID: uuid.New()}); !isForeignKeyViolation(err) {
+			t.Errorf("following a missing feed: got %v, want a foreign key violation", err)
+		}
+
+		_, err = q.CreateFeed(f.ctx, database.CreateFeedParams{
+			ID: uuid.New(), CreatedAt: f.now, UpdatedAt: f.now, Name: "Again", UserID: bob.ID, Url: feed.Url,
+		})
+		if !isUniqueViolation(err) {
+			t.Errorf("adding a feed twice: got %v, want a unique violation", err)
+		}
+		_, err = q.CreateUser(f.ctx, database.CreateUserParams{
+			ID: uuid.New(), CreatedAt: f.now, UpdatedAt: f.now, Name: "alice", Role: roleMember,
+		})
+		if !isUniqueViolation(err) {
+			t.Errorf("adding a user twice: got %v, want a unique violation", err)
+		}
+
+		// Unfollowing leaves the other follow.
+		if err := q.DeleteFeedFollow(f.ctx, database.DeleteFeedFollowParams{Name: "alice", Url: feed.Url}); err != nil {
+			t.Fatal(err)
+		}
+		follows, err := q.GetFeedFollowsForUser(f.ctx, "alice")
+		if err != nil || len(follows) != 0 {
+			t.Errorf("alice follows %v, %v; want none", follows, err)
+		}
+		follows, err = q.GetFeedFollowsForUser(f.ctx, "bob")
+		if err != nil || len(follows) != 1 {
+			t.Errorf("bob follows %v, %v; want the feed", follows, err)
+		}
+	})
+}
+
+func TestStoreFollowFolders(t *testing.T) {
+	forEachStore(t, func(t *testing.T, q database.Querier) {
+		f := newStoreFixture(t, q)
+		alice := f.user("alice")
+		feed := f.feed(alice, "Blog", "https://example.com/feed")
+
+		n, err := q.SetFollowFolders(f.ctx, database.SetFollowFoldersParams{Folders: []string{"tech", "daily"}, UserID: alice.ID, Url: feed.Url})
+		if err != nil || n != 1 {
+			t.Fatalf("SetFollowFolders = %d, %v; want 1", n, err)
+		}
+		if _, err := q.SetFollowAlias(f.ctx, database.SetFollowAliasParams{Alias: sql.NullString{String: "Mine", Valid: true}, UserID: alice.ID, Url: feed.Url}); err != nil {
+			t.Fatal(err)
+		}
+		follows, err := q.GetFeedFollowsForUser(f.ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(follows) != 1 || !slices.Equal(follows[0].Folders, []string{"tech", "daily"}) || follows[0].Alias.String != "Mine" {
+			t.Errorf("GetFeedFollowsForUser = %+v", follows)
+		}
+		folders, err := q.GetFollowFolders(f.ctx, alice.ID)
+		if err != nil || !slices.Equal(folders, []string{"daily", "tech"}) {
+			t.Errorf("GetFollowFolders = %q, %v; want daily, tech", folders, err)
+		}
+
+		n, err = q.SetFollowFolders(f.ctx, database.SetFollowFoldersParams{Folders: []string{}, UserID: alice.ID, Url: "https://example.com/other"})
+		if err != nil || n != 0 {
+			t.Errorf("SetFollowFolders on a feed alice does not follow = %d, %v; want 0", n, err)
+		}
+	})
+}
+
+func TestStoreCreatePosts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, q database.Querier) {
+		f := newStoreFixture(t, q)
+		alice := f.user("alice")
+		feed := f.feed(alice, "Blog", "https://example.com/feed")
+
+		description, author := "<p>Body</p>", "Ann"
+		published := time.Date(2026, 10, 18, 10, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+		first := database.NewPost{
+			Title: "First", Url: "https://example.com/first", Description: &description,
+			PublishedAt: &published, Author: &author, Categories: []string{"go", "sql"},
+			EffectiveAt: published, DateSource: database.DateSourcePublished,
+		}
+		second := database.NewPost{
+			ID: uuid.New(), Title: "Second", Url: "https://example.com/second",
+			EffectiveAt: f.now, DateSource: database.DateSourceFetched,
+		}
+		if n := f.posts(feed, f.now, first, second); n != 2 {
+			t.Errorf("CreatePosts stored %d posts, want 2", n)
+		}
+		// Posts already stored are skipped, whether the URL or the ID
+		// matches, and the rest are stored.
+		again := database.NewPost{Title: "Again", Url: first.Url, EffectiveAt: f.now}
+		sameID := database.NewPost{ID: second.ID, Title: "Same ID", Url: "https://example.com/same-id", EffectiveAt: f.now}
+		third := f.post("third", 3)
+		if n := f.posts(feed, f.now, again, sameID, third); n != 1 {
+			t.Errorf("CreatePosts stored %d posts, want 1", n)
+		}
+		if n := f.posts(feed, f.now); n != 0 {
+			t.Errorf("CreatePosts with no posts stored %d", n)
+		}
+
+		rows, err := database.GetPostsForUser(f.ctx, q, database.GetPostsForUserParams{UserID: alice.ID, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 3 {
+			t.Fatalf("GetPostsForUser returned %d posts, want 3", len(rows))
+		}
+		got := rows[1]
+		if got.Title != "First" || got.Url != first.Url || got.FeedName != "Blog" || got.FeedID != feed.ID {
+			t.Errorf("post = %+v", got)
+		}
+		if got.Description.String != description || got.Author.String != author {
+			t.Errorf("description, author = %v, %v; want %q, %q", got.Description, got.Author, description, author)
+		}
+		if !slices.Equal(got.Categories, []string{"go", "sql"}) {
+			t.Errorf("categories = %q, want go, sql", got.Categories)
+		}
+		if !got.SortDate.Equal(published) || got.DateSource != database.DateSourcePublished {
+			t.Errorf("sort date = %v, %s; want %v, %s", got.SortDate, got.DateSource, published, database.DateSourcePublished)
+		}
+		if got.Read || got.Starred {
+			t.Errorf("new post read %v, starred %v", got.Read, got.Starred)
+		}
+		if got := rows[0]; got.Title != "Second" || got.Description.Valid || got.Author.Valid || got.Categories == nil || len(got.Categories) != 0 {
+			t.Errorf("post without optional fields = %+v", got)
+		}
+	})
+}
+
+func TestStoreTimelineFilters(t *testing.T) {
+	forEachStore(t, func(t *testing.T, q database.Querier) {
+		f := newStoreFixture(t, q)
+		alice, bob := f.user("alice"), f.user("bob")
+		blog := f.feed(alice, "Blog", "https://example.com/blog")
+		news := f.feed(alice, "News", "https://example.com/news")
+		other := f.feed(bob, "Other", "https://example.com/other")
+
+		ann, ben := "Ann Smith", "Ben"
+		golang := f.post("golang", 1)
+		golang.Author, golang.Categories = &ann, []string{"go"}
+		sqlPost := f.post("sql", 3)
+		sqlPost.Author, sqlPost.Categories = &ben, []string{"db", "go"}
+		body := "all about Go"
+		weather := f.post("weather", 2)
+		weather.Description = &body
+		f.posts(blog, f.now, golang, sqlPost)
+		f.posts(news, f.now, weather, f.post("sport", 5))
+		f.posts(other, f.now, f.post("unfollowed", 0))
+
+		if _, err := q.SetFollowFolders(f.ctx, database.SetFollowFoldersParams{Folders: []string{"tech"}, UserID: alice.ID, Url: blog.Url}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := q.SetFollowAlias(f.ctx, database.SetFollowAliasParams{Alias: sql.NullString{String: "Headlines", Valid: true}, UserID: alice.ID, Url: news.Url}); err != nil {
+			t.Fatal(err)
+		}
+		ids := f.ids(alice)
+		rows, err := database.GetPostsForUser(f.ctx, q, database.GetPostsForUserParams{UserID: alice.ID, FeedUrl: sql.NullString{String: news.Url, Valid: true}, Limit: 1})
+		if err != nil || len(rows) != 1 || rows[0].FeedName != "Headlines" {
+			t.Errorf("GetPostsForUser = %+v, %v; want the feed named by its alias, Headlines", rows, err)
+		}
+		if err := q.MarkPostRead(f.ctx, database.MarkPostReadParams{UserID: alice.ID, PostID: ids["golang"], ReadAt: f.now}); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.StarPost(f.ctx, database.StarPostParams{UserID: alice.ID, PostID: ids["sport"], StarredAt: f.now}); err != nil {
+			t.Fatal(err)
+		}
+		// Bob's read and starred posts are his own.
+		if err := q.MarkPostRead(f.ctx, database.MarkPostReadParams{UserID: bob.ID, PostID: ids["sql"], ReadAt: f.now}); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.StarPost(f.ctx, database.StarPostParams{UserID: bob.ID, PostID: ids["weather"], StarredAt: f.now}); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tc := range []struct {
+			user database.User
+			post uuid.UUID
+			want bool
+		}{{alice, ids["golang"], true}, {bob, ids["golang"], false}, {alice, uuid.New(), false}} {
+			if got, err := q.FollowsPost(f.ctx, database.FollowsPostParams{UserID: tc.user.ID, PostID: tc.post}); err != nil || got != tc.want {
+				t.Errorf("FollowsPost(%s, %v) = %v, %v; want %v", tc.user.Name, tc.post, got, err, tc.want)
+			}
+		}
+
+		str := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+		day := func(days int) sql.NullTime { return sql.NullTime{Time: f.now.AddDate(0, 0, -days), Valid: true} }
+		tests := []struct {
+			name string
+			arg  database.GetPostsForUserParams
+			want []string
+		}{
+			{"all", database.GetPostsForUserParams{}, []string{"golang", "weather", "sql", "sport"}},
+			{"oldest first", database.GetPostsForUserParams{OldestFirst: true}, []string{"sport", "sql", "weather", "golang"}},
+			{"limit", database.GetPostsForUserParams{Limit: 2}, []string{"golang", "weather"}},
+			{"feed", database.GetPostsForUserParams{FeedUrl: str(news.Url)}, []string{"weather", "sport"}},
+			{"unfollowed feed", database.GetPostsForUserParams{FeedUrl: str(other.Url)}, nil},
+			{"since", database.GetPostsForUserParams{Since: day(2)}, []string{"golang", "weather"}},
+			{"until", database.GetPostsForUserParams{Until: day(2)}, []string{"sql", "sport"}},
+			{"since and until", database.GetPostsForUserParams{Since: day(3), Until: day(1)}, []string{"weather", "sql"}},
+			{"author", database.GetPostsForUserParams{Author: str("Smith")}, []string{"golang"}},
+			{"tag", database.GetPostsForUserParams{Tag: str("go")}, []string{"golang", "sql"}},
+			{"tag is matched whole", database.GetPostsForUserParams{Tag: str("g")}, nil},
+			{"search title", database.GetPostsForUserParams{Search: str("spor")}, []string{"sport"}},
+			{"search description", database.GetPostsForUserParams{Search: str("about Go")}, []string{"weather"}},
+			{"folder", database.GetPostsForUserParams{Folder: str("tech")}, []string{"golang", "sql"}},
+			{"unread", database.GetPostsForUserParams{UnreadOnly: true}, []string{"weather", "sql", "sport"}},
+			{"starred", database.GetPostsForUserParams{StarredOnly: true}, []string{"sport"}},
+			{"unread in a feed, oldest first", database.GetPostsForUserParams{FeedUrl: str(blog.Url), UnreadOnly: true, OldestFirst: true}, []string{"sql"}},
+		}
+		for _, tc := range tests {
+			tc.arg.UserID = alice.ID
+			if got := f.timeline(tc.arg); !slices.Equal(got, tc.want) {
+				t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+			}
+		}
+		if got := f.timeline(database.GetPostsForUserParams{UserID: bob.ID, StarredOnly: true}); got != nil {
+			t.Errorf("bob's starred posts = %q, want none he follows", got)
+		}
+	})
+}
+
+func TestStoreTimelineCursor(t *testing.T) {
+	forEachStore(t, func(t *testing.T, q database.Querier) {
+		f := newStoreFixture(t, q)
+		alice := f.user("alice")
+		feed := f.feed(alice, "Blog", "https://example.com/blog")
+		// Posts that share a date are ordered by ID, which the cursor
+		// carries on from.
+		var posts []database.NewPost
+		for i, title := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+			posts = append(posts, f.post(title, i/3))
+		}
+		f.posts(feed, f.now, posts...)
+
+		for _, oldestFirst := range []bool{false, true} {
+			all := f.timeline(database.GetPostsForUserParams{UserID: alice.ID, OldestFirst: oldestFirst})
+			if len(all) != len(posts) {
+				t.Fatalf("oldest first %v: got %q", oldestFirst, all)
+			}
+			var paged []string
+			arg := database.GetPostsForUserParams{UserID: alice.ID, OldestFirst: oldestFirst, Limit: 2}
+			for range len(posts) {
+				rows, err := database.GetPostsForUser(f.ctx, q, arg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(rows) == 0 {
+					break
+				}
+				for _, row := range rows {
+					paged = append(paged, row.Title)
+				}
+				last := rows[len(rows)-1]
+				arg.AfterDate = sql.NullTime{Time: last.SortDate, Valid: true}
+				arg.AfterID = uuid.NullUUID{UUID: last.ID, Valid: true}
+			}
+			if !slices.Equal(paged, all) {
+				t.Errorf("oldest first %v: pages give %q, want %q", oldestFirst, paged, all)
+			}
+		}
+	})
+}
+
+func TestStorePrune(t *testing.T) {
+	forEachStore(t, func(t *testing.T, q database.Querier) {
+		f := newStoreFixture(t, q)
+		alice := f.user("alice")
+		blog := f.feed(alice, "Blog", "https://example.com/blog")
+		news := f.feed(alice, "News", "https://example.com/news")
+		// Blog's posts were all fetched now, and News's ten days ago.
+		f.posts(blog, f.now, f.post("b1", 0), f.post("b2", 1), f.post("b3", 2), f.post("b4", 40))
+		f.posts(news, f.now.AddDate(0, 0, -10), f.post("n1", 10), f.post("n2", 11))
+
+		if err := q.StarPost(f.ctx, database.StarPostParams{UserID: alice.ID, PostID: f.ids(alice)["b4"], StarredAt: f.now}); err != nil {
+			t.Fatal(err)
+		}
+
+		count := func(keepDays, keepPosts int32) map[string]int64 {
+			t.Helper()
+			rows, err := q.CountPrunablePosts(f.ctx, database.CountPrunablePostsParams{Now: f.now, KeepDays: keepDays, KeepPosts: keepPosts})
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts := map[string]int64{}
+			for _, row := range rows {
+				counts[row.Name] = row.Posts
+			}
+			return counts
+		}
+		tests := []struct {
+			name                string
+			keepDays, keepPosts int32
+			want                map[string]int64
+		}{
+			{"keep everything", 0, 0, map[string]int64{}},
+			// The starred b4 is kept however old it is.
+			{"keep a week", 7, 0, map[string]int64{"News": 2}},
+			{"keep two posts", 0, 2, map[string]int64{"Blog": 1}},
+			{"keep a week or one post", 7, 1, map[string]int64{"Blog": 2, "News": 2}},
+		}
+		for _, tc := range tests {
+			if got := count(tc.keepDays, tc.keepPosts); !maps.Equal(got, tc.want) {
+				t.Errorf("%s: CountPrunablePosts = %v, want %v", tc.name, got, tc.want)
+			}
+		}
+
+		// A feed's own settings win over the defaults.
+		if err := q.SetFeedRetention(f.ctx, database.SetFeedRetentionParams{ID: news.ID, KeepDays: sql.NullInt32{Int32: 30, Valid: true}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.SetFeedRetention(f.ctx, database.SetFeedRetentionParams{ID: blog.ID, KeepPosts: sql.NullInt32{Int32: 3, Valid: true}}); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := count(7, 1), map[string]int64{"News": 1}; !maps.Equal(got, want) {
+			t.Errorf("with feed settings: CountPrunablePosts = %v, want %v", got, want)
+		}
+
+		n, err := q.PrunePosts(f.ctx, database.PrunePostsParams{Now: f.now, KeepDays: 7, KeepPosts: 1})
+		if err != nil || n != 1 {
+			t.Errorf("PrunePosts = %d, %v; want 1", n, err)
+		}
+		if got, want := f.timeline(database.GetPostsForUserParams{UserID: alice.ID}), []string{"b1", "b2", "b3", "n1", "b4"}; !slices.Equal(got, want) {
+			t.Errorf("after pruning: got %q, want %q", got, want)
+		}
+		if got := count(7, 1); len(got) != 0 {
+			t.Errorf("after pruning: CountPrunablePosts = %v, want none", got)
+		}
+	})
+}
+
+func TestStoreCreatePost(t *testing.T) {
+	forEachStore(t, func(t *testing.T, q database.Querier) {
+		f := newStoreFixture(t, q)
+		alice := f.user("alice")
+		feed := f.feed(alice, "Blog", "https://example.com/blog")
+
+		published := f.now.Add(-time.Hour)
+		arg := database.CreatePostParams{
+			ID: uuid.New(), CreatedAt: f.now, UpdatedAt: f.now, Title: "Post", Url: "https://example.com/post",
+			PublishedAt: sql.NullTime{Time: published, Valid: true}, FeedID: feed.ID,
+			Categories: []string{"a", "b"}, EffectiveAt: published, DateSource: database.DateSourcePublished,
+		}
+		post, err := q.CreatePost(f.ctx, arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if post.ID != arg.ID || post.FeedID != feed.ID || post.Seq == 0 {
+			t.Errorf("CreatePost = %+v", post)
+		}
+		if !post.PublishedAt.Valid || !post.PublishedAt.Time.Equal(published) || !post.EffectiveAt.Equal(published) {
+			t.Errorf("published, effective = %v, %v; want %v", post.PublishedAt, post.EffectiveAt, published)
+		}
+		if post.Description.Valid || post.Author.Valid {
+			t.Errorf("description, author = %v, %v; want NULL", post.Description, post.Author)
+		}
+		if !slices.Equal(post.Categories, arg.Categories) {
+			t.Errorf("categories = %q, want %q", post.Categories, arg.Categories)
+		}
+		if id, err := q.GetPostIDBySeq(f.ctx, post.Seq); err != nil || id != post.ID {
+			t.Errorf("GetPostIDBySeq = %v, %v; want %v", id, err, post.ID)
+		}
+
+		arg.ID = uuid.New()
+		if _, err := q.CreatePost(f.ctx, arg); !isUniqueViolation(err) {
+			t.Errorf("adding a post twice: got %v, want a unique violation", err)
+		}
+	})
+}
+
+func TestStoreFeedDetails(t *testing.T) {
+	forEachStore(t, func(t *testing.T, q database.Querier) {
+		f := newStoreFixture(t, q)
+		alice, bob := f.user("alice"), f.user("bob")
+		blog := f.feed(alice, "Blog", "https://example.com/blog")
+		news := f.feed(alice, "News", "https://example.com/news")
+		f.follow(bob, blog)
+		f.posts(blog, f.now, f.post("one", 0), f.post("two", 1))
+
+		details, err := q.GetFeedDetails(f.ctx, blog.Url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.ID != blog.ID || details.Owner != "alice" || details.Followers != 2 || details.Posts != 2 {
+			t.Errorf("GetFeedDetails = %+v", details)
+		}
+		if details.LastFetchedAt.Valid || details.LastFetchError.Valid || details.KeepDays.Valid || details.KeepPosts.Valid {
+			t.Errorf("a new feed has %v, %v, %v, %v; want all NULL", details.LastFetchedAt, details.LastFetchError, details.KeepDays, details.KeepPosts)
+		}
+
+		// The feed fetched longest ago, or never, is next.
+		before := time.Now().Add(-time.Minute)
+		fetchErr := sql.NullString{String: "404 Not Found", Valid: true}
+		if err := q.MarkFeedFetched(f.ctx, database.MarkFeedFetchedParams{ID: blog.ID, LastFetchError: fetchErr}); err != nil {
+			t.Fatal(err)
+		}
+		if next, err := q.GetNextFeedToFetch(f.ctx); err != nil || next.ID != news.ID {
+			t.Errorf("GetNextFeedToFetch = %v, %v; want News", next, err)
+		}
+		if err := q.SetFeedRetention(f.ctx, database.SetFeedRetentionParams{ID: blog.ID, KeepDays: sql.NullInt32{Int32: 30, Valid: true}}); err != nil {
+			t.Fatal(err)
+		}
+		details, err = q.GetFeedDetails(f.ctx, blog.Url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !details.LastFetchedAt.Valid || details.LastFetchedAt.Time.Before(before) || details.LastFetchError != fetchErr {
+			t.Errorf("after fetching: %v, %v", details.LastFetchedAt, details.LastFetchError)
+		}
+		if details.KeepDays != (sql.NullInt32{Int32: 30, Valid: true}) || details.KeepPosts.Valid {
+			t.Errorf("retention = %v, %v; want 30 days and no post limit", details.KeepDays, details.KeepPosts)
+		}
+		if details.QueuedAhead != 1 {
+			t.Errorf("%d feeds queued ahead, want the unfetched News", details.QueuedAhead)
+		}
+
+		if _, err := q.GetFeedDetails(f.ctx, "https://example.com/missing"); err != sql.ErrNoRows {
+			t.Errorf("GetFeedDetails of a missing feed: got %v, want sql.ErrNoRows", err)
+		}
+	})
+}
+
+func TestStoreSessions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, q database.Querier) {
+		f := newStoreFixture(t, q)
+		alice := f.user("alice")
+		now := time.Now()
+		for hash, expires := range map[string]time.Time{"live": now.Add(time.Hour), "other": now.Add(time.Hour), "expired": now.Add(-time.Hour)} {
+			if err := q.CreateSession(f.ctx, database.CreateSessionParams{TokenHash: hash, UserID: alice.ID, CreatedAt: now, ExpiresAt: expires}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if user, err := q.GetUserBySession(f.ctx, "live"); err != nil || user.ID != alice.ID {
+			t.Errorf("GetUserBySession = %v, %v; want alice", user.Name, err)
+		}
+		if _, err := q.GetUserBySession(f.ctx, "expired"); err != sql.ErrNoRows {
+			t.Errorf("GetUserBySession of an expired session: got %v, want sql.ErrNoRows", err)
+		}
+		if err := q.DeleteOtherSessions(f.ctx, database.DeleteOtherSessionsParams{UserID: alice.ID, TokenHash: "live"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := q.GetUserBySession(f.ctx, "other"); err != sql.ErrNoRows {
+			t.Errorf("GetUserBySession after logging out elsewhere: got %v, want sql.ErrNoRows", err)
+		}
+		if err := q.DeleteSession(f.ctx, "live"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := q.GetUserBySession(f.ctx, "live"); err != sql.ErrNoRows {
+			t.Errorf("GetUserBySession after logging out: got %v, want sql.ErrNoRows", err)
+		}
+	})
+}
+
+func TestStoreFeverItems(t *testing.T) {
+	forEachStore(t, func(t *testing.T, q database.Querier) {
+		f := newStoreFixture(t, q)
+		alice, bob := f.user("alice"), f.user("bob")
+		blog := f.feed(alice, "Blog", "https://example.com/blog")
+		news := f.feed(alice, "News", "https://example.com/news")
+		f.feed(bob, "Other", "https://example.com/other")
+		f.posts(blog, f.now.Add(-time.Hour), f.post("b1", 2), f.post("b2", 1))
+		f.posts(news, f.now, f.post("n1", 0))
+
+		items := func(arg database.GetFeverItemsParams) []string {
+			t.Helper()
+			arg.UserID = alice.ID
+			if arg.Limit == 0 {
+				arg.Limit = 50
+			}
+			rows, err := q.GetFeverItems(f.ctx, arg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var titles []string
+			for _, row := range rows {
+				titles = append(titles, row.Title)
+			}
+			return titles
+		}
+		rows, err := q.GetFeverItems(f.ctx, database.GetFeverItemsParams{UserID: alice.ID, Limit: 50})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 3 || rows[2].FeedSeq == rows[0].FeedSeq {
+			t.Fatalf("GetFeverItems = %+v", rows)
+		}
+		seq := map[string]int64{}
+		for _, row := range rows {
+			seq[row.Title] = row.Seq
+		}
+		if n, err := q.CountFeverItems(f.ctx, alice.ID); err != nil || n != 3 {
+			t.Errorf("CountFeverItems = %d, %v; want 3", n, err)
+		}
+
+		some := func(n int64) sql.NullInt64 { return sql.NullInt64{Int64: n, Valid: true} }
+		tests := []struct {
+			name string
+			arg  database.GetFeverItemsParams
+			want []string
+		}{
+			{"all", database.GetFeverItemsParams{}, []string{"b1", "b2", "n1"}},
+			{"since", database.GetFeverItemsParams{SinceID: some(seq["b1"])}, []string{"b2", "n1"}},
+			{"max", database.GetFeverItemsParams{MaxID: some(seq["n1"])}, []string{"b2", "b1"}},
+			{"limit", database.GetFeverItemsParams{Limit: 1}, []string{"b1"}},
+			{"with ids", database.GetFeverItemsParams{WithIds: []int64{seq["n1"], seq["b1"]}}, []string{"b1", "n1"}},
+			{"with no ids", database.GetFeverItemsParams{WithIds: []int64{}}, nil},
+		}
+		for _, tc := range tests {
+			if got := items(tc.arg); !slices.Equal(got, tc.want) {
+				t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+			}
+		}
+
+		// Marking a feed read stops at posts fetched after the time given.
+		feeds, err := q.GetFeverFeeds(f.ctx, alice.ID)
+		if err != nil || len(feeds) != 2 {
+			t.Fatalf("GetFeverFeeds = %v, %v", feeds, err)
+		}
+		for _, feed := range feeds {
+			err := q.MarkFeedsReadBefore(f.ctx, database.MarkFeedsReadBeforeParams{UserID: alice.ID, FeedSeq: some(feed.Seq), Before: f.now.Add(-time.Minute)})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := q.StarPost(f.ctx, database.StarPostParams{UserID: alice.ID, PostID: f.ids(alice)["n1"], StarredAt: f.now}); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := q.GetUnreadItemSeqs(f.ctx, alice.ID); err != nil || !slices.Equal(got, []int64{seq["n1"]}) {
+			t.Errorf("GetUnreadItemSeqs = %v, %v; want n1's", got, err)
+		}
+		if got, err := q.GetStarredItemSeqs(f.ctx, alice.ID); err != nil || !slices.Equal(got, []int64{seq["n1"]}) {
+			t.Errorf("GetStarredItemSeqs = %v, %v; want n1's", got, err)
+		}
+		if got, err := q.GetUnreadItemSeqs(f.ctx, bob.ID); err != nil || len(got) != 0 {
+			t.Errorf("bob's unread items = %v, %v; want none", got, err)
+		}
+
+		// Only the fetched feed has a last_fetched_at.
+		if feeds[0].LastFetchedAt.Valid || feeds[1].LastFetchedAt.Valid {
+			t.Errorf("GetFeverFeeds before fetching = %+v", feeds)
+		}
+		if err := q.MarkFeedFetched(f.ctx, database.MarkFeedFetchedParams{ID: news.ID}); err != nil {
+			t.Fatal(err)
+		}
+		feeds, err = q.GetFeverFeeds(f.ctx, alice.ID)
+		if err != nil || len(feeds) != 2 || feeds[0].LastFetchedAt.Valid || !feeds[1].LastFetchedAt.Valid {
+			t.Fatalf("GetFeverFeeds after fetching News = %+v, %v", feeds, err)
+		}
+		if age := time.Since(feeds[1].LastFetchedAt.Time); age < -time.Minute || age > time.Minute {
+			t.Errorf("News was last fetched at %v", feeds[1].LastFetchedAt.Time)
+		}
+	})
+}
@@ -0,0 +1,593 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/interyx/gator/internal/database"
+	"golang.org/x/term"
+)
+
+const (
+	tuiRefreshInterval = 30 * time.Second
+	tuiPostLimit       = 200
+)
+
+type tuiPane int
+
+const (
+	paneFeeds tuiPane = iota
+	panePosts
+	paneArticle
+)
+
+// tui holds the state of the full-screen reader started by `gator tui`.
+// Index 0 of the feed list is a synthetic "All feeds" entry, so feedIndex-1
+// indexes into feeds.
+type tui struct {
+	s    *state
+	user database.User
+
+	feeds      []database.GetFollowedFeedsWithUnreadRow
+	posts      []database.GetPostsForUserRow
+	feedIndex  int
+	postIndex  int
+	feedTop    int
+	postTop    int
+	articleTop int
+	focus      tuiPane
+	unreadOnly bool
+	status     string
+
+	width  int
+	height int
+}
+
+func handlerTUI(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return cmd.usageError("Too many arguments.")
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("The reader needs an interactive terminal")
+	}
+	t := &tui{
+		s:          s,
+		user:       user,
+		unreadOnly: cmd.flagBool("unread"),
+	}
+	if err := t.reload(); err != nil {
+		return err
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	// Switch to the alternate screen and hide the cursor, and put both back
+	// however we leave.
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		term.Restore(fd, oldState)
+	}()
+
+	keys := make(chan string)
+	go readKeys(keys)
+	refresh := time.NewTicker(tuiRefreshInterval)
+	defer refresh.Stop()
+	resize := time.NewTicker(time.Second)
+	defer resize.Stop()
+
+	t.render()
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok || !t.handleKey(key) {
+				return nil
+			}
+		case <-refresh.C:
+			if err := t.reload(); err != nil {
+				t.status = fmt.Sprintf("Refresh failed: %v", err)
+			}
+		case <-resize.C:
+			w, h, err := term.GetSize(int(os.Stdout.Fd()))
+			if err != nil || (w == t.width && h == t.height) {
+				continue
+			}
+		}
+		t.render()
+	}
+}
+
+// readKeys turns raw terminal input into key names: "up", "down", "pgup",
+// "pgdn", "enter", "tab", "esc", or the character typed.
+func readKeys(keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		in := buf[:n]
+		for len(in) > 0 {
+			key, size := decodeKey(in)
+			in = in[size:]
+			if key != "" {
+				keys <- key
+			}
+		}
+	}
+}
+
+func decodeKey(in []byte) (string, int) {
+	sequences := []struct {
+		seq string
+		key string
+	}{
+		{"\x1b[A", "up"},
+		{"\x1b[B", "down"},
+		{"\x1b[C", "right"},
+		{"\x1b[D", "left"},
+		{"\x1b[5~", "pgup"},
+		{"\x1b[6~", "pgdn"},
+		{"\x1b[H", "home"},
+		{"\x1b[F", "end"},
+	}
+	for _, s := range sequences {
+		if bytes.HasPrefix(in, []byte(s.seq)) {
+			return s.key, len(s.seq)
+		}
+	}
+	switch in[0] {
+	case '\x1b':
+		// An unrecognised escape sequence is dropped whole.
+		if len(in) > 1 && in[1] == '[' {
+			return "", len(in)
+		}
+		return "esc", 1
+	case '\r', '\n':
+		return "enter", 1
+	case '\t':
+		return "tab", 1
+	case 3:
+		return "q", 1
+	}
+	r, size := utf8.DecodeRune(in)
+	return string(r), size
+}
+
+// handleKey applies one key press.  It returns false when the reader should
+// exit.
+func (t *tui) handleKey(key string) bool {
+	t.status = ""
+	switch key {
+	case "q":
+		return false
+	case "tab":
+		t.focus = (t.focus + 1) % 3
+	case "h", "left":
+		if t.focus > paneFeeds {
+			t.focus--
+		}
+	case "l", "right":
+		if t.focus < paneArticle {
+			t.focus++
+		}
+	case "esc":
+		if t.focus == paneArticle {
+			t.focus = panePosts
+		}
+	case "j", "down":
+		t.move(1)
+	case "k", "up":
+		t.move(-1)
+	case "pgdn", " ":
+		t.move(t.pageSize())
+	case "pgup", "b":
+		t.move(-t.pageSize())
+	case "enter":
+		switch t.focus {
+		case paneFeeds:
+			t.focus = panePosts
+		case panePosts:
+			if len(t.posts) > 0 {
+				t.focus = paneArticle
+				t.articleTop = 0
+				t.setRead(true)
+			}
+		}
+	case "m":
+		if post, ok := t.selectedPost(); ok {
+			t.setRead(!post.Read)
+		}
+	case "s":
+		t.toggleStar()
+	case "o":
+		t.openLink()
+	case "u":
+		t.unreadOnly = !t.unreadOnly
+		t.posts, t.postIndex, t.postTop = nil, 0, 0
+		t.reportErr(t.reload())
+	case "r":
+		t.reportErr(t.reload())
+		if t.status == "" {
+			t.status = "Refreshed"
+		}
+	}
+	return true
+}
+
+func (t *tui) pageSize() int {
+	return max(1, t.postsHeight()-1)
+}
+
+func (t *tui) move(delta int) {
+	switch t.focus {
+	case paneFeeds:
+		index := clamp(t.feedIndex+delta, 0, len(t.feeds))
+		if index != t.feedIndex {
+			t.feedIndex = index
+			t.posts, t.postIndex, t.postTop = nil, 0, 0
+			t.reportErr(t.loadPosts())
+		}
+	case panePosts:
+		t.postIndex = clamp(t.postIndex+delta, 0, len(t.posts)-1)
+		t.articleTop = 0
+	case paneArticle:
+		t.articleTop = max(0, t.articleTop+delta)
+	}
+}
+
+func clamp(n, low, high int) int {
+	if high < low {
+		return low
+	}
+	return max(low, min(n, high))
+}
+
+func (t *tui) reportErr(err error) {
+	if err != nil {
+		t.status = fmt.Sprintf("Error: %v", err)
+	}
+}
+
+// reload refreshes the feed list and the posts of the selected feed, keeping
+// the selection on the same feed and post where they still exist.
+func (t *tui) reload() error {
+	ctx := context.Background()
+	selectedFeed := t.selectedFeedURL()
+	feeds, err := t.s.db.GetFollowedFeedsWithUnread(ctx, t.user.ID)
+	if err != nil {
+		return err
+	}
+	t.feeds = feeds
+	t.feedIndex = 0
+	for i, feed := range feeds {
+		if feed.Url == selectedFeed {
+			t.feedIndex = i + 1
+		}
+	}
+	return t.loadPosts()
+}
+
+func (t *tui) loadPosts() error {
+	selectedPost, hadSelection := t.selectedPost()
+	posts, err := database.GetPostsForUser(context.Background(), t.s.db, database.GetPostsForUserParams{
+		UserID:     t.user.ID,
+		FeedUrl:    optionalString(t.selectedFeedURL()),
+		UnreadOnly: t.unreadOnly,
+		Limit:      tuiPostLimit,
+	})
+	if err != nil {
+		return err
+	}
+	t.posts = posts
+	if hadSelection {
+		for i, post := range posts {
+			if post.ID == selectedPost.ID {
+				t.postIndex = i
+			}
+		}
+	}
+	t.postIndex = clamp(t.postIndex, 0, len(t.posts)-1)
+	return nil
+}
+
+func (t *tui) selectedFeedURL() string {
+	if t.feedIndex == 0 || t.feedIndex > len(t.feeds) {
+		return ""
+	}
+	return t.feeds[t.feedIndex-1].Url
+}
+
+func (t *tui) selectedPost() (database.GetPostsForUserRow, bool) {
+	if t.postIndex < 0 || t.postIndex >= len(t.posts) {
+		return database.GetPostsForUserRow{}, false
+	}
+	return t.posts[t.postIndex], true
+}
+
+func (t *tui) setRead(read bool) {
+	post, ok := t.selectedPost()
+	if !ok || post.Read == read {
+		return
+	}
+	if err := setPostRead(context.Background(), t.s, t.user.ID, post.ID, read); err != nil {
+		t.reportErr(err)
+		return
+	}
+	t.posts[t.postIndex].Read = read
+	for i := range t.feeds {
+		// Aliases need not be unique, so the feed is found by ID.
+		if t.feeds[i].ID == post.FeedID {
+			if read {
+				t.feeds[i].Unread--
+			} else {
+				t.feeds[i].Unread++
+			}
+			break
+		}
+	}
+}
+
+func (t *tui) toggleStar() {
+	post, ok := t.selectedPost()
+	if !ok {
+		return
+	}
+	if err := setPostStarred(context.Background(), t.s, t.user.ID, post.ID, !post.Starred); err != nil {
+		t.reportErr(err)
+		return
+	}
+	t.posts[t.postIndex].Starred = !post.Starred
+}
+
+// openLink opens the selected post with $BROWSER, falling back to the
+// platform's default opener.  A %s in $BROWSER is replaced by the URL.
+func (t *tui) openLink() {
+	post, ok := t.selectedPost()
+	if !ok {
+		return
+	}
+	browser := strings.Split(os.Getenv("BROWSER"), ":")[0]
+	if browser == "" {
+		browser = "xdg-open"
+		if runtime.GOOS == "darwin" {
+			browser = "open"
+		}
+	}
+	args := strings.Fields(browser)
+	if strings.Contains(browser, "%s") {
+		for i := range args {
+			args[i] = strings.ReplaceAll(args[i], "%s", post.Url)
+		}
+	} else {
+		args = append(args, post.Url)
+	}
+	if err := exec.Command(args[0], args[1:]...).Start(); err != nil {
+		t.reportErr(err)
+		return
+	}
+	t.status = "Opened " + post.Url
+	t.setRead(true)
+}
+
+// Layout: a header line, the feed list down the left, the post list above
+// the article on the right, and a status line at the bottom.
+func (t *tui) feedsWidth() int {
+	return min(max(20, t.width/4), 40)
+}
+
+func (t *tui) postsHeight() int {
+	return max(3, (t.height-3)*2/5)
+}
+
+func (t *tui) render() {
+	w, h, err := term.GetSize(int(os.Stdout.Fd()))
+	if err == nil {
+		t.width, t.height = w, h
+	}
+	if t.width < 40 || t.height < 10 {
+		fmt.Print("\x1b[H\x1b[2JTerminal too small")
+		return
+	}
+	bodyHeight := t.height - 2
+	leftWidth := t.feedsWidth()
+	rightWidth := t.width - leftWidth - 1
+	left := t.feedLines(leftWidth, bodyHeight)
+	right := t.postLines(rightWidth, t.postsHeight())
+	right = append(right, paneTitle("Article", rightWidth, t.focus == paneArticle))
+	right = append(right, t.articleLines(rightWidth, bodyHeight-len(right))...)
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	title := fmt.Sprintf(" gator - %s", t.user.Name)
+	if t.unreadOnly {
+		title += " (unread only)"
+	}
+	b.WriteString("\x1b[7m" + fit(title, t.width) + "\x1b[0m\r\n")
+	for i := 0; i < bodyHeight; i++ {
+		b.WriteString(lineAt(left, i, leftWidth))
+		b.WriteString("│")
+		b.WriteString(lineAt(right, i, rightWidth))
+		b.WriteString("\r\n")
+	}
+	status := t.status
+	if status == "" {
+		status = "tab/h/l pane  j/k move  enter open  m read  s star  o browser  u unread  r refresh  q quit"
+	}
+	b.WriteString("\x1b[7m" + fit(" "+status, t.width) + "\x1b[0m")
+	fmt.Print(b.String())
+}
+
+func (t *tui) feedLines(width, height int) []string {
+	lines := []string{paneTitle("Feeds", width, t.focus == paneFeeds)}
+	var total int64
+	for _, feed := range t.feeds {
+		total += feed.Unread
+	}
+	entries := []string{fmt.Sprintf("All feeds (%d)", total)}
+	for _, feed := range t.feeds {
+		entries = append(entries, fmt.Sprintf("%s (%d)", feed.Name, feed.Unread))
+	}
+	t.feedTop = scrollTo(t.feedTop, t.feedIndex, height-1)
+	for i := t.feedTop; i < len(entries) && len(lines) < height; i++ {
+		lines = append(lines, highlight(fit(" "+cleanLine(entries[i]), width), i == t.feedIndex))
+	}
+	return lines
+}
+
+func (t *tui) postLines(width, height int) []string {
+	lines := []string{paneTitle("Posts", width, t.focus == panePosts)}
+	if len(t.posts) == 0 {
+		return append(lines, fit(" No posts", width))
+	}
+	t.postTop = scrollTo(t.postTop, t.postIndex, height-1)
+	for i := t.postTop; i < len(t.posts) && len(lines) < height; i++ {
+		post := t.posts[i]
+		marker := " "
+		if post.Starred {
+			marker = "*"
+		}
+		line := fit(fmt.Sprintf("%s%s  %s", marker, post.SortDate.Format("Jan 02"), cleanLine(post.Title)), width)
+		if !post.Read {
+			line = "\x1b[1m" + line + "\x1b[0m"
+		}
+		lines = append(lines, highlight(line, i == t.postIndex))
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+func (t *tui) articleLines(width, height int) []string {
+	post, ok := t.selectedPost()
+	if !ok {
+		return nil
+	}
+	meta := cleanLine(post.FeedName) + " | " + formatTime(post.SortDate)
+	if post.Author.String != "" {
+		meta += " | " + cleanLine(post.Author.String)
+	}
+	if post.Starred {
+		meta += " | starred"
+	}
+	text := []string{"\x1b[1m" + fit(" "+cleanLine(post.Title), width) + "\x1b[0m", fit(" "+meta, width), fit(" "+cleanLine(post.Url), width), ""}
+	for _, line := range htmlText(post.Description.String, post.Url, width-2) {
+		text = append(text, fit(" "+line, width))
+	}
+	t.articleTop = clamp(t.articleTop, 0, len(text)-height)
+	end := min(len(text), t.articleTop+height)
+	return text[t.articleTop:end]
+}
+
+// scrollTo adjusts a pane's first visible row so the selected row is shown.
+func scrollTo(top, selected, height int) int {
+	if selected < top {
+		return selected
+	}
+	if selected >= top+height {
+		return selected - height + 1
+	}
+	return top
+}
+
+func paneTitle(title string, width int, focused bool) string {
+	line := fit(" "+title, width)
+	if focused {
+		return "\x1b[1;4m" + line + "\x1b[0m"
+	}
+	return "\x1b[4m" + line + "\x1b[0m"
+}
+
+func highlight(line string, selected bool) string {
+	if selected {
+		return "\x1b[7m" + line + "\x1b[0m"
+	}
+	return line
+}
+
+func lineAt(lines []string, i, width int) string {
+	if i < len(lines) && lines[i] != "" {
+		return lines[i]
+	}
+	return strings.Repeat(" ", width)
+}
+
+// fit truncates or pads s to exactly width columns.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if displayWidth(s) > width {
+		s, _ = cutWidth(s, width-1)
+		if displayWidth(s) > width-1 {
+			// A wide character does not fit next to the ellipsis.
+			s = ""
+		}
+		s += "…"
+	}
+	return s + strings.Repeat(" ", width-displayWidth(s))
+}
+
+// cleanLine removes control characters that would break the layout.
+func cleanLine(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// wrapText breaks text into lines of at most width columns, keeping the
+// paragraph breaks in the original.
+func wrapText(text string, width int) []string {
+	width = max(1, width)
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			if len(lines) > 0 && lines[len(lines)-1] != "" {
+				lines = append(lines, "")
+			}
+			continue
+		}
+		line := ""
+		for _, word := range words {
+			for displayWidth(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				var head string
+				head, word = cutWidth(word, width)
+				lines = append(lines, head)
+			}
+			switch {
+			case line == "":
+				line = word
+			case displayWidth(line)+1+displayWidth(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/interyx/gator/internal/database"
+)
+
+// `gator user` manages single accounts.  Members may look at and rename
+// their own account; admins may do so for anyone, and only admins may
+// delete accounts.
+
+var userSubcommands = map[string]func(fs *flag.FlagSet){
+	"show":   nil,
+	"rename": nil,
+	"delete": func(fs *flag.FlagSet) {
+		fs.String("transfer-to", "", "on delete, give the user's feeds to this `user` (default: you)")
+		yesFlag(fs)
+	},
+}
+
+func handlerUser(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return cmd.usageError("Expected a subcommand: show, rename or delete.")
+	}
+	sub := cmd.args[0]
+	cmd.args = cmd.args[1:]
+	switch sub {
+	case "show":
+		return handlerUserShow(s, cmd, user)
+	case "rename":
+		return handlerUserRename(s, cmd, user)
+	case "delete":
+		return handlerUserDelete(s, cmd, user)
+	}
+	return cmd.usageError(fmt.Sprintf("Unknown subcommand %q; use show, rename or delete.", sub))
+}
+
+// userForCommand looks up the user named on the command line, who must be
+// the current user unless they are an admin.
+func userForCommand(ctx context.Context, s *state, current database.User, name string) (database.User, error) {
+	if name == current.Name {
+		return current, nil
+	}
+	if current.Role != roleAdmin {
+		return database.User{}, errors.New("Only admins can manage other users")
+	}
+	user, err := s.db.GetUser(ctx, name)
+	if err != nil {
+		return database.User{}, fmt.Errorf("No user with that name found")
+	}
+	return user, nil
+}
+
+func handlerUserShow(s *state, cmd command, current database.User) error {
+	if len(cmd.args) > 1 {
+		return cmd.usageError("Too many arguments.")
+	}
+	ctx := context.Background()
+	user := current
+	if len(cmd.args) == 1 {
+		var err error
+		if user, err = userForCommand(ctx, s, current, cmd.args[0]); err != nil {
+			return err
+		}
+	}
+	stats, err := s.db.GetUserStats(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	owned, err := s.db.GetFeedsOwnedBy(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	password := "set"
+	if !user.PasswordHash.Valid {
+		password = "not set"
+	}
+	fmt.Fprintf(s.out, "%s\n", user.Name)
+	fmt.Fprintf(s.out, "  Role:        %s\n", user.Role)
+	fmt.Fprintf(s.out, "  Registered:  %s\n", formatTime(user.CreatedAt))
+	fmt.Fprintf(s.out, "  Password:    %s\n", password)
+	fmt.Fprintf(s.out, "  Following:   %d feeds\n", stats.Follows)
+	fmt.Fprintf(s.out, "  Unread:      %d posts\n", stats.Unread)
+	fmt.Fprintf(s.out, "  Starred:     %d posts\n", stats.Starred)
+	fmt.Fprintf(s.out, "  Feeds added: %d\n", stats.FeedsOwned)
+	for _, feed := range owned {
+		fmt.Fprintf(s.out, "    * %s (%s), %d followers\n", feed.Name, feed.Url, feed.Followers)
+	}
+	return nil
+}
+
+func handlerUserRename(s *state, cmd command, current database.User) error {
+	if len(cmd.args) != 2 {
+		return cmd.usageError("Expected the user's current and new names.")
+	}
+	ctx := context.Background()
+	user, err := userForCommand(ctx, s, current, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	newName := cmd.args[1]
+	err = s.db.RenameUser(ctx, database.RenameUserParams{
+		ID:   user.ID,
+		Name: newName,
+	})
+	if err != nil {
+		return fmt.Errorf("Could not rename %s: %v", user.Name, err)
+	}
+	if user.ID == current.ID {
+		if err := s.cfg.SetUser(newName); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(s.out, "%s is now called %s\n", user.Name, newName)
+	if user.FeverKeyHash.Valid {
+		fmt.Fprintln(s.out, "Fever apps are signed out until the API key is rotated with `gator apikey --rotate`")
+	}
+	return nil
+}
+
+func handlerUserDelete(s *state, cmd command, admin database.User) error {
+	if len(cmd.args) != 1 {
+		return cmd.usageError("Expected the name of the user to delete.")
+	}
+	if admin.Role != roleAdmin {
+		return errors.New("Only admins can delete users")
+	}
+	ctx := context.Background()
+	user, err := s.db.GetUser(ctx, cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("No user with that name found")
+	}
+	if user.ID == admin.ID {
+		return errors.New("You cannot delete yourself; ask another admin")
+	}
+	heir := admin
+	if name := cmd.flagString("transfer-to"); name != "" {
+		if heir, err = s.db.GetUser(ctx, name); err != nil {
+			return fmt.Errorf("No user named %s to transfer the feeds to", name)
+		}
+		if heir.ID == user.ID {
+			return cmd.usageError("The feeds cannot be transferred to the user being deleted.")
+		}
+	}
+
+	stats, err := s.db.GetUserStats(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	owned, err := s.db.GetFeedsOwnedBy(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "Deleting %s will:\n", user.Name)
+	fmt.Fprintf(s.out, "  * remove their %d follows, %d stars and their reading history\n", stats.Follows, stats.Starred)
+	fmt.Fprintf(s.out, "  * transfer the %d feeds they added to %s\n", len(owned), heir.Name)
+	for _, feed := range owned {
+		fmt.Fprintf(s.out, "      %s (%s)\n", feed.Name, feed.Url)
+	}
+	ok, err := confirm(s, cmd, fmt.Sprintf("Delete %s?", user.Name))
+	if err != nil || !ok {
+		return err
+	}
+
+	err = withTx(ctx, s, func(q database.Querier) error {
+		// Feeds would otherwise go with their owner, and take every other
+		// follower's posts with them.
+		_, err := q.TransferFeeds(ctx, database.TransferFeedsParams{
+			ToUserID:   heir.ID,
+			FromUserID: user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		return q.DeleteUser(ctx, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "Deleted %s\n", user.Name)
+	return nil
+}
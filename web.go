@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/interyx/gator/internal/database"
+)
+
+// The web reader is a server-rendered counterpart to the CLI for people who
+// would rather not use a terminal.  It is served by `gator serve` next to the
+// JSON API and uses the same shared operations.
+//
+// Users sign in with their name and password; the session token is kept in
+// an HttpOnly, SameSite=Strict cookie, which also keeps other sites from
+// posting forms on the user's behalf.
+
+//go:embed web/templates
+var webTemplates embed.FS
+
+const sessionCookie = "gator_session"
+const webPageSize = 25
+
+type webUI struct {
+	s     *state
+	pages map[string]*template.Template
+}
+
+// page is the data every template receives; Data holds the page's own.
+type page struct {
+	Title string
+	User  *database.User
+	Error string
+	Data  any
+}
+
+type postsData struct {
+	Query   postQuery
+	Feeds   []database.GetFollowedFeedsWithUnreadRow
+	Folders []string
+	Posts   []postRecord
+	Next    string
+	Return  string
+}
+
+type feedsData struct {
+	Following []database.GetFollowedFeedsWithUnreadRow
+	Others    []database.GetAllFeedsRow
+	Name      string
+	URL       string
+}
+
+func newWebUI(s *state) *webUI {
+	funcs := template.FuncMap{
+		"formatTime": formatTime,
+		"sanitize":   sanitizedHTML,
+	}
+	ui := &webUI{s: s, pages: map[string]*template.Template{}}
+	for _, name := range []string{"login", "posts", "feeds", "error"} {
+		ui.pages[name] = template.Must(template.New("layout.html").Funcs(funcs).ParseFS(webTemplates,
+			"web/templates/layout.html", "web/templates/"+name+".html"))
+	}
+	return ui
+}
+
+func (ui *webUI) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/posts", http.StatusSeeOther)
+	})
+	mux.HandleFunc("GET /login", ui.handleLoginPage)
+	mux.HandleFunc("POST /login", ui.handleLogin)
+	mux.HandleFunc("POST /logout", ui.handleLogout)
+	mux.HandleFunc("GET /posts", ui.signedIn(ui.handlePosts))
+	mux.HandleFunc("POST /posts/{id}/read", ui.signedIn(ui.handlePostState(setPostRead)))
+	mux.HandleFunc("POST /posts/{id}/star", ui.signedIn(ui.handlePostState(setPostStarred)))
+	mux.HandleFunc("GET /feeds", ui.signedIn(ui.handleFeeds))
+	mux.HandleFunc("POST /feeds", ui.signedIn(ui.handleAddFeed))
+	mux.HandleFunc("POST /follows", ui.signedIn(ui.handleFollow))
+	mux.HandleFunc("POST /follows/delete", ui.signedIn(ui.handleUnfollow))
+}
+
+// signedIn is the web reader's counterpart to authenticated: it reads the
+// session token from the cookie and sends visitors without a valid one to
+// the login page.
+func (ui *webUI) signedIn(handler authedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+		if err != nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		user, err := userForSession(r.Context(), ui.s, cookie.Value)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		if err != nil {
+			ui.renderDBError(w, nil, err)
+			return
+		}
+		handler(w, r, user)
+	}
+}
+
+func (ui *webUI) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	ui.render(w, http.StatusOK, "login", page{Title: "Log in"})
+}
+
+func (ui *webUI) handleLogin(w http.ResponseWriter, r *http.Request) {
+	name := r.PostFormValue("name")
+	user, err := checkPassword(r.Context(), ui.s, name, r.PostFormValue("password"))
+	if errors.Is(err, errBadLogin) || errors.Is(err, errNoPassword) {
+		ui.render(w, http.StatusUnauthorized, "login", page{
+			Title: "Log in",
+			Error: err.Error(),
+			Data:  name,
+		})
+		return
+	}
+	if err != nil {
+		ui.renderDBError(w, nil, err)
+		return
+	}
+	token, expires, err := startSession(r.Context(), ui.s, user)
+	if err != nil {
+		ui.renderDBError(w, nil, err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/posts", http.StatusSeeOther)
+}
+
+func (ui *webUI) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		if err := endSession(r.Context(), ui.s, cookie.Value); err != nil {
+			ui.renderDBError(w, nil, err)
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (ui *webUI) handlePosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	query := postQueryFromValues(r.URL.Query(), webPageSize)
+	data := postsData{Query: query, Return: r.URL.RequestURI()}
+	feeds, err := ui.s.db.GetFollowedFeedsWithUnread(r.Context(), user.ID)
+	if err != nil {
+		ui.renderDBError(w, &user, err)
+		return
+	}
+	data.Feeds = feeds
+	data.Folders, err = ui.s.db.GetFollowFolders(r.Context(), user.ID)
+	if err != nil {
+		ui.renderDBError(w, &user, err)
+		return
+	}
+	p := page{Title: "Posts", User: &user, Data: &data}
+
+	params, err := query.params(user.ID)
+	if err != nil {
+		p.Error = err.Error()
+		ui.render(w, http.StatusBadRequest, "posts", p)
+		return
+	}
+	rows, err := database.GetPostsForUser(r.Context(), ui.s.db, params)
+	if err != nil {
+		ui.renderDBError(w, &user, err)
+		return
+	}
+	for _, row := range rows {
+		data.Posts = append(data.Posts, newPostRecord(row))
+	}
+	if len(rows) == query.Limit {
+		next := r.URL.Query()
+		next.Set("after", data.Posts[len(data.Posts)-1].Cursor)
+		data.Next = "/posts?" + next.Encode()
+	}
+	ui.render(w, http.StatusOK, "posts", p)
+}
+
+// handlePostState serves the read and star buttons.  The form's value field
+// says which way to set the flag.
+func (ui *webUI) handlePostState(set func(context.Context, *state, uuid.UUID, uuid.UUID, bool) error) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) {
+		postID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			ui.renderError(w, &user, http.StatusBadRequest, "Invalid post id")
+			return
+		}
+		if err := set(r.Context(), ui.s, user.ID, postID, r.PostFormValue("value") == "true"); err != nil {
+			ui.renderDBError(w, &user, err)
+			return
+		}
+		redirectBack(w, r, "/posts")
+	}
+}
+
+func (ui *webUI) handleFeeds(w http.ResponseWriter, r *http.Request, user database.User) {
+	ui.showFeeds(w, r, user, http.StatusOK, "", feedsData{})
+}
+
+func (ui *webUI) handleAddFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	form := feedsData{
+		Name: strings.TrimSpace(r.PostFormValue("name")),
+		URL:  strings.TrimSpace(r.PostFormValue("url")),
+	}
+	if form.Name == "" {
+		ui.showFeeds(w, r, user, http.StatusBadRequest, "A feed needs a name", form)
+		return
+	}
+	_, _, err := addFeed(r.Context(), ui.s, user, form.Name, form.URL)
+	if errors.Is(err, errInvalidURL) {
+		ui.showFeeds(w, r, user, http.StatusBadRequest, err.Error(), form)
+		return
+	}
+	if err != nil {
+		code, msg := dbErrorStatus(err)
+		ui.showFeeds(w, r, user, code, msg, form)
+		return
+	}
+	http.Redirect(w, r, "/feeds", http.StatusSeeOther)
+}
+
+func (ui *webUI) handleFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	if _, err := followFeed(r.Context(), ui.s, user, r.PostFormValue("url")); err != nil {
+		code, msg := dbErrorStatus(err)
+		ui.showFeeds(w, r, user, code, msg, feedsData{})
+		return
+	}
+	http.Redirect(w, r, "/feeds", http.StatusSeeOther)
+}
+
+func (ui *webUI) handleUnfollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := unfollowFeed(r.Context(), ui.s, user, r.PostFormValue("url")); err != nil {
+		code, msg := dbErrorStatus(err)
+		ui.showFeeds(w, r, user, code, msg, feedsData{})
+		return
+	}
+	http.Redirect(w, r, "/feeds", http.StatusSeeOther)
+}
+
+// showFeeds renders the feeds page; form carries what the user typed into
+// the add feed form when it is shown again with an error.
+func (ui *webUI) showFeeds(w http.ResponseWriter, r *http.Request, user database.User, code int, msg string, form feedsData) {
+	following, err := ui.s.db.GetFollowedFeedsWithUnread(r.Context(), user.ID)
+	if err != nil {
+		ui.renderDBError(w, &user, err)
+		return
+	}
+	all, err := ui.s.db.GetAllFeeds(r.Context())
+	if err != nil {
+		ui.renderDBError(w, &user, err)
+		return
+	}
+	followed := make(map[string]bool, len(following))
+	for _, feed := range following {
+		followed[feed.Url] = true
+	}
+	for _, feed := range all {
+		if !followed[feed.Url] {
+			form.Others = append(form.Others, feed)
+		}
+	}
+	form.Following = following
+	ui.render(w, code, "feeds", page{Title: "Feeds", User: &user, Error: msg, Data: &form})
+}
+
+func (ui *webUI) render(w http.ResponseWriter, code int, name string, p page) {
+	var buf strings.Builder
+	if err := ui.pages[name].Execute(&buf, p); err != nil {
+		log.Printf("Error rendering %s: %v", name, err)
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write([]byte(buf.String()))
+}
+
+func (ui *webUI) renderError(w http.ResponseWriter, user *database.User, code int, msg string) {
+	ui.render(w, code, "error", page{Title: http.StatusText(code), User: user, Error: msg})
+}
+
+func (ui *webUI) renderDBError(w http.ResponseWriter, user *database.User, err error) {
+	code, msg := dbErrorStatus(err)
+	ui.renderError(w, user, code, msg)
+}
+
+// redirectBack returns to the page named by the form's return field, as long
+// as it is a path on this site.
+func redirectBack(w http.ResponseWriter, r *http.Request, fallback string) {
+	target := r.PostFormValue("return")
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(target, "/") ||
+		strings.HasPrefix(target, "//") || strings.Contains(target, "\\") {
+		target = fallback
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
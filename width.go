@@ -0,0 +1,82 @@
+package main
+
+import (
+	"slices"
+	"unicode"
+)
+
+// Terminals give most characters one column, but CJK characters and most
+// emoji two, and combining marks none.  displayWidth measures text in
+// columns so the TUI's layout holds with any of them.  Emoji joined into
+// one glyph by zero-width joiners are counted as their parts, which some
+// terminals also do.
+
+// wideRunes are the ranges of characters that take two columns: the East
+// Asian Wide and Fullwidth characters of Unicode, which include emoji.
+var wideRunes = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18CD5}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F202}, {0x1F210, 0x1F23B},
+	{0x1F240, 0x1F248}, {0x1F250, 0x1F251}, {0x1F260, 0x1F265}, {0x1F300, 0x1F3FA},
+	{0x1F400, 0x1F64F}, {0x1F680, 0x1F6FF}, {0x1F7E0, 0x1F7EB}, {0x1F90C, 0x1F9FF},
+	{0x1FA70, 0x1FAFF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// runeWidth is the number of columns r takes.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf), // combining marks, joiners
+		r >= 0x1160 && r <= 0x11FF,   // Hangul vowels and finals join the syllable
+		r >= 0x1F3FB && r <= 0x1F3FF: // skin tones modify the emoji before them
+		return 0
+	case r < 0x1100:
+		return 1
+	}
+	_, wide := slices.BinarySearchFunc(wideRunes, r, func(span [2]rune, r rune) int {
+		switch {
+		case span[1] < r:
+			return -1
+		case span[0] > r:
+			return 1
+		}
+		return 0
+	})
+	if wide {
+		return 2
+	}
+	return 1
+}
+
+// displayWidth is the number of columns s takes.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// cutWidth splits s after as many columns as fit in width, keeping at least
+// one character in the head so that callers always make progress.
+func cutWidth(s string, width int) (head, tail string) {
+	n := 0
+	for i, r := range s {
+		w := runeWidth(r)
+		if n+w > width && i > 0 {
+			return s[:i], s[i:]
+		}
+		n += w
+	}
+	return s, ""
+}